@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/warmans/fakt-api/pkg/server"
+	"github.com/warmans/fakt-api/pkg/server/data"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+type crawlFlags struct {
+	sourceName string
+	dryRun     bool
+}
+
+func parseCrawlFlags(args []string, out io.Writer) (*crawlFlags, error) {
+	f := &crawlFlags{}
+	flags := flag.NewFlagSet("crawl", flag.ContinueOnError)
+	flags.SetOutput(out)
+	flags.StringVar(&f.sourceName, "source", "", "Name of the crawler to run. If empty all crawlers are run")
+	flags.BoolVar(&f.dryRun, "dry-run", false, "Print events as JSON lines instead of storing them")
+	return f, flags.Parse(args)
+}
+
+// isDryRun is true if the arguments are for a crawl that must not change the DB.
+func isDryRun(args []string) bool {
+	if len(args) == 0 || args[0] != "crawl" {
+		return false
+	}
+	f, err := parseCrawlFlags(args[1:], ioutil.Discard)
+	return err == nil && f.dryRun
+}
+
+// crawl performs a single ingest pass and prints the outcome of each crawler. With --dry-run nothing is stored and
+// each event is printed as JSON instead (one per line). It returns the process exit code.
+func crawl(ctx context.Context, srv *server.Server, args []string, out io.Writer) int {
+
+	flags, err := parseCrawlFlags(args, out)
+	if err != nil {
+		return 2
+	}
+
+	if flags.dryRun {
+		ingest, err := srv.NewDryRunIngest()
+		if err != nil {
+			fmt.Fprintf(out, "Failed to create ingest: %s\n", err.Error())
+			return 1
+		}
+		return dryRunCrawl(ctx, ingest, flags.sourceName, out)
+	}
+
+	ingest, err := srv.NewIngest()
+	if err != nil {
+		fmt.Fprintf(out, "Failed to create ingest: %s\n", err.Error())
+		return 1
+	}
+
+	runs, err := ingest.CrawlNow(ctx, flags.sourceName)
+	if err != nil {
+		fmt.Fprintf(out, "Crawl failed: %s\n", err.Error())
+		return 1
+	}
+
+	code := 0
+	for _, run := range runs {
+		printRun(out, run)
+		if !run.Succeeded {
+			code = 1
+		}
+	}
+	return code
+}
+
+func dryRunCrawl(ctx context.Context, ingest *data.Ingest, sourceName string, out io.Writer) int {
+
+	code := 0
+	enc := json.NewEncoder(out)
+	err := ingest.DryRun(ctx, sourceName, func(result *data.DryRunResult) {
+		if result.Error != "" {
+			code = 1
+		}
+		if err := enc.Encode(result); err != nil {
+			code = 1
+		}
+	})
+	if err != nil {
+		fmt.Fprintf(out, "Crawl failed: %s\n", err.Error())
+		return 1
+	}
+	return code
+}
+
+func printRun(out io.Writer, run *common.CrawlRun) {
+	status := "OK"
+	if !run.Succeeded {
+		status = "FAILED"
+	}
+	fmt.Fprintf(
+		out,
+		"%s %s: discovered %d, inserted %d, updated %d, rejected %d (took %s)\n",
+		status,
+		run.Crawler,
+		run.Discovered,
+		run.Inserted,
+		run.Updated,
+		run.Rejected,
+		run.EndTime.Sub(run.StartTime),
+	)
+	for _, msg := range run.Errors {
+		fmt.Fprintf(out, "  %s\n", msg)
+	}
+}
@@ -1,23 +1,37 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/rubenv/sql-migrate"
 	"github.com/warmans/dbr"
 	"github.com/warmans/fakt-api/pkg/server"
+	"github.com/warmans/fakt-api/pkg/server/data/source"
 	"go.uber.org/zap"
 )
 
 var (
 	serverBind             = flag.String("server.bind", ":8080", "Web server bind address")
 	serverEncryptionKey    = flag.String("server.encryption.key", "changeme91234567890123456789012", "Key used to create sessions")
+	serverAdminToken       = flag.String("server.admin.token", "", "Bearer token required by the admin API. If empty the admin API is disabled")
+	serverShutdownTimeout  = flag.Duration("server.shutdown-timeout", server.DefaultShutdownTimeout, "Maximum time to wait for in-flight requests when stopping")
 	crawlerStressfaktorURI = flag.String("crawler.stressfaktor.uri", "https://stressfaktor.squat.net/termine.php?display=30", "Address of termine page")
 	crawlerLocation        = flag.String("crawler.location", "Europe/Berlin", "Time localization")
+	crawlerConfigPath      = flag.String("crawler.config", "", "Location of crawler config file (JSON only, YAML is not supported). If empty the stressfaktor and k9 crawlers are used")
 	crawlerRun             = flag.Bool("crawler.run", true, "Periodically ingest new data")
+	crawlerConcurrency     = flag.Int("crawler.concurrency", 2, "Maximum number of crawlers to run at once")
+	crawlerMaxBackoff      = flag.Duration("crawler.max-backoff", time.Hour*24, "Maximum delay for a failing crawler")
+	crawlerUserAgent       = flag.String("crawler.user-agent", source.DefaultUserAgent, "User-Agent sent by crawlers and enrichers")
+	crawlerInterval        = flag.Duration("crawler.request-interval", source.DefaultRequestInterval, "Minimum time between requests to the same host")
+	crawlerHTTPTimeout     = flag.Duration("crawler.http-timeout", source.DefaultRequestTimeout, "Maximum duration of a single HTTP request")
+	crawlerCacheDir        = flag.String("crawler.cache-dir", "", "Location to cache HTTP responses. If empty responses are not cached")
 	dbPath                 = flag.String("db.path", "./db.sqlite3", "Location of DB file")
 	verbose                = flag.Bool("log.verbose", false, "Verbose logging")
 	staticFilesPath        = flag.String("static.path", "static", "Location to store static files")
@@ -35,12 +49,28 @@ func main() {
 		os.Exit(0)
 	}
 
+	os.Exit(run())
+}
+
+// run starts the server or runs a sub-command and returns the exit code. Exiting is left to main so the DB is
+// closed and logs are flushed first.
+func run() int {
+
 	config := &server.Config{
 		ServerBind:             *serverBind,
 		ServerLocation:         *crawlerLocation,
 		CrawlerStressfaktorURI: *crawlerStressfaktorURI,
+		CrawlerConfigPath:      *crawlerConfigPath,
 		CrawlerRun:             *crawlerRun,
+		CrawlerConcurrency:     *crawlerConcurrency,
+		CrawlerMaxBackoff:      *crawlerMaxBackoff,
+		CrawlerUserAgent:       *crawlerUserAgent,
+		CrawlerInterval:        *crawlerInterval,
+		CrawlerHTTPTimeout:     *crawlerHTTPTimeout,
+		CrawlerCacheDir:        *crawlerCacheDir,
 		EncryptionKey:          *serverEncryptionKey,
+		AdminToken:             *serverAdminToken,
+		ShutdownTimeout:        *serverShutdownTimeout,
 		VerboseLogging:         *verbose,
 		StaticFilesPath:        *staticFilesPath,
 	}
@@ -48,24 +78,68 @@ func main() {
 	logger, err := zap.NewProduction()
 	if err != nil {
 		fmt.Println("Failed to create logger")
-		os.Exit(1)
+		return 1
 	}
 	defer logger.Sync()
 
 	db, err := dbr.Open("sqlite3", *dbPath, nil)
 	if err != nil {
-		logger.Fatal("Failed to open DB", zap.Error(err))
+		logger.Error("Failed to open DB", zap.Error(err))
+		return 1
 	}
 	defer db.Close()
 
-	// apply migrations on startup
-	if !*migrationsDisabled {
+	// apply migrations on startup (a dry run must leave the DB as it is)
+	if !*migrationsDisabled && !isDryRun(flag.Args()) {
 		n, err := migrate.Exec(db.DB, "sqlite3", &migrate.FileMigrationSource{Dir: *migrationsPath}, migrate.Up)
 		if err != nil {
-			logger.Fatal("Migrations failed", zap.Error(err))
+			logger.Error("Migrations failed", zap.Error(err))
+			return 1
 		}
 		logger.Info("Applied  migrations", zap.Int("num", n))
 	}
 
-	logger.Fatal("Server Exited", zap.Error(server.NewServer(config, logger, db).Start()))
+	srv := server.NewServer(config, logger, db)
+
+	ctx := stopOnSignal(logger)
+
+	// e.g. fakt-api crawl --source=stressfaktor
+	if flag.Arg(0) == "crawl" {
+		return crawl(ctx, srv, flag.Args()[1:], os.Stdout)
+	}
+
+	// e.g. fakt-api export --file=snapshot.jsonl
+	if flag.Arg(0) == "export" {
+		return exportSnapshot(ctx, srv, flag.Args()[1:], os.Stdout, os.Stderr)
+	}
+
+	// e.g. fakt-api import --file=snapshot.jsonl
+	if flag.Arg(0) == "import" {
+		return importSnapshot(ctx, srv, flag.Args()[1:], os.Stdin, os.Stderr)
+	}
+
+	if err := srv.Start(ctx); err != nil {
+		logger.Error("Server Exited", zap.Error(err))
+		return 1
+	}
+	logger.Info("Server stopped")
+	return 0
+}
+
+// stopOnSignal returns a context that is cancelled on SIGINT or SIGTERM. A second signal exits immediately.
+func stopOnSignal(logger *zap.Logger) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		logger.Info("Stopping", zap.String("signal", sig.String()))
+		cancel()
+
+		sig = <-signals
+		logger.Warn("Stopped without waiting", zap.String("signal", sig.String()))
+		os.Exit(1)
+	}()
+	return ctx
 }
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/warmans/fakt-api/pkg/server"
+	"github.com/warmans/fakt-api/pkg/server/data/snapshot"
+)
+
+// exportSnapshot writes the database as JSON lines to --file or stdout. Progress and errors are written to
+// log. It returns the process exit code.
+func exportSnapshot(ctx context.Context, srv *server.Server, args []string, stdout io.Writer, log io.Writer) int {
+
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(log)
+	path := flags.String("file", "", "File to write the export to. If empty it is written to stdout")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	out := stdout
+	if *path != "" {
+		f, err := os.Create(*path)
+		if err != nil {
+			fmt.Fprintf(log, "Failed to create export file: %s\n", err.Error())
+			return 1
+		}
+		defer f.Close()
+		out = f
+	}
+
+	counts, err := srv.NewSnapshot().Export(ctx, out)
+	if err != nil {
+		fmt.Fprintf(log, "Export failed: %s\n", err.Error())
+		return 1
+	}
+	printCounts(log, "Exported", counts)
+	return 0
+}
+
+// importSnapshot loads an export from --file or stdin. It returns the process exit code.
+func importSnapshot(ctx context.Context, srv *server.Server, args []string, stdin io.Reader, log io.Writer) int {
+
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(log)
+	path := flags.String("file", "", "File to import. If empty the export is read from stdin")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	in := stdin
+	if *path != "" {
+		f, err := os.Open(*path)
+		if err != nil {
+			fmt.Fprintf(log, "Failed to open import file: %s\n", err.Error())
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+
+	counts, err := srv.NewSnapshot().Import(ctx, in)
+	if err != nil {
+		fmt.Fprintf(log, "Import failed, nothing was imported: %s\n", err.Error())
+		return 1
+	}
+	printCounts(log, "Imported", counts)
+	return 0
+}
+
+func printCounts(out io.Writer, action string, counts snapshot.Counts) {
+	types := make([]string, 0, len(counts))
+	for t := range counts {
+		if t != snapshot.TypeHeader {
+			types = append(types, t)
+		}
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		fmt.Fprintf(out, "%s %d %s records\n", action, counts[t], t)
+	}
+}
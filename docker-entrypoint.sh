@@ -3,8 +3,17 @@ set -e
 
 : ${SERVER_BIND:=":8080"}
 : ${SERVER_ENCRYPTION_KEY:="changeme91234567890123456789012"}
+: ${SERVER_ADMIN_TOKEN:=""}
+: ${SERVER_SHUTDOWN_TIMEOUT:="30s"}
 : ${CRAWLER_STRESSFAKTOR_URI:="https://stressfaktor.squat.net/termine.php?days=all"}
 : ${CRAWLER_LOCATION:="Europe/Berlin"}
+: ${CRAWLER_CONFIG:=""}
+: ${CRAWLER_CONCURRENCY:=2}
+: ${CRAWLER_MAX_BACKOFF:="24h"}
+: ${CRAWLER_USER_AGENT:="fakt-api/1.0 (+https://github.com/warmans/fakt-api)"}
+: ${CRAWLER_REQUEST_INTERVAL:="2s"}
+: ${CRAWLER_HTTP_TIMEOUT:="30s"}
+: ${CRAWLER_CACHE_DIR:="/opt/fakt-api/cache"}
 : ${DB_PATH:="/opt/fakt-api/db/db.sqlite3"}
 : ${LOG_VERBOSE:=false}
 : ${MIGRATIONS_PATH:="/opt/fakt-api/migrations"}
@@ -14,15 +23,28 @@ if [ "$1" = 'fakt-api' ]; then
 
 	touch /var/log/fakt-api/out.log;
 	cd /opt/fakt-api/;
+
+	# exec via a fifo rather than a pipe so fakt-api receives SIGTERM and can shut down cleanly
+	rm -f /tmp/fakt-api.out && mkfifo /tmp/fakt-api.out;
+	tee /var/log/fakt-api/out.log < /tmp/fakt-api.out &
 	exec ./fakt-api \
 		-server.bind=${SERVER_BIND} \
 		-server.encryption.key=${SERVER_ENCRYPTION_KEY} \
+		-server.admin.token=${SERVER_ADMIN_TOKEN} \
+		-server.shutdown-timeout=${SERVER_SHUTDOWN_TIMEOUT} \
 		-crawler.stressfaktor.uri=${CRAWLER_STRESSFAKTOR_URI} \
 		-crawler.location=${CRAWLER_LOCATION} \
+		-crawler.config=${CRAWLER_CONFIG} \
+		-crawler.concurrency=${CRAWLER_CONCURRENCY} \
+		-crawler.max-backoff=${CRAWLER_MAX_BACKOFF} \
+		-crawler.user-agent="${CRAWLER_USER_AGENT}" \
+		-crawler.request-interval=${CRAWLER_REQUEST_INTERVAL} \
+		-crawler.http-timeout=${CRAWLER_HTTP_TIMEOUT} \
+		-crawler.cache-dir=${CRAWLER_CACHE_DIR} \
 		-db.path=${DB_PATH} \
 		-log.verbose=${LOG_VERBOSE} \
 		-migrations.path=${MIGRATIONS_PATH} \
-		-migrations.disabled=${MIGRATIONS_DISABLED} | tee /var/log/fakt-api/out.log 2>&1
+		-migrations.disabled=${MIGRATIONS_DISABLED} > /tmp/fakt-api.out
 fi
 
 exec "$@"
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS event_extra (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  event_id INTEGER,
+  link TEXT,
+  link_type TEXT NULL,
+  link_description TEXT NULL
+);
+
+-- +migrate Down
+
+DROP TABLE event_extra;
@@ -0,0 +1,20 @@
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS crawl_run (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  crawler TEXT,
+  start_time DATETIME,
+  end_time DATETIME NULL,
+  discovered INTEGER DEFAULT 0,
+  inserted INTEGER DEFAULT 0,
+  updated INTEGER DEFAULT 0,
+  rejected INTEGER DEFAULT 0,
+  succeeded BOOLEAN DEFAULT 0,
+  errors TEXT NULL
+);
+
+CREATE INDEX IF NOT EXISTS crawl_run_crawler ON crawl_run (crawler, start_time);
+
+-- +migrate Down
+
+DROP TABLE crawl_run;
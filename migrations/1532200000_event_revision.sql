@@ -0,0 +1,15 @@
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS event_revision (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  event_id INTEGER,
+  date DATETIME,
+  source TEXT NULL,
+  changes TEXT
+);
+
+CREATE INDEX IF NOT EXISTS event_revision_event ON event_revision (event_id);
+
+-- +migrate Down
+
+DROP TABLE event_revision;
@@ -0,0 +1,11 @@
+-- +migrate Up
+
+ALTER TABLE event ADD COLUMN status TEXT DEFAULT 'active';
+
+CREATE INDEX IF NOT EXISTS event_source_date ON event (source, date);
+
+-- +migrate Down
+
+DROP INDEX event_source_date;
+
+-- sqlite cannot drop the status column without rebuilding the table so it is left in place
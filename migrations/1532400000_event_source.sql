@@ -0,0 +1,17 @@
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS event_source (
+  event_id INTEGER,
+  source TEXT,
+  first_seen DATETIME,
+  last_seen DATETIME,
+  score REAL DEFAULT 1,
+  PRIMARY KEY (event_id, source)
+);
+
+INSERT OR IGNORE INTO event_source (event_id, source, first_seen, last_seen)
+  SELECT id, source, datetime('now'), datetime('now') FROM event WHERE source IS NOT NULL AND source != '';
+
+-- +migrate Down
+
+DROP TABLE event_source;
@@ -0,0 +1,13 @@
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS venue_alias (
+  alias TEXT PRIMARY KEY,
+  venue_id INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS venue_alias_venue ON venue_alias (venue_id);
+
+-- +migrate Down
+
+DROP INDEX venue_alias_venue;
+DROP TABLE venue_alias;
@@ -0,0 +1,14 @@
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS performer_alias (
+  alias TEXT,
+  performer_id INTEGER,
+  PRIMARY KEY (alias, performer_id)
+);
+
+CREATE INDEX IF NOT EXISTS performer_alias_performer ON performer_alias (performer_id);
+
+-- +migrate Down
+
+DROP INDEX performer_alias_performer;
+DROP TABLE performer_alias;
@@ -0,0 +1,8 @@
+-- +migrate Up
+
+ALTER TABLE event_performer ADD COLUMN extractor TEXT NULL;
+ALTER TABLE event_performer ADD COLUMN confidence REAL NULL;
+
+-- +migrate Down
+
+-- sqlite cannot drop the extractor and confidence columns without rebuilding the table so they are left in place
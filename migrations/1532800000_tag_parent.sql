@@ -0,0 +1,11 @@
+-- +migrate Up
+
+ALTER TABLE tag ADD COLUMN parent_id INTEGER NULL;
+
+CREATE INDEX IF NOT EXISTS tag_parent ON tag (parent_id);
+
+-- +migrate Down
+
+DROP INDEX tag_parent;
+
+-- sqlite cannot drop the parent_id column without rebuilding the table so it is left in place
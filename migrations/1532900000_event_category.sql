@@ -0,0 +1,11 @@
+-- +migrate Up
+
+ALTER TABLE event ADD COLUMN category TEXT NULL;
+
+CREATE INDEX IF NOT EXISTS event_category ON event (category);
+
+-- +migrate Down
+
+DROP INDEX event_category;
+
+-- sqlite cannot drop the category column without rebuilding the table so it is left in place
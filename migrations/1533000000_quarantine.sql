@@ -0,0 +1,18 @@
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS quarantine (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  source TEXT,
+  record_type TEXT,
+  payload TEXT,
+  reason TEXT,
+  event_id INTEGER NULL,
+  created DATETIME,
+  updated DATETIME NULL
+);
+
+CREATE INDEX IF NOT EXISTS quarantine_source ON quarantine (source, created);
+
+-- +migrate Down
+
+DROP TABLE quarantine;
@@ -0,0 +1,14 @@
+-- +migrate Up
+
+ALTER TABLE event ADD COLUMN end_date DATETIME NULL;
+ALTER TABLE event ADD COLUMN doors DATETIME NULL;
+ALTER TABLE event ADD COLUMN price_min REAL NULL;
+ALTER TABLE event ADD COLUMN price_max REAL NULL;
+ALTER TABLE event ADD COLUMN currency TEXT NULL;
+ALTER TABLE event ADD COLUMN is_free BOOLEAN DEFAULT 0;
+ALTER TABLE event ADD COLUMN donation_based BOOLEAN DEFAULT 0;
+ALTER TABLE event ADD COLUMN min_age INTEGER NULL;
+
+-- +migrate Down
+
+-- sqlite cannot drop the detail columns without rebuilding the table so they are left in place
@@ -0,0 +1,27 @@
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS festival (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL,
+  description TEXT NULL,
+  start_date DATETIME NOT NULL,
+  end_date DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS festival_name ON festival (name);
+
+ALTER TABLE event ADD COLUMN festival_id INTEGER NULL REFERENCES festival(id);
+
+CREATE INDEX IF NOT EXISTS event_festival ON event (festival_id);
+
+CREATE INDEX IF NOT EXISTS event_end_date ON event (end_date);
+
+-- +migrate Down
+
+DROP INDEX event_end_date;
+
+DROP INDEX event_festival;
+
+DROP TABLE festival;
+
+-- sqlite cannot drop the festival_id column without rebuilding the table so it is left in place
@@ -0,0 +1,29 @@
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS event_series (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  venue_id INTEGER NOT NULL REFERENCES venue(id),
+  weekday INTEGER NOT NULL,
+  time TEXT NOT NULL,
+  name TEXT NOT NULL,
+  description TEXT NULL,
+  num_events INTEGER NOT NULL DEFAULT 0,
+  first_date DATETIME NULL,
+  last_date DATETIME NULL,
+  created DATETIME NOT NULL,
+  updated DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS event_series_venue ON event_series (venue_id);
+
+ALTER TABLE event ADD COLUMN series_id INTEGER NULL REFERENCES event_series(id);
+
+CREATE INDEX IF NOT EXISTS event_series_id ON event (series_id);
+
+-- +migrate Down
+
+DROP INDEX event_series_id;
+
+DROP TABLE event_series;
+
+-- sqlite cannot drop the series_id column without rebuilding the table so it is left in place
@@ -0,0 +1,12 @@
+-- +migrate Up
+
+-- records quarantined before this have no hash so they aren't matched when the same record is rejected again
+ALTER TABLE quarantine ADD COLUMN payload_hash TEXT NULL;
+
+CREATE INDEX IF NOT EXISTS quarantine_payload ON quarantine (source, record_type, payload_hash);
+
+-- +migrate Down
+
+DROP INDEX quarantine_payload;
+
+-- sqlite cannot drop the payload_hash column without rebuilding the table so it is left in place
@@ -0,0 +1,8 @@
+-- +migrate Up
+
+-- the source that cancelled or postponed an event if it wasn't the event's own source
+ALTER TABLE event ADD COLUMN status_source TEXT NULL;
+
+-- +migrate Down
+
+-- sqlite cannot drop the status_source column without rebuilding the table so it is left in place
@@ -0,0 +1,8 @@
+-- +migrate Up
+
+-- runs recorded before this counted unchanged events as updated
+ALTER TABLE crawl_run ADD COLUMN unchanged INTEGER DEFAULT 0;
+
+-- +migrate Down
+
+-- sqlite cannot drop the unchanged column without rebuilding the table so it is left in place
@@ -6,8 +6,12 @@ import (
 	"github.com/gorilla/context"
 	"github.com/warmans/fakt-api/pkg/server/api.v1/handler"
 	mw "github.com/warmans/fakt-api/pkg/server/api.v1/middleware"
+	"github.com/warmans/fakt-api/pkg/server/data"
+	"github.com/warmans/fakt-api/pkg/server/data/store/crawlrun"
 	"github.com/warmans/fakt-api/pkg/server/data/store/event"
 	"github.com/warmans/fakt-api/pkg/server/data/store/performer"
+	"github.com/warmans/fakt-api/pkg/server/data/store/quarantine"
+	"github.com/warmans/fakt-api/pkg/server/data/store/series"
 	"github.com/warmans/fakt-api/pkg/server/data/store/tag"
 	"github.com/warmans/fakt-api/pkg/server/data/store/venue"
 	"github.com/warmans/route-rest/routes"
@@ -15,13 +19,20 @@ import (
 )
 
 type API struct {
-	AppVersion     string
-	EventStore     *event.Store
-	VenueStore     *venue.Store
-	PerformerStore *performer.Store
-	TagStore       *tag.Store
+	AppVersion      string
+	EventStore      *event.Store
+	VenueStore      *venue.Store
+	PerformerStore  *performer.Store
+	TagStore        *tag.Store
+	CrawlRunStore   *crawlrun.Store
+	QuarantineStore *quarantine.Store
+	SeriesStore     *series.Store
+	Ingest          *data.Ingest
 
-	Logger       *zap.Logger
+	// AdminToken must be supplied as a bearer token to use any /admin endpoints.
+	AdminToken string
+
+	Logger *zap.Logger
 }
 
 func (a *API) NewServeMux() http.Handler {
@@ -40,6 +51,12 @@ func (a *API) NewServeMux() http.Handler {
 						handler.NewEventSimilarHandler(a.EventStore),
 						[]*routes.Route{},
 					),
+					routes.NewRoute(
+						"revision",
+						"{revision_id:[0-9]+}",
+						handler.NewEventRevisionHandler(a.EventStore),
+						[]*routes.Route{},
+					),
 				},
 			),
 			routes.NewRoute(
@@ -48,6 +65,18 @@ func (a *API) NewServeMux() http.Handler {
 				handler.NewEventTypeHandler(a.EventStore),
 				[]*routes.Route{},
 			),
+			routes.NewRoute(
+				"festival",
+				"{festival_id:[0-9]+}",
+				handler.NewFestivalHandler(a.EventStore),
+				[]*routes.Route{},
+			),
+			routes.NewRoute(
+				"series",
+				"{series_id:[0-9]+}",
+				handler.NewSeriesHandler(a.SeriesStore),
+				[]*routes.Route{},
+			),
 			routes.NewRoute(
 				"venue",
 				"{venue_id:[0-9]+}",
@@ -90,6 +119,82 @@ func (a *API) NewServeMux() http.Handler {
 		[]string{""}, //no prefix on root resource
 	)
 
+	//ingest
+	routes.ApplyRoutes(
+		restRouter,
+		[]*routes.Route{
+			routes.NewRoute(
+				"run",
+				"{run_id:[0-9]+}",
+				handler.NewIngestRunHandler(a.CrawlRunStore),
+				[]*routes.Route{},
+			),
+		},
+		[]string{"", "ingest"},
+	)
+
+	//admin
+	adminOnly := func(next http.HandlerFunc) http.HandlerFunc {
+		return mw.AddAdminAuth(next, a.AdminToken).ServeHTTP
+	}
+	routes.ApplyRoutes(
+		restRouter,
+		[]*routes.Route{
+			routes.NewRoute(
+				"ingest",
+				"{crawler}",
+				handler.NewAdminIngestHandler(a.Ingest),
+				[]*routes.Route{},
+			).Middleware(adminOnly),
+			routes.NewRoute(
+				"venue",
+				"{venue_id:[0-9]+}",
+				&routes.DefaultRESTHandler{},
+				[]*routes.Route{
+					routes.NewRoute(
+						"merge",
+						"{merge_id:[0-9]+}",
+						handler.NewAdminVenueMergeHandler(a.VenueStore),
+						[]*routes.Route{},
+					).Middleware(adminOnly),
+				},
+			).Middleware(adminOnly),
+			routes.NewRoute(
+				"performer",
+				"{performer_id:[0-9]+}",
+				&routes.DefaultRESTHandler{},
+				[]*routes.Route{
+					routes.NewRoute(
+						"merge",
+						"{merge_id:[0-9]+}",
+						handler.NewAdminPerformerMergeHandler(a.PerformerStore),
+						[]*routes.Route{},
+					).Middleware(adminOnly),
+				},
+			).Middleware(adminOnly),
+			routes.NewRoute(
+				"venue_duplicate",
+				"{venue_duplicate_id:[0-9]+}",
+				handler.NewAdminVenueDuplicateHandler(a.VenueStore),
+				[]*routes.Route{},
+			).Middleware(adminOnly),
+			routes.NewRoute(
+				"quarantine",
+				"{quarantine_id:[0-9]+}",
+				handler.NewAdminQuarantineHandler(a.QuarantineStore),
+				[]*routes.Route{
+					routes.NewRoute(
+						"resubmit",
+						"{resubmit_id:[0-9]+}",
+						handler.NewAdminQuarantineResubmitHandler(a.Ingest),
+						[]*routes.Route{},
+					).Middleware(adminOnly),
+				},
+			).Middleware(adminOnly),
+		},
+		[]string{"", "admin"},
+	)
+
 	//meta
 	restRouter.Handle("/version", handler.NewVersionHandler(a.AppVersion))
 
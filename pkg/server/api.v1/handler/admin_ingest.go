@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/warmans/fakt-api/pkg/server/api.v1/common"
+	"github.com/warmans/fakt-api/pkg/server/api.v1/middleware"
+	"github.com/warmans/fakt-api/pkg/server/data"
+	"github.com/warmans/route-rest/routes"
+)
+
+func NewAdminIngestHandler(ingest *data.Ingest) routes.RESTHandler {
+	return &AdminIngestHandler{ingest: ingest}
+}
+
+type AdminIngestHandler struct {
+	routes.DefaultRESTHandler
+	ingest *data.Ingest
+}
+
+// HandlePost runs all crawlers (or just the one given by the crawler param) and responds with the resulting runs.
+func (h *AdminIngestHandler) HandlePost(rw http.ResponseWriter, r *http.Request) {
+
+	logger := middleware.MustGetLogger(r)
+
+	if h.ingest == nil {
+		common.SendError(rw, common.HTTPError{"Ingest is not configured", http.StatusServiceUnavailable, errors.New("no ingest")}, nil)
+		return
+	}
+
+	runs, err := h.ingest.CrawlNow(r.Context(), r.Form.Get("crawler"))
+	if err == data.ErrUnknownCrawler {
+		common.SendError(rw, common.HTTPError{"Crawler not Found", http.StatusNotFound, err}, nil)
+		return
+	}
+	if err != nil {
+		common.SendError(rw, err, logger)
+		return
+	}
+	common.SendResponse(rw, &common.Response{Status: http.StatusOK, Payload: runs})
+}
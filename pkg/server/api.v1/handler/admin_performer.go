@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/warmans/fakt-api/pkg/server/api.v1/common"
+	"github.com/warmans/fakt-api/pkg/server/api.v1/middleware"
+	"github.com/warmans/fakt-api/pkg/server/data/store/performer"
+	"github.com/warmans/route-rest/routes"
+)
+
+func NewAdminPerformerMergeHandler(ds *performer.Store) routes.RESTHandler {
+	return &AdminPerformerMergeHandler{ds: ds}
+}
+
+type AdminPerformerMergeHandler struct {
+	routes.DefaultRESTHandler
+	ds *performer.Store
+}
+
+// HandlePost merges the performer into the one given by the into param and responds with the remaining performer.
+func (h *AdminPerformerMergeHandler) HandlePost(rw http.ResponseWriter, r *http.Request) {
+
+	logger := middleware.MustGetLogger(r)
+
+	performerID, err := strconv.ParseInt(mux.Vars(r)["performer_id"], 10, 64)
+	if err != nil {
+		common.SendError(rw, common.HTTPError{"Invalid performer ID", http.StatusBadRequest, err}, nil)
+		return
+	}
+	intoID, err := strconv.ParseInt(r.Form.Get("into"), 10, 64)
+	if err != nil {
+		common.SendError(rw, common.HTTPError{"Invalid into performer ID", http.StatusBadRequest, err}, nil)
+		return
+	}
+	if performerID == intoID {
+		common.SendError(rw, common.HTTPError{"Cannot merge performer into itself", http.StatusBadRequest, errors.New("same performer")}, nil)
+		return
+	}
+
+	if err := h.ds.MergePerformers(performerID, intoID); err != nil {
+		if err == performer.ErrPerformerNotFound {
+			common.SendError(rw, common.HTTPError{"Performer not Found", http.StatusNotFound, err}, nil)
+			return
+		}
+		common.SendError(rw, err, logger)
+		return
+	}
+
+	f := &performer.Filter{}
+	f.IDs = []int64{intoID}
+	f.PageSize = 1
+	f.Page = 1
+
+	performers, err := h.ds.FindPerformers(f)
+	if err != nil {
+		common.SendError(rw, err, logger)
+		return
+	}
+	if len(performers) < 1 {
+		common.SendError(rw, common.HTTPError{"Performer not Found", http.StatusNotFound, performer.ErrPerformerNotFound}, nil)
+		return
+	}
+	common.SendResponse(rw, &common.Response{Status: http.StatusOK, Payload: performers[0]})
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/warmans/fakt-api/pkg/server/api.v1/common"
+	"github.com/warmans/fakt-api/pkg/server/api.v1/middleware"
+	"github.com/warmans/fakt-api/pkg/server/data"
+	storeCommon "github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"github.com/warmans/fakt-api/pkg/server/data/store/quarantine"
+	"github.com/warmans/route-rest/routes"
+)
+
+// maxQuarantinePayload limits the size of an edited record.
+const maxQuarantinePayload = 1 << 20
+
+func NewAdminQuarantineHandler(qs *quarantine.Store) routes.RESTHandler {
+	return &AdminQuarantineHandler{qs: qs}
+}
+
+type AdminQuarantineHandler struct {
+	routes.DefaultRESTHandler
+	qs *quarantine.Store
+}
+
+func (h *AdminQuarantineHandler) HandleGetList(rw http.ResponseWriter, r *http.Request) {
+
+	logger := middleware.MustGetLogger(r)
+
+	records, err := h.qs.FindRecords(quarantine.FilterFromRequest(r))
+	if err != nil {
+		common.SendError(rw, err, logger)
+		return
+	}
+	common.SendResponse(rw, &common.Response{Status: http.StatusOK, Payload: records})
+}
+
+func (h *AdminQuarantineHandler) HandleGet(rw http.ResponseWriter, r *http.Request) {
+
+	logger := middleware.MustGetLogger(r)
+
+	recordID, err := strconv.ParseInt(mux.Vars(r)["quarantine_id"], 10, 64)
+	if err != nil {
+		common.SendError(rw, common.HTTPError{"Invalid record ID", http.StatusBadRequest, err}, nil)
+		return
+	}
+
+	record, err := h.qs.FindRecord(recordID)
+	if err == quarantine.ErrRecordNotFound {
+		common.SendError(rw, common.HTTPError{"Record not Found", http.StatusNotFound, err}, nil)
+		return
+	}
+	if err != nil {
+		common.SendError(rw, err, logger)
+		return
+	}
+	common.SendResponse(rw, &common.Response{Status: http.StatusOK, Payload: record})
+}
+
+// HandlePut replaces the record's payload with the request body e.g. to fix it before re-submitting it.
+func (h *AdminQuarantineHandler) HandlePut(rw http.ResponseWriter, r *http.Request) {
+
+	logger := middleware.MustGetLogger(r)
+
+	recordID, err := strconv.ParseInt(mux.Vars(r)["quarantine_id"], 10, 64)
+	if err != nil {
+		common.SendError(rw, common.HTTPError{"Invalid record ID", http.StatusBadRequest, err}, nil)
+		return
+	}
+
+	record, err := h.qs.FindRecord(recordID)
+	if err == quarantine.ErrRecordNotFound {
+		common.SendError(rw, common.HTTPError{"Record not Found", http.StatusNotFound, err}, nil)
+		return
+	}
+	if err != nil {
+		common.SendError(rw, err, logger)
+		return
+	}
+
+	payload, err := ioutil.ReadAll(io.LimitReader(r.Body, maxQuarantinePayload))
+	if err != nil {
+		common.SendError(rw, common.HTTPError{"Failed to read payload", http.StatusBadRequest, err}, nil)
+		return
+	}
+
+	//the payload must at least decode to the record type
+	var decoded interface{}
+	switch record.Type {
+	case storeCommon.QuarantineTypeEvent:
+		decoded = &storeCommon.Event{}
+	case storeCommon.QuarantineTypePerformer:
+		decoded = &storeCommon.Performer{}
+	default:
+		common.SendError(rw, common.HTTPError{"Record cannot be edited", http.StatusBadRequest, errors.New(record.Type)}, nil)
+		return
+	}
+	if err := json.Unmarshal(payload, decoded); err != nil {
+		common.SendError(rw, common.HTTPError{"Invalid payload", http.StatusBadRequest, err}, nil)
+		return
+	}
+
+	record.Payload = payload
+	if err := h.qs.UpdateRecord(record); err != nil {
+		common.SendError(rw, err, logger)
+		return
+	}
+	common.SendResponse(rw, &common.Response{Status: http.StatusOK, Payload: record})
+}
+
+// HandleDelete discards the record.
+func (h *AdminQuarantineHandler) HandleDelete(rw http.ResponseWriter, r *http.Request) {
+
+	logger := middleware.MustGetLogger(r)
+
+	recordID, err := strconv.ParseInt(mux.Vars(r)["quarantine_id"], 10, 64)
+	if err != nil {
+		common.SendError(rw, common.HTTPError{"Invalid record ID", http.StatusBadRequest, err}, nil)
+		return
+	}
+
+	if err := h.qs.DiscardRecord(recordID); err != nil {
+		if err == quarantine.ErrRecordNotFound {
+			common.SendError(rw, common.HTTPError{"Record not Found", http.StatusNotFound, err}, nil)
+			return
+		}
+		common.SendError(rw, err, logger)
+		return
+	}
+	common.SendResponse(rw, &common.Response{Status: http.StatusOK})
+}
+
+func NewAdminQuarantineResubmitHandler(ingest *data.Ingest) routes.RESTHandler {
+	return &AdminQuarantineResubmitHandler{ingest: ingest}
+}
+
+type AdminQuarantineResubmitHandler struct {
+	routes.DefaultRESTHandler
+	ingest *data.Ingest
+}
+
+// HandlePost ingests the record again and responds with the stored event or performer. If it is rejected again
+// it stays in quarantine with the new reason.
+func (h *AdminQuarantineResubmitHandler) HandlePost(rw http.ResponseWriter, r *http.Request) {
+
+	logger := middleware.MustGetLogger(r)
+
+	if h.ingest == nil {
+		common.SendError(rw, common.HTTPError{"Ingest is not configured", http.StatusServiceUnavailable, errors.New("no ingest")}, nil)
+		return
+	}
+
+	recordID, err := strconv.ParseInt(mux.Vars(r)["quarantine_id"], 10, 64)
+	if err != nil {
+		common.SendError(rw, common.HTTPError{"Invalid record ID", http.StatusBadRequest, err}, nil)
+		return
+	}
+
+	stored, err := h.ingest.Resubmit(recordID)
+	if err != nil {
+		switch err := err.(type) {
+		case *data.RejectedError:
+			common.SendError(rw, common.HTTPError{err.Reason, http.StatusUnprocessableEntity, err}, nil)
+			return
+		}
+		if err == quarantine.ErrRecordNotFound {
+			common.SendError(rw, common.HTTPError{"Record not Found", http.StatusNotFound, err}, nil)
+			return
+		}
+		common.SendError(rw, err, logger)
+		return
+	}
+	common.SendResponse(rw, &common.Response{Status: http.StatusOK, Payload: stored})
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/warmans/fakt-api/pkg/server/api.v1/common"
+	"github.com/warmans/fakt-api/pkg/server/api.v1/middleware"
+	"github.com/warmans/fakt-api/pkg/server/data/store/venue"
+	"github.com/warmans/route-rest/routes"
+)
+
+func NewAdminVenueMergeHandler(ds *venue.Store) routes.RESTHandler {
+	return &AdminVenueMergeHandler{ds: ds}
+}
+
+type AdminVenueMergeHandler struct {
+	routes.DefaultRESTHandler
+	ds *venue.Store
+}
+
+// HandlePost merges the venue into the one given by the into param and responds with the remaining venue.
+func (h *AdminVenueMergeHandler) HandlePost(rw http.ResponseWriter, r *http.Request) {
+
+	logger := middleware.MustGetLogger(r)
+
+	venueID, err := strconv.ParseInt(mux.Vars(r)["venue_id"], 10, 64)
+	if err != nil {
+		common.SendError(rw, common.HTTPError{"Invalid venue ID", http.StatusBadRequest, err}, nil)
+		return
+	}
+	intoID, err := strconv.ParseInt(r.Form.Get("into"), 10, 64)
+	if err != nil {
+		common.SendError(rw, common.HTTPError{"Invalid into venue ID", http.StatusBadRequest, err}, nil)
+		return
+	}
+	if venueID == intoID {
+		common.SendError(rw, common.HTTPError{"Cannot merge venue into itself", http.StatusBadRequest, errors.New("same venue")}, nil)
+		return
+	}
+
+	if err := h.ds.MergeVenues(venueID, intoID); err != nil {
+		if err == venue.ErrVenueNotFound {
+			common.SendError(rw, common.HTTPError{"Venue not Found", http.StatusNotFound, err}, nil)
+			return
+		}
+		common.SendError(rw, err, logger)
+		return
+	}
+
+	f := &venue.Filter{}
+	f.IDs = []int64{intoID}
+	f.PageSize = 1
+	f.Page = 1
+
+	venues, err := h.ds.FindVenues(f)
+	if err != nil {
+		common.SendError(rw, err, logger)
+		return
+	}
+	if len(venues) < 1 {
+		common.SendError(rw, common.HTTPError{"Venue not Found", http.StatusNotFound, venue.ErrVenueNotFound}, nil)
+		return
+	}
+	common.SendResponse(rw, &common.Response{Status: http.StatusOK, Payload: venues[0]})
+}
+
+func NewAdminVenueDuplicateHandler(ds *venue.Store) routes.RESTHandler {
+	return &AdminVenueDuplicateHandler{ds: ds}
+}
+
+type AdminVenueDuplicateHandler struct {
+	routes.DefaultRESTHandler
+	ds *venue.Store
+}
+
+// HandleGetList lists pairs of venues that are probably the same place. The min_score param can be used to
+// show more or fewer.
+func (h *AdminVenueDuplicateHandler) HandleGetList(rw http.ResponseWriter, r *http.Request) {
+
+	logger := middleware.MustGetLogger(r)
+
+	threshold := venue.DefaultDuplicateThreshold
+	if minScore := r.Form.Get("min_score"); minScore != "" {
+		var err error
+		if threshold, err = strconv.ParseFloat(minScore, 64); err != nil {
+			common.SendError(rw, common.HTTPError{"Invalid min_score", http.StatusBadRequest, err}, nil)
+			return
+		}
+	}
+
+	duplicates, err := h.ds.FindDuplicateVenues(threshold)
+	if err != nil {
+		common.SendError(rw, err, logger)
+		return
+	}
+	common.SendResponse(rw, &common.Response{Status: http.StatusOK, Payload: duplicates})
+}
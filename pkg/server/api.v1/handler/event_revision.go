@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/warmans/fakt-api/pkg/server/api.v1/common"
+	"github.com/warmans/fakt-api/pkg/server/api.v1/middleware"
+	"github.com/warmans/fakt-api/pkg/server/data/store/event"
+	"github.com/warmans/route-rest/routes"
+)
+
+func NewEventRevisionHandler(ds *event.Store) routes.RESTHandler {
+	return &EventRevisionHandler{ds: ds}
+}
+
+type EventRevisionHandler struct {
+	routes.DefaultRESTHandler
+	ds *event.Store
+}
+
+func (h *EventRevisionHandler) HandleGetList(rw http.ResponseWriter, r *http.Request) {
+
+	logger := middleware.MustGetLogger(r)
+
+	eventID, err := strconv.Atoi(mux.Vars(r)["event_id"])
+	if err != nil {
+		common.SendError(rw, common.HTTPError{"Invalid eventID", http.StatusBadRequest, err}, nil)
+		return
+	}
+
+	revisions, err := h.ds.FindEventRevisions(int64(eventID))
+	if err != nil {
+		common.SendError(rw, err, logger)
+		return
+	}
+	common.SendResponse(rw, &common.Response{Status: http.StatusOK, Payload: revisions})
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/warmans/fakt-api/pkg/server/api.v1/common"
+	"github.com/warmans/fakt-api/pkg/server/api.v1/middleware"
+	"github.com/warmans/fakt-api/pkg/server/data/store/event"
+	"github.com/warmans/route-rest/routes"
+)
+
+func NewFestivalHandler(ds *event.Store) routes.RESTHandler {
+	return &FestivalHandler{ds: ds}
+}
+
+type FestivalHandler struct {
+	routes.DefaultRESTHandler
+	ds *event.Store
+}
+
+func (h *FestivalHandler) HandleGetList(rw http.ResponseWriter, r *http.Request) {
+
+	logger := middleware.MustGetLogger(r)
+
+	festivals, err := h.ds.FindFestivals(event.FestivalFilterFromRequest(r))
+	if err != nil {
+		common.SendError(rw, err, logger)
+		return
+	}
+
+	common.SendResponse(rw, &common.Response{Status: http.StatusOK, Payload: festivals})
+}
+
+func (h *FestivalHandler) HandleGet(rw http.ResponseWriter, r *http.Request) {
+
+	logger := middleware.MustGetLogger(r)
+
+	festivalID, err := strconv.Atoi(mux.Vars(r)["festival_id"])
+	if err != nil {
+		common.SendError(rw, common.HTTPError{"Invalid festival ID", http.StatusBadRequest, err}, nil)
+		return
+	}
+
+	festival, err := h.ds.FindFestival(int64(festivalID))
+	if err == event.ErrFestivalNotFound {
+		common.SendError(rw, common.HTTPError{"Festival not Found", http.StatusNotFound, err}, nil)
+		return
+	}
+	if err != nil {
+		common.SendError(rw, err, logger)
+		return
+	}
+
+	common.SendResponse(rw, &common.Response{Status: http.StatusOK, Payload: festival})
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/warmans/fakt-api/pkg/server/api.v1/common"
+	"github.com/warmans/fakt-api/pkg/server/api.v1/middleware"
+	"github.com/warmans/fakt-api/pkg/server/data/store/crawlrun"
+	"github.com/warmans/route-rest/routes"
+)
+
+func NewIngestRunHandler(rs *crawlrun.Store) routes.RESTHandler {
+	return &IngestRunHandler{runs: rs}
+}
+
+type IngestRunHandler struct {
+	routes.DefaultRESTHandler
+	runs *crawlrun.Store
+}
+
+func (h *IngestRunHandler) HandleGetList(rw http.ResponseWriter, r *http.Request) {
+
+	logger := middleware.MustGetLogger(r)
+
+	runs, err := h.runs.FindRuns(crawlrun.FilterFromRequest(r))
+	if err != nil {
+		common.SendError(rw, err, logger)
+		return
+	}
+	common.SendResponse(rw, &common.Response{Status: http.StatusOK, Payload: runs})
+}
+
+func (h *IngestRunHandler) HandleGet(rw http.ResponseWriter, r *http.Request) {
+
+	logger := middleware.MustGetLogger(r)
+
+	runID, err := strconv.Atoi(mux.Vars(r)["run_id"])
+	if err != nil {
+		common.SendError(rw, common.HTTPError{"Invalid run ID", http.StatusBadRequest, err}, nil)
+		return
+	}
+
+	f := &crawlrun.Filter{}
+	f.IDs = []int64{int64(runID)}
+	f.PageSize = 1
+	f.Page = 1
+
+	runs, err := h.runs.FindRuns(f)
+	if err != nil {
+		common.SendError(rw, err, logger)
+		return
+	}
+	if len(runs) < 1 {
+		common.SendError(rw, common.HTTPError{"Run not Found", http.StatusNotFound, err}, nil)
+		return
+	}
+	common.SendResponse(rw, &common.Response{Status: http.StatusOK, Payload: runs[0]})
+}
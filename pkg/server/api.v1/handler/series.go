@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/warmans/fakt-api/pkg/server/api.v1/common"
+	"github.com/warmans/fakt-api/pkg/server/api.v1/middleware"
+	"github.com/warmans/fakt-api/pkg/server/data/store/series"
+	"github.com/warmans/route-rest/routes"
+)
+
+func NewSeriesHandler(ds *series.Store) routes.RESTHandler {
+	return &SeriesHandler{ds: ds}
+}
+
+type SeriesHandler struct {
+	routes.DefaultRESTHandler
+	ds *series.Store
+}
+
+func (h *SeriesHandler) HandleGetList(rw http.ResponseWriter, r *http.Request) {
+
+	logger := middleware.MustGetLogger(r)
+
+	found, err := h.ds.FindSeries(series.FilterFromRequest(r))
+	if err != nil {
+		common.SendError(rw, err, logger)
+		return
+	}
+
+	common.SendResponse(rw, &common.Response{Status: http.StatusOK, Payload: found})
+}
+
+func (h *SeriesHandler) HandleGet(rw http.ResponseWriter, r *http.Request) {
+
+	logger := middleware.MustGetLogger(r)
+
+	seriesID, err := strconv.Atoi(mux.Vars(r)["series_id"])
+	if err != nil {
+		common.SendError(rw, common.HTTPError{"Invalid series ID", http.StatusBadRequest, err}, nil)
+		return
+	}
+
+	f := &series.Filter{}
+	f.IDs = []int64{int64(seriesID)}
+	f.PageSize = 1
+	f.Page = 1
+
+	found, err := h.ds.FindSeries(f)
+	if err != nil {
+		common.SendError(rw, err, logger)
+		return
+	}
+
+	if len(found) < 1 {
+		common.SendError(rw, common.HTTPError{"Series not Found", http.StatusNotFound, err}, nil)
+		return
+	}
+
+	common.SendResponse(rw, &common.Response{Status: http.StatusOK, Payload: found[0]})
+}
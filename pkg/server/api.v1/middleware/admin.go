@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/warmans/fakt-api/pkg/server/api.v1/common"
+)
+
+// AddAdminAuth only allows requests with an "Authorization: Bearer <token>" header matching the admin token.
+// If no token is configured all requests are refused.
+func AddAdminAuth(nextHandler http.Handler, token string) http.Handler {
+	return &AdminAuthMiddleware{next: nextHandler, token: token}
+}
+
+type AdminAuthMiddleware struct {
+	next  http.Handler
+	token string
+}
+
+func (m *AdminAuthMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+
+	if m.token == "" {
+		common.SendError(rw, common.HTTPError{"Admin API is disabled", http.StatusForbidden, errors.New("no admin token configured")}, nil)
+		return
+	}
+
+	supplied := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(supplied), []byte(m.token)) != 1 {
+		common.SendError(rw, common.HTTPError{"Invalid admin token", http.StatusUnauthorized, errors.New("invalid admin token")}, MustGetLogger(r))
+		return
+	}
+
+	m.next.ServeHTTP(rw, r)
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"encoding/json"
+
+	"github.com/warmans/fakt-api/pkg/server/data/source"
+	"github.com/warmans/fakt-api/pkg/server/data/source/feed"
+	"github.com/warmans/fakt-api/pkg/server/data/source/scrape"
+)
+
+// stressfaktorOptions configures the scrape crawler for the stressfaktor termine page. Each day is a
+// .termin_tag_titel row (e.g. "Montag, 21. Dezember 2015") followed by a .termin_box row per event.
+var stressfaktorOptions = json.RawMessage(`{
+	"container": "#column_center",
+	"row": ".termin_tag_titel, .termin_box",
+	"date_header": ".termin_tag_titel",
+	"date": {"regex": "^[A-Za-z]+, ([0-9]{2}\\. [\\p{L}]+ [0-9]{4})$"},
+	"time": {"selector": ".spalte_uhrzeit > .uhrzeit2", "regex": "^[0-9]{2}:[0-9]{2}$"},
+	"date_layouts": ["02. January 2006 15:04"],
+	"locale": "de_DE",
+	"venue": {"selector": ".spalte_termintext > b"},
+	"venue_address": {"selector": ".spalte_termintext > b a", "attr": "title"},
+	"type": {"selector": ".spalte_termintext", "lines": "0", "regex": "^[^:]*:\\s*([^:]*)"},
+	"description": {"selector": ".spalte_termintext", "lines": "1:"},
+	"tags": {"selector": ".termin_tags > .termin_tag"},
+	"performers": "guess"
+}`)
+
+// k9Options configures the feed crawler for the Kinzig 9 RSS feed which has dates in the title
+// e.g. "Freitag, 02.09.2016 – ab 19.00 h – Größenwahn und Leichtsinn"
+var k9Options = json.RawMessage(`{
+	"venue_name": "K9",
+	"venue_address": "Kinzigstr. 9, 10247 Berlin",
+	"date_field": "title",
+	"date_formats": [
+		{"regex": "[^0-9]+([0-9]{2})\\.([0-9]{2})\\.([0-9]{4})[^0-9]+([0-9]{2})\\.([0-9]{2})", "layout": "02 01 2006 15 04"}
+	]
+}`)
+
+// defaultCrawlerConfig is used when no crawler config file is given.
+func defaultCrawlerConfig(stressfaktorURI string) *source.Config {
+	return &source.Config{
+		Crawlers: []*source.CrawlerConfig{
+			{Name: "stressfaktor", Type: scrape.Type, URI: stressfaktorURI, Enabled: true, Options: stressfaktorOptions},
+			{Name: "k9", Type: feed.Type, URI: "http://www.kinzig9.de/rss.xml", Enabled: true, Options: k9Options},
+		},
+	}
+}
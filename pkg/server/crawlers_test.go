@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/warmans/fakt-api/pkg/server/data"
+	"github.com/warmans/fakt-api/pkg/server/data/source/sourcetest"
+	"github.com/warmans/fakt-api/pkg/server/data/store/storetest"
+	"github.com/warmans/fakt-api/pkg/server/data/store/venue"
+	"go.uber.org/zap"
+)
+
+const testStressfaktorURI = "https://stressfaktor.squat.net/termine.php?display=30"
+
+func TestDefaultCrawlerConfig(t *testing.T) {
+	crawlers, err := defaultCrawlerConfig(testStressfaktorURI).Build("Europe/Berlin", nil, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Default crawler config is invalid: %s", err.Error())
+	}
+	if len(crawlers) != 2 {
+		t.Errorf("Expected 2 crawlers got %d", len(crawlers))
+	}
+}
+
+// TestDefaultCrawlers runs the default crawlers against the responses in testdata/synthetic-http. These were
+// written by hand to match the sites' markup, not recorded, so they only show the crawlers parse what the sites
+// are expected to return. Use -record to replace them with the real responses (and rename the directory) and
+// -update to accept the new output.
+func TestDefaultCrawlers(t *testing.T) {
+	crawlers, err := defaultCrawlerConfig(testStressfaktorURI).Build(
+		"Europe/Berlin",
+		sourcetest.NewClient(t, filepath.Join("testdata", "synthetic-http")),
+		zap.NewNop(),
+	)
+	if err != nil {
+		t.Fatalf("Default crawler config is invalid: %s", err.Error())
+	}
+	for _, c := range crawlers {
+		events, err := c.Crawl(context.Background())
+		if err != nil {
+			t.Fatalf("Crawler %s failed: %s", c.Name(), err.Error())
+		}
+		sourcetest.CompareGolden(t, filepath.Join("testdata", "golden", c.Name()+".json"), events)
+	}
+}
+
+func TestNewDryRunIngest(t *testing.T) {
+
+	//without a DB anything trying to use it would panic
+	srv := NewServer(&Config{ServerLocation: "Europe/Berlin", CrawlerStressfaktorURI: testStressfaktorURI}, zap.NewNop(), nil)
+
+	ingest, err := srv.NewDryRunIngest()
+	if err != nil {
+		t.Fatalf("Failed to create dry run ingest: %s", err.Error())
+	}
+	if len(ingest.Crawlers) != 2 {
+		t.Errorf("Expected 2 crawlers got %d", len(ingest.Crawlers))
+	}
+	for _, v := range ingest.EventVisitors {
+		switch v.(type) {
+		case *data.StatusVisitor, *data.CategoryVisitor, *data.DetailsVisitor:
+		default:
+			t.Errorf("Unexpected visitor %T in dry run", v)
+		}
+	}
+}
+
+func TestVenueStoresCanMergeEvents(t *testing.T) {
+
+	db, cleanup := storetest.MustOpenDB(t)
+	defer cleanup()
+
+	srv := NewServer(&Config{ServerLocation: "Europe/Berlin", CrawlerStressfaktorURI: testStressfaktorURI}, zap.NewNop(), db)
+
+	ingest, err := srv.NewIngest()
+	if err != nil {
+		t.Fatalf("Failed to create ingest: %s", err.Error())
+	}
+	for name, store := range map[string]*venue.Store{"ingest": ingest.VenueStore, "snapshot": srv.NewSnapshot().VenueStore} {
+		if store.EventStore == nil {
+			t.Errorf("Expected %s venue store to have an event store", name)
+		}
+	}
+}
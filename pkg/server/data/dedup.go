@@ -0,0 +1,89 @@
+package data
+
+import (
+	"math"
+	"time"
+
+	"github.com/warmans/dbr"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+const (
+	// DefaultDedupWindow is how far apart two listings can start and still be considered the same event.
+	DefaultDedupWindow = time.Hour * 3
+	// DefaultDedupThreshold is the minimum score for two listings to be merged.
+	DefaultDedupThreshold = 0.7
+
+	// minVenueSimilarity stops events at different venues being merged however similar they are otherwise.
+	minVenueSimilarity = 0.5
+	// otherSourceGrace is how long an event listed by another source is protected from being marked removed.
+	otherSourceGrace = time.Hour * 48
+)
+
+// ScoreDuplicate estimates how likely it is (0-1) that two listings describe the same event based on the
+// venue, start time and description.
+func ScoreDuplicate(a, b *common.Event, window time.Duration) float64 {
+	if a.Venue == nil || b.Venue == nil || window <= 0 {
+		return 0
+	}
+
+	venueScore := math.Max(
+		common.Similarity(a.Venue.Name, b.Venue.Name),
+		common.Similarity(a.Venue.Address, b.Venue.Address),
+	)
+	if venueScore < minVenueSimilarity {
+		return 0
+	}
+
+	diff := a.Date.Sub(b.Date)
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > window {
+		return 0
+	}
+	timeScore := 1 - float64(diff)/float64(window)
+
+	descriptionScore := math.Max(
+		common.Similarity(a.Description, b.Description),
+		common.TokenOverlap(a.Description, b.Description),
+	)
+
+	return 0.4*venueScore + 0.3*timeScore + 0.3*descriptionScore
+}
+
+// findDuplicate returns the best matching event already listed by a different source, or nil if nothing
+// scores above the threshold.
+func (i *Ingest) findDuplicate(tr *dbr.Tx, event *common.Event) (*common.Event, float64, error) {
+
+	if event.Source == "" {
+		return nil, 0, nil
+	}
+
+	window := i.DedupWindow
+	if window == 0 {
+		window = DefaultDedupWindow
+	}
+	threshold := i.DedupThreshold
+	if threshold == 0 {
+		threshold = DefaultDedupThreshold
+	}
+
+	candidates, err := i.EventStore.FindEventCandidates(tr, event.Date, window)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var best *common.Event
+	bestScore := 0.0
+	for _, candidate := range candidates {
+		//a source is trusted not to list the same event twice, so its own events are matched exactly
+		if candidate.Source == "" || candidate.Source == event.Source {
+			continue
+		}
+		if score := ScoreDuplicate(event, candidate, window); score >= threshold && score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	return best, bestScore, nil
+}
@@ -0,0 +1,160 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+func TestScoreDuplicate(t *testing.T) {
+
+	date := time.Date(2018, 7, 20, 20, 0, 0, 0, time.UTC)
+
+	k9 := &common.Event{
+		Date:        date,
+		Venue:       &common.Venue{Name: "K9", Address: "Kinzigstr. 9, 10247 Berlin"},
+		Description: "Soli-Konzert mit Kotzreiz und Gäste",
+	}
+
+	examples := []struct {
+		Name      string
+		Other     *common.Event
+		Duplicate bool
+	}{
+		{
+			Name: "same listing from another source",
+			Other: &common.Event{
+				Date:        date.Add(time.Minute * 30),
+				Venue:       &common.Venue{Name: "K9 - Kinzigstraße 9", Address: "Kinzigstr. 9"},
+				Description: "Kotzreiz + Gäste. Soli-Konzert",
+			},
+			Duplicate: true,
+		},
+		{
+			Name: "different venue",
+			Other: &common.Event{
+				Date:        date,
+				Venue:       &common.Venue{Name: "Köpi", Address: "Köpenicker Str. 137"},
+				Description: "Soli-Konzert mit Kotzreiz und Gäste",
+			},
+			Duplicate: false,
+		},
+		{
+			Name: "outside window",
+			Other: &common.Event{
+				Date:        date.Add(time.Hour * 5),
+				Venue:       k9.Venue,
+				Description: "Soli-Konzert mit Kotzreiz und Gäste",
+			},
+			Duplicate: false,
+		},
+		{
+			Name: "different event at same venue",
+			Other: &common.Event{
+				Date:        date.Add(time.Hour * 2),
+				Venue:       k9.Venue,
+				Description: "Tresen und Filmabend",
+			},
+			Duplicate: false,
+		},
+	}
+
+	for _, ex := range examples {
+		score := ScoreDuplicate(k9, ex.Other, DefaultDedupWindow)
+		if (score >= DefaultDedupThreshold) != ex.Duplicate {
+			t.Errorf("%s: unexpected score %f", ex.Name, score)
+		}
+	}
+}
+
+func TestIngestDuplicateCarriesCancellation(t *testing.T) {
+
+	ingest, cleanup := newTestIngest(t)
+	defer cleanup()
+
+	date := time.Now().Truncate(time.Hour*24).AddDate(0, 0, 7).Add(time.Hour * 20)
+	listing := func(source, status string) *common.Event {
+		return &common.Event{
+			Date:        date,
+			Venue:       &common.Venue{Name: "K9", Address: "Kinzigstr. 9"},
+			Type:        "Konzert",
+			Description: "Soli-Konzert mit Kotzreiz und Gäste",
+			Source:      source,
+			Status:      status,
+		}
+	}
+
+	if _, err := ingest.Ingest(listing("k9", common.EventStatusActive)); err != nil {
+		t.Fatalf("failed to ingest: %s", err.Error())
+	}
+	if _, err := ingest.Ingest(listing("stressfaktor", common.EventStatusCancelled)); err != nil {
+		t.Fatalf("failed to ingest duplicate: %s", err.Error())
+	}
+
+	events := mustFindEvents(t, ingest)
+	if len(events) != 1 {
+		t.Fatalf("expected duplicate to be merged got %d events", len(events))
+	}
+	if events[0].Status != common.EventStatusCancelled {
+		t.Errorf("expected merged event to be cancelled got %s", events[0].Status)
+	}
+
+	revisions, err := ingest.EventStore.FindEventRevisions(events[0].ID)
+	if err != nil {
+		t.Fatalf("failed to find revisions: %s", err.Error())
+	}
+	if len(revisions) != 1 || revisions[0].Source != "stressfaktor" {
+		t.Fatalf("expected a single revision from the duplicate's source got %+v", revisions)
+	}
+	if len(revisions[0].Changes) != 1 || revisions[0].Changes[0].Field != "status" {
+		t.Errorf("expected a status change got %+v", revisions[0].Changes)
+	}
+
+	//another source still listing the event doesn't undo the cancellation
+	if _, err := ingest.Ingest(listing("koepi", common.EventStatusActive)); err != nil {
+		t.Fatalf("failed to ingest duplicate: %s", err.Error())
+	}
+	if events = mustFindEvents(t, ingest); events[0].Status != common.EventStatusCancelled {
+		t.Errorf("expected event to stay cancelled got %s", events[0].Status)
+	}
+
+	//nor does the event's own source listing it again
+	for i := 0; i < 2; i++ {
+		if _, err := ingest.Ingest(listing("k9", common.EventStatusActive)); err != nil {
+			t.Fatalf("failed to re-ingest: %s", err.Error())
+		}
+		if _, err := ingest.Ingest(listing("stressfaktor", common.EventStatusCancelled)); err != nil {
+			t.Fatalf("failed to re-ingest duplicate: %s", err.Error())
+		}
+		if events = mustFindEvents(t, ingest); events[0].Status != common.EventStatusCancelled {
+			t.Errorf("expected event to stay cancelled after its own source's crawl got %s", events[0].Status)
+		}
+	}
+	if revisions, err = ingest.EventStore.FindEventRevisions(events[0].ID); err != nil || len(revisions) != 1 {
+		t.Errorf("expected status not to change again got %d revisions (%v)", len(revisions), err)
+	}
+}
+
+func TestIngestOwnSourceCanChangeItsStatus(t *testing.T) {
+
+	ingest, cleanup := newTestIngest(t)
+	defer cleanup()
+
+	date := time.Now().Truncate(time.Hour*24).AddDate(0, 0, 7).Add(time.Hour * 20)
+	for _, status := range []string{common.EventStatusPostponed, common.EventStatusActive} {
+		ev := &common.Event{
+			Date:   date,
+			Venue:  &common.Venue{Name: "K9", Address: "Kinzigstr. 9"},
+			Type:   "Konzert",
+			Source: "k9",
+			Status: status,
+		}
+		if _, err := ingest.Ingest(ev); err != nil {
+			t.Fatalf("failed to ingest: %s", err.Error())
+		}
+		if events := mustFindEvents(t, ingest); len(events) != 1 || events[0].Status != status {
+			t.Errorf("expected one %s event got %+v", status, events)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package data
+
+import (
+	"context"
+
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+// DryRunResult is either an event as it would be ingested or a failed crawl.
+type DryRunResult struct {
+	Crawler string        `json:"crawler"`
+	Event   *common.Event `json:"event,omitempty"`
+	// Rejected is why the event would not be stored.
+	Rejected string `json:"rejected,omitempty"`
+	// Error is why the crawl failed.
+	Error string `json:"error,omitempty"`
+}
+
+// DryRun crawls the named crawler (or all crawlers if name is empty) and runs the visitors over each event
+// without storing anything or recording the run. Each event or crawl failure is passed to emit as it is found.
+// The returned error is for a problem running the crawlers, failed crawls are only emitted.
+func (i *Ingest) DryRun(ctx context.Context, name string, emit func(*DryRunResult)) error {
+	found := false
+	for _, c := range i.Crawlers {
+		if name != "" && c.Name() != name {
+			continue
+		}
+		found = true
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		crawlCtx, cancel := context.WithTimeout(ctx, c.Timeout)
+		events, err := c.Crawl(crawlCtx)
+		cancel()
+		if err != nil {
+			emit(&DryRunResult{Crawler: c.Name(), Error: err.Error()})
+			continue
+		}
+
+		for _, ev := range events {
+			ev.Source = c.Name()
+			result := &DryRunResult{Crawler: c.Name(), Event: ev}
+			if err := i.prepare(ev); err != nil {
+				result.Rejected = err.Error()
+			}
+			emit(result)
+		}
+	}
+	if !found {
+		return ErrUnknownCrawler
+	}
+	return nil
+}
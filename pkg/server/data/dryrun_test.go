@@ -0,0 +1,72 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/warmans/fakt-api/pkg/server/data/source"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+type staticCrawler struct {
+	events []*common.Event
+	err    error
+}
+
+func (c *staticCrawler) Name() string {
+	return "static"
+}
+
+func (c *staticCrawler) Crawl(ctx context.Context) ([]*common.Event, error) {
+	return c.events, c.err
+}
+
+func TestDryRun(t *testing.T) {
+
+	date := time.Date(2018, 7, 20, 20, 0, 0, 0, time.UTC)
+
+	ingest := &Ingest{
+		EventVisitors: []common.EventVisitor{&CategoryVisitor{}},
+		Crawlers: []*source.ConfiguredCrawler{
+			{
+				Crawler: &staticCrawler{
+					events: []*common.Event{
+						{Date: date, Venue: &common.Venue{Name: "K9"}, Type: "Konzert"},
+						{Date: date, Venue: &common.Venue{}},
+					},
+				},
+				Config:  &source.CrawlerConfig{Name: "k9"},
+				Timeout: time.Minute,
+			},
+			{
+				Crawler: &staticCrawler{err: errors.New("parser failed")},
+				Config:  &source.CrawlerConfig{Name: "broken"},
+				Timeout: time.Minute,
+			},
+		},
+	}
+
+	results := make([]*DryRunResult, 0)
+	if err := ingest.DryRun(context.Background(), "", func(r *DryRunResult) { results = append(results, r) }); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results got %d", len(results))
+	}
+
+	if results[0].Rejected != "" || results[0].Event.Category != common.EventCategoryConcert || results[0].Event.Source != "k9" {
+		t.Errorf("expected a visited valid event, got %+v", results[0])
+	}
+	if results[1].Rejected == "" {
+		t.Error("expected event with no venue name to be rejected")
+	}
+	if results[2].Crawler != "broken" || results[2].Error != "parser failed" {
+		t.Errorf("expected crawl failure, got %+v", results[2])
+	}
+
+	if err := ingest.DryRun(context.Background(), "unknown", func(r *DryRunResult) {}); err != ErrUnknownCrawler {
+		t.Errorf("expected unknown crawler error, got %v", err)
+	}
+}
@@ -1,82 +1,371 @@
 package data
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math/rand"
 	"sync"
 	"time"
 
 	"github.com/warmans/dbr"
 	"github.com/warmans/fakt-api/pkg/server/data/source"
 	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"github.com/warmans/fakt-api/pkg/server/data/store/crawlrun"
 	"github.com/warmans/fakt-api/pkg/server/data/store/event"
 	"github.com/warmans/fakt-api/pkg/server/data/store/performer"
+	"github.com/warmans/fakt-api/pkg/server/data/store/quarantine"
 	"github.com/warmans/fakt-api/pkg/server/data/store/venue"
 	"go.uber.org/zap"
 )
 
+const (
+	DefaultMaxConcurrency = 2
+	DefaultMaxBackoff     = time.Hour * 24
+)
+
 type Ingest struct {
 	Logger          *zap.Logger
 	DB              *dbr.Session
 	UpdateFrequency time.Duration
 	EventVisitors   []common.EventVisitor
-	Crawlers        []source.Crawler
-	timezone        *time.Location
+	Crawlers        []*source.ConfiguredCrawler
+	// MaxConcurrency limits how many crawlers may run at the same time.
+	MaxConcurrency int
+	// MaxBackoff caps how long a failing crawler will be delayed beyond its schedule.
+	MaxBackoff time.Duration
+	// DedupWindow and DedupThreshold control matching events listed by more than one source (see ScoreDuplicate).
+	DedupWindow    time.Duration
+	DedupThreshold float64
+	timezone       *time.Location
 
 	EventStore     *event.Store
 	VenueStore     *venue.Store
 	PerformerStore *performer.Store
+	CrawlRunStore  *crawlrun.Store
+	// QuarantineStore keeps rejected events and performers. If nil they are only logged.
+	QuarantineStore *quarantine.Store
+
+	initOnce sync.Once
+	slots    chan struct{}
+	// running prevents a scheduled and an on-demand crawl of the same source overlapping. Each is a lock
+	// (buffered with one slot) so waiting for it can be cancelled.
+	running map[string]chan struct{}
 }
 
-func (i *Ingest) Run() {
-	for {
-		wg := sync.WaitGroup{}
+// ErrUnknownCrawler is returned by CrawlNow if no crawler has the given name.
+var ErrUnknownCrawler = errors.New("unknown crawler")
+
+// RejectedError is returned when an event is not valid enough to be stored.
+type RejectedError struct {
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("event was rejected: %s", e.Reason)
+}
+
+func (i *Ingest) init() {
+	i.initOnce.Do(func() {
+		maxConcurrency := i.MaxConcurrency
+		if maxConcurrency < 1 {
+			maxConcurrency = DefaultMaxConcurrency
+		}
+		i.slots = make(chan struct{}, maxConcurrency)
+		i.running = make(map[string]chan struct{})
 		for _, c := range i.Crawlers {
-			wg.Add(1)
-			go func(c source.Crawler) {
-
-				defer wg.Done()
-				logger := i.Logger.With(zap.String("crawler", fmt.Sprintf("%T", c)))
-
-				logger.Info("crawling...")
-				events, err := c.Crawl()
-				if err != nil {
-					logger.Error("Failed failed crawling", zap.Error(err))
-					return
-				}
-
-				logger.Info(fmt.Sprintf("Discovered %d events", len(events)))
-				for _, ev := range events {
-					//append the source to all events
-					ev.Source = c.Name()
-					if err := i.Ingest(ev); err != nil {
-						logger.Error("Failed to ingest event", zap.Error(err))
-					}
-				}
-			}(c)
-		}
-		wg.Wait()
+			i.running[c.Name()] = make(chan struct{}, 1)
+		}
+	})
+}
 
+// Run starts each crawler on its own schedule and periodically cleans up old events. It blocks until ctx is
+// cancelled and any crawls in progress have stopped.
+func (i *Ingest) Run(ctx context.Context) {
+	i.init()
+
+	wg := sync.WaitGroup{}
+	for _, c := range i.Crawlers {
+		wg.Add(1)
+		go func(c *source.ConfiguredCrawler) {
+			defer wg.Done()
+			i.schedule(ctx, c)
+		}(c)
+	}
+	for {
 		i.Cleanup()
-		time.Sleep(i.UpdateFrequency)
+		if !sleep(ctx, i.UpdateFrequency) {
+			break
+		}
+	}
+	wg.Wait()
+	i.Logger.Info("Ingest stopped")
+}
+
+// CrawlNow synchronously runs the named crawler or all crawlers if name is empty.
+func (i *Ingest) CrawlNow(ctx context.Context, name string) ([]*common.CrawlRun, error) {
+	runs := make([]*common.CrawlRun, 0)
+	for _, c := range i.Crawlers {
+		if name != "" && c.Name() != name {
+			continue
+		}
+		run, _ := i.RunCrawler(ctx, c)
+		if run == nil {
+			return runs, ctx.Err()
+		}
+		runs = append(runs, run)
+	}
+	if len(runs) == 0 {
+		return nil, ErrUnknownCrawler
+	}
+	return runs, nil
+}
+
+// RunCrawler crawls once respecting the concurrency limit and the crawler's timeout. If the crawler is already
+// running it waits for it to finish. The run is nil if ctx was cancelled before the crawl could start.
+func (i *Ingest) RunCrawler(ctx context.Context, c *source.ConfiguredCrawler) (*common.CrawlRun, error) {
+	i.init()
+
+	//the crawler's own lock is taken first so waiting for it doesn't use a slot other crawlers could run in
+	if lock := i.running[c.Name()]; lock != nil {
+		select {
+		case lock <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		defer func() { <-lock }()
+	}
+
+	select {
+	case i.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-i.slots }()
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	return i.Crawl(ctx, c)
+}
+
+// schedule runs the crawler immediately and then according to its schedule. Consecutive failures push the
+// next run further out (see source.Backoff).
+func (i *Ingest) schedule(ctx context.Context, c *source.ConfiguredCrawler) {
+
+	logger := i.Logger.With(zap.String("crawler", c.Name()))
+
+	maxBackoff := i.MaxBackoff
+	if maxBackoff == 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+
+	failures := 0
+	for {
+		if _, err := i.RunCrawler(ctx, c); err != nil {
+			failures++
+		} else {
+			failures = 0
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		now := time.Now()
+		next := c.Schedule.Next(now)
+		if next.IsZero() {
+			logger.Error("Schedule has no future runs, crawler stopped")
+			return
+		}
+		delay := source.Backoff(next.Sub(now), maxBackoff, failures, rand.Float64())
+		if failures > 0 {
+			logger.Info("Backing off after failure", zap.Int("failures", failures), zap.Duration("delay", delay))
+		}
+		if !sleep(ctx, delay) {
+			return
+		}
+	}
+}
+
+// sleep waits for d or until ctx is cancelled. It returns false if ctx was cancelled.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Crawl runs a single crawler and ingests everything it finds. The outcome is recorded as a crawl run.
+// The returned error is only for a failed crawl, failures ingesting individual events are just recorded.
+func (i *Ingest) Crawl(ctx context.Context, c source.Crawler) (*common.CrawlRun, error) {
+
+	logger := i.Logger.With(zap.String("crawler", c.Name()))
+
+	run := &common.CrawlRun{Crawler: c.Name(), StartTime: time.Now(), Errors: []string{}}
+	if i.CrawlRunStore != nil {
+		if err := i.CrawlRunStore.StartRun(run); err != nil {
+			logger.Error("Failed to record crawl run", zap.Error(err))
+		}
+	}
+	defer i.finishRun(run, logger)
+
+	logger.Info("crawling...")
+	events, err := c.Crawl(ctx)
+	if err != nil {
+		logger.Error("Failed failed crawling", zap.Error(err))
+		run.AddError(err)
+		return run, err
+	}
+	run.Succeeded = true
+	run.Discovered = int64(len(events))
+
+	logger.Info(fmt.Sprintf("Discovered %d events", len(events)))
+
+	listed := newCrawlListings(events)
+	seenIDs := make([]int64, 0, len(events))
+	lastDate := time.Time{}
+	complete := true
+
+	for _, ev := range events {
+		//each event is stored in its own transaction so stopping here never leaves one half written
+		if ctx.Err() != nil {
+			logger.Info("Crawl stopped before all events were ingested", zap.Error(ctx.Err()))
+			run.AddError(ctx.Err())
+			complete = false
+			break
+		}
+		//append the source to all events
+		ev.Source = c.Name()
+
+		//keep the record as crawled in case it is rejected (visitors modify it)
+		crawled := i.quarantineRecord(common.QuarantineTypeEvent, ev)
+
+		created, changed, err := i.ingest(ev, listed)
+		if err != nil {
+			if rejected, ok := err.(*RejectedError); ok {
+				run.Rejected++
+				i.quarantine(crawled, rejected, logger)
+			} else {
+				complete = false
+			}
+			logger.Error("Failed to ingest event", zap.Error(err))
+			run.AddError(err)
+			continue
+		}
+		switch {
+		case created:
+			run.Inserted++
+		case changed:
+			run.Updated++
+		default:
+			run.Unchanged++
+		}
+		//invalid performers are skipped by the performer store so keep them for review
+		for _, p := range ev.Performers {
+			if rejected := performerRejection(p); rejected != nil {
+				record := i.quarantineRecord(common.QuarantineTypePerformer, p)
+				record.EventID = ev.ID
+				i.quarantine(record, rejected, logger)
+			}
+		}
+		seenIDs = append(seenIDs, ev.ID)
+		if ev.Date.After(lastDate) {
+			lastDate = ev.Date
+		}
+	}
+
+	// an empty or partially stored crawl is more likely a broken parser or DB problem than every event being
+	// removed so don't risk flagging everything
+	if len(seenIDs) == 0 || !complete {
+		logger.Info("Skipped reconciling removed events")
+		return run, nil
+	}
+	removed, err := i.EventStore.ReconcileSource(c.Name(), seenIDs, lastDate, otherSourceGrace)
+	if err != nil {
+		logger.Error("Failed to reconcile removed events", zap.Error(err))
+		run.AddError(err)
+		return run, nil
 	}
+	logger.Info(fmt.Sprintf("Marked %d events removed from source", removed))
+	return run, nil
 }
 
-func (i *Ingest) Ingest(event *common.Event) error {
+func (i *Ingest) finishRun(run *common.CrawlRun, logger *zap.Logger) {
+	endTime := time.Now()
+	run.EndTime = &endTime
+
+	logger.Info(
+		"Crawl finished",
+		zap.Int64("discovered", run.Discovered),
+		zap.Int64("inserted", run.Inserted),
+		zap.Int64("updated", run.Updated),
+		zap.Int64("unchanged", run.Unchanged),
+		zap.Int64("rejected", run.Rejected),
+		zap.Int("errors", len(run.Errors)),
+	)
+	if i.CrawlRunStore == nil || run.ID == 0 {
+		return
+	}
+	if err := i.CrawlRunStore.FinishRun(run); err != nil {
+		logger.Error("Failed to record crawl run", zap.Error(err))
+	}
+}
+
+// prepare runs the visitors over the event and checks the result can be stored.
+func (i *Ingest) prepare(event *common.Event) error {
 	//pre-process record
 	for _, v := range i.EventVisitors {
 		v.Visit(event)
 	}
 
+	if event.Venue == nil || !event.Venue.IsValid() {
+		return &RejectedError{Reason: fmt.Sprintf("invalid venue %+v", event.Venue)}
+	}
+	if !event.IsValid() {
+		return &RejectedError{Reason: fmt.Sprintf("invalid event %+v", event)}
+	}
+	return nil
+}
+
+// performerRejection explains why the performer can't be stored or returns nil if it can.
+func performerRejection(performer *common.Performer) *RejectedError {
+	if performer.Name == "" {
+		return &RejectedError{Reason: "performer has no name"}
+	}
+	if !performer.IsValid() {
+		return &RejectedError{Reason: "performer has no genre"}
+	}
+	return nil
+}
+
+// Ingest stores the event along with its venue and performers. created is true if the event did not already exist.
+func (i *Ingest) Ingest(event *common.Event) (created bool, err error) {
+	created, _, err = i.ingest(event, nil)
+	return created, err
+}
+
+// ingest stores the event. listed is everything else the source currently lists (if known) which is used to tell
+// a changed start time from a new event. changed is true if an existing event was changed (see
+// event.Store.EventMustExist).
+func (i *Ingest) ingest(event *common.Event, listed crawlListings) (created bool, changed bool, err error) {
+	if err := i.prepare(event); err != nil {
+		return false, false, err
+	}
+
 	tx, err := i.DB.Begin()
 	if err != nil {
-		return err
+		return false, false, err
 	}
 
 	err = func(tr *dbr.Tx) error {
 
-		//event must have an existing venue
-		if err := i.VenueStore.VenueMustExist(tr, event.Venue); err != nil {
+		//the same event may already have been listed by a different source
+		duplicate, score, err := i.findDuplicate(tr, event)
+		if err != nil {
 			return err
 		}
 
@@ -88,25 +377,71 @@ func (i *Ingest) Ingest(event *common.Event) error {
 			}
 		}
 
-		return i.EventStore.EventMustExist(tr, event)
+		if duplicate != nil {
+			i.Logger.Debug(
+				"Merging duplicate event",
+				zap.Int64("event", duplicate.ID),
+				zap.String("source", event.Source),
+				zap.Float64("score", score),
+			)
+			event.ID = duplicate.ID
+			event.Venue = duplicate.Venue
+			if changed, err = i.EventStore.MergeEvent(tr, event); err != nil {
+				return err
+			}
+			return i.EventStore.StoreEventSource(tr, event.ID, event.Source, score)
+		}
+
+		//event must have an existing venue
+		if err := i.VenueStore.VenueMustExist(tr, event.Venue); err != nil {
+			return err
+		}
+
+		if event.ID, err = i.EventStore.FindEventID(tr, event); err != nil {
+			return err
+		}
+		if event.ID == 0 {
+			retimed, err := i.findRetimed(tr, event, listed)
+			if err != nil {
+				return err
+			}
+			if retimed != nil {
+				i.Logger.Debug(
+					"Moving re-timed event",
+					zap.Int64("event", retimed.ID),
+					zap.Time("from", retimed.Date),
+					zap.Time("to", event.Date),
+				)
+				event.ID = retimed.ID
+			}
+		}
+		created = event.ID == 0
+
+		if changed, err = i.EventStore.EventMustExist(tr, event); err != nil {
+			return err
+		}
+		if event.Source == "" {
+			return nil
+		}
+		return i.EventStore.StoreEventSource(tr, event.ID, event.Source, 1)
 	}(tx)
 
 	if err == nil {
 		if err := tx.Commit(); err != nil {
-			return err
+			return false, false, err
 		}
 	} else {
 		if txerr := tx.Rollback(); txerr != nil {
-			return errors.New(fmt.Sprintf("%s -> %s", err, txerr))
+			return false, false, errors.New(fmt.Sprintf("%s -> %s", err, txerr))
 		}
-		return err
+		return false, false, err
 	}
 
-	return nil
+	return created, changed && !created, nil
 }
 
 func (i *Ingest) Cleanup() {
-	res, err := i.DB.Exec(`UPDATE event SET deleted=1 WHERE date(date) < date('now') AND deleted=0`)
+	res, err := i.DB.Exec(`UPDATE event SET deleted=1 WHERE date(coalesce(end_date, date)) < date('now') AND deleted=0`)
 	if err != nil {
 		i.Logger.Error("Cleaned failed", zap.Error(err))
 		return
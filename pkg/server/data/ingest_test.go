@@ -0,0 +1,256 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/warmans/fakt-api/pkg/server/data/source"
+	"github.com/warmans/fakt-api/pkg/server/data/source/extract"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"github.com/warmans/fakt-api/pkg/server/data/store/event"
+	"github.com/warmans/fakt-api/pkg/server/data/store/performer"
+	"github.com/warmans/fakt-api/pkg/server/data/store/quarantine"
+	"github.com/warmans/fakt-api/pkg/server/data/store/storetest"
+	"github.com/warmans/fakt-api/pkg/server/data/store/venue"
+	"go.uber.org/zap"
+)
+
+// newTestIngest creates an ingest backed by a new database.
+func newTestIngest(t *testing.T) (*Ingest, func()) {
+	db, cleanup := storetest.MustOpenDB(t)
+	performerStore := &performer.Store{DB: db.NewSession(nil), Logger: zap.NewNop()}
+	eventStore := &event.Store{DB: db.NewSession(nil), PerformerStore: performerStore}
+	return &Ingest{
+		Logger:          zap.NewNop(),
+		DB:              db.NewSession(nil),
+		EventStore:      eventStore,
+		VenueStore:      &venue.Store{DB: db.NewSession(nil), EventStore: eventStore},
+		PerformerStore:  performerStore,
+		QuarantineStore: &quarantine.Store{DB: db.NewSession(nil)},
+	}, cleanup
+}
+
+// mustFindEvents returns all events (including deleted ones) by date.
+func mustFindEvents(t *testing.T, ingest *Ingest) []*common.Event {
+	events := make([]*common.Event, 0)
+	for _, deleted := range []bool{false, true} {
+		f := &event.Filter{ShowDeleted: deleted}
+		f.PageSize = 100
+		found, err := ingest.EventStore.FindEvents(f)
+		if err != nil {
+			t.Fatalf("failed to find events: %s", err.Error())
+		}
+		events = append(events, found...)
+	}
+	return events
+}
+
+// blockingCrawler fails once released so nothing is ingested.
+type blockingCrawler struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (c *blockingCrawler) Name() string {
+	return "blocking"
+}
+
+func (c *blockingCrawler) Crawl(ctx context.Context) ([]*common.Event, error) {
+	c.started <- struct{}{}
+	<-c.release
+	return nil, errors.New("released")
+}
+
+func TestRunCrawlerWaitsForRunningCrawlerWithoutSlot(t *testing.T) {
+
+	blocking := &blockingCrawler{started: make(chan struct{}, 2), release: make(chan struct{})}
+	slow := &source.ConfiguredCrawler{Crawler: blocking, Config: &source.CrawlerConfig{Name: "slow"}, Timeout: time.Minute}
+	other := &source.ConfiguredCrawler{Crawler: &staticCrawler{err: errors.New("failed")}, Config: &source.CrawlerConfig{Name: "other"}, Timeout: time.Minute}
+
+	ingest := &Ingest{Logger: zap.NewNop(), MaxConcurrency: 2, Crawlers: []*source.ConfiguredCrawler{slow, other}}
+
+	firstDone := make(chan struct{})
+	go func() {
+		defer close(firstDone)
+		ingest.RunCrawler(context.Background(), slow)
+	}()
+	<-blocking.started
+
+	//a second run of the same crawler waits...
+	ctx, cancel := context.WithCancel(context.Background())
+	secondDone := make(chan error, 1)
+	go func() {
+		run, err := ingest.RunCrawler(ctx, slow)
+		if run != nil {
+			err = errors.New("expected no run")
+		}
+		secondDone <- err
+	}()
+	time.Sleep(time.Millisecond * 50)
+
+	//...without stopping other crawlers using the remaining slot
+	otherDone := make(chan struct{})
+	go func() {
+		defer close(otherDone)
+		ingest.RunCrawler(context.Background(), other)
+	}()
+	select {
+	case <-otherDone:
+	case <-time.After(time.Second):
+		t.Fatal("other crawler was blocked by a crawler waiting for itself")
+	}
+
+	//and can be cancelled while it waits
+	cancel()
+	select {
+	case err := <-secondDone:
+		if err != context.Canceled {
+			t.Errorf("expected cancelled wait got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiting for a running crawler could not be cancelled")
+	}
+
+	close(blocking.release)
+	<-firstDone
+	if len(blocking.started) != 0 {
+		t.Error("cancelled run should not have crawled")
+	}
+}
+
+func TestCrawlStoresExtractedPerformersWithoutGenre(t *testing.T) {
+
+	ingest, cleanup := newTestIngest(t)
+	defer cleanup()
+
+	date := time.Now().Truncate(time.Hour*24).AddDate(0, 0, 7).Add(time.Hour * 20)
+	listings := []*common.Event{
+		{Date: date, Venue: &common.Venue{Name: "K9"}, Type: "Konzert", Description: "Heute: KOTZREIZ und DJ Lala LIVE"},
+		{Date: date, Venue: &common.Venue{Name: "Köpi"}, Type: "Konzert", Description: `"Lost Lyrics" (aus Berlin)`},
+		{Date: date, Venue: &common.Venue{Name: "Zielona Gora"}, Type: "Konzert", Performers: []*common.Performer{{Name: "Dead Moon"}}},
+	}
+	for strategy, ev := range map[string]*common.Event{"caps": listings[0], "quoted": listings[1]} {
+		chain, err := extract.NewChain([]string{strategy})
+		if err != nil {
+			t.Fatalf("failed to create chain: %s", err.Error())
+		}
+		chain.Apply(ev)
+	}
+
+	if _, err := ingest.Crawl(context.Background(), &staticCrawler{events: listings}); err != nil {
+		t.Fatalf("crawl failed: %s", err.Error())
+	}
+
+	stored := make(map[string]*common.Performer)
+	for _, ev := range mustFindEvents(t, ingest) {
+		for _, p := range ev.Performers {
+			stored[p.Name] = p
+		}
+	}
+
+	examples := []struct {
+		Name      string
+		Extractor string
+	}{
+		{Name: "KOTZREIZ", Extractor: "caps"},
+		{Name: "Lost Lyrics", Extractor: "quoted"},
+	}
+	for _, ex := range examples {
+		p, ok := stored[ex.Name]
+		if !ok {
+			t.Errorf("expected %s to be stored", ex.Name)
+			continue
+		}
+		if p.Extractor != ex.Extractor || p.Confidence == 0 {
+			t.Errorf("expected %s to be stored with the %s extractor and its confidence got %s %f", ex.Name, ex.Extractor, p.Extractor, p.Confidence)
+		}
+	}
+
+	//a performer that wasn't extracted still needs a genre so it is kept for review instead
+	if _, ok := stored["Dead Moon"]; ok {
+		t.Error("expected performer with no genre or extractor not to be stored")
+	}
+	f := &quarantine.Filter{Type: common.QuarantineTypePerformer}
+	f.PageSize = 10
+	records, err := ingest.QuarantineStore.FindRecords(f)
+	if err != nil {
+		t.Fatalf("failed to find records: %s", err.Error())
+	}
+	if len(records) != 1 || records[0].Reason != "performer has no genre" {
+		t.Fatalf("expected performer with no genre to be quarantined got %+v", records)
+	}
+}
+
+func TestRecrawlUnchangedListingStoresNoRevisions(t *testing.T) {
+
+	ingest, cleanup := newTestIngest(t)
+	defer cleanup()
+
+	date := time.Now().Truncate(time.Hour*24).AddDate(0, 0, 7).Add(time.Hour * 20)
+	listings := func() []*common.Event {
+		return []*common.Event{{
+			Date:        date,
+			Venue:       &common.Venue{Name: "K9"},
+			Type:        "Konzert",
+			Description: `"Kotzreiz" (Punk aus Leipzig) und Lost Lyrics`,
+			Performers:  []*common.Performer{{Name: "Kotzreiz", Genre: "Punk aus Leipzig"}, {Name: "Lost Lyrics"}},
+		}}
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := ingest.Crawl(context.Background(), &staticCrawler{events: listings()}); err != nil {
+			t.Fatalf("crawl %d failed: %s", i, err.Error())
+		}
+	}
+
+	events := mustFindEvents(t, ingest)
+	if len(events) != 1 || len(events[0].Performers) != 1 {
+		t.Fatalf("expected one event with one stored performer got %+v", events)
+	}
+	revisions, err := ingest.EventStore.FindEventRevisions(events[0].ID)
+	if err != nil {
+		t.Fatalf("failed to find revisions: %s", err.Error())
+	}
+	if len(revisions) != 0 {
+		t.Errorf("expected no revisions for an unchanged listing got %d", len(revisions))
+	}
+}
+
+func TestCrawlRunCountsOnlyChangedEventsAsUpdated(t *testing.T) {
+
+	ingest, cleanup := newTestIngest(t)
+	defer cleanup()
+
+	date := time.Now().Truncate(time.Hour*24).AddDate(0, 0, 7).Add(time.Hour * 20)
+	listings := func(description string) []*common.Event {
+		return []*common.Event{
+			{Date: date, Venue: &common.Venue{Name: "K9"}, Type: "Konzert", Description: "Kotzreiz"},
+			{Date: date, Venue: &common.Venue{Name: "Köpi"}, Type: "Konzert", Description: description},
+		}
+	}
+
+	examples := []struct {
+		Description string
+		Inserted    int64
+		Updated     int64
+		Unchanged   int64
+	}{
+		{Description: "Lost Lyrics", Inserted: 2},
+		{Description: "Lost Lyrics", Unchanged: 2},
+		{Description: "Lost Lyrics + Dead Moon", Updated: 1, Unchanged: 1},
+	}
+	for k, ex := range examples {
+		run, err := ingest.Crawl(context.Background(), &staticCrawler{events: listings(ex.Description)})
+		if err != nil {
+			t.Fatalf("crawl %d failed: %s", k, err.Error())
+		}
+		if run.Inserted != ex.Inserted || run.Updated != ex.Updated || run.Unchanged != ex.Unchanged {
+			t.Errorf(
+				"crawl %d: expected %d inserted, %d updated and %d unchanged got %d, %d and %d",
+				k, ex.Inserted, ex.Updated, ex.Unchanged, run.Inserted, run.Updated, run.Unchanged,
+			)
+		}
+	}
+}
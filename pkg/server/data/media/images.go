@@ -1,26 +1,91 @@
 package media
 
-import "github.com/warmans/coldlink"
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/warmans/coldlink"
+	"github.com/warmans/fakt-api/pkg/server/data/source"
+)
+
+const maxOrigImageSizeInBytes = 1024 * 1024 * 1024 * 5
 
 //ImageMirror wraps other library to ensure consistent image creation (same targets, max size etc.)
 type ImageMirror struct {
 	coldlink *coldlink.Coldlink
+	// HTTP is used to download images (normally a source.Fetcher client so image hosts are treated like any
+	// other crawled site).
+	HTTP *http.Client
 }
 
-func NewImageMirror(storageDir string) *ImageMirror {
+func NewImageMirror(storageDir string, client *http.Client) *ImageMirror {
 	return &ImageMirror{
-		coldlink: &coldlink.Coldlink{StorageDir: storageDir, MaxOrigImageSizeInBytes: 1024 * 1024 * 1024 * 5},
+		coldlink: &coldlink.Coldlink{StorageDir: storageDir, MaxOrigImageSizeInBytes: maxOrigImageSizeInBytes},
+		HTTP:     client,
 	}
 }
 
 func (i *ImageMirror) Mirror(remoteURL string, localName string) (map[string]string, error) {
-	return i.coldlink.Get(
-		remoteURL,
-		localName,
-		[]*coldlink.TargetSpec{
-			{Name: "orig", Op: coldlink.OpOriginal},
-			{Name: "sm", Op: coldlink.OpThumb, Width: 150, Height: 150},
-			{Name: "xs", Op: coldlink.OpThumb, Width: 60, Height: 60},
-		},
-	)
+
+	rawFilePath, err := i.download(remoteURL)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(rawFilePath)
+
+	results := make(map[string]string)
+	for _, target := range []*coldlink.TargetSpec{
+		{Name: "orig", Op: coldlink.OpOriginal},
+		{Name: "sm", Op: coldlink.OpThumb, Width: 150, Height: 150},
+		{Name: "xs", Op: coldlink.OpThumb, Width: 60, Height: 60},
+	} {
+		var name string
+		if target.Op == coldlink.OpOriginal {
+			name, err = i.coldlink.MakeOrig(rawFilePath, localName, target.Name)
+		} else {
+			name, err = i.coldlink.MakeThumb(rawFilePath, localName, target.Name, target.Width, target.Height)
+		}
+		if err != nil {
+			return results, err
+		}
+		results[target.Name] = name
+	}
+	return results, nil
+}
+
+// download stores the image in a temporary file (with the same extension) and returns its path. coldlink does
+// its own downloading but doesn't allow the client to be replaced.
+func (i *ImageMirror) download(remoteURL string) (string, error) {
+
+	body, err := source.Get(context.Background(), i.HTTP, remoteURL)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	tf, err := ioutil.TempFile(os.TempDir(), "cold")
+	if err != nil {
+		return "", err
+	}
+	written, err := io.Copy(tf, io.LimitReader(body, maxOrigImageSizeInBytes+1))
+	tf.Close()
+	if err == nil && written > maxOrigImageSizeInBytes {
+		err = fmt.Errorf("origin image was too big (more than %d bytes)", maxOrigImageSizeInBytes)
+	}
+	if err != nil {
+		os.Remove(tf.Name())
+		return "", err
+	}
+
+	finalName := tf.Name() + filepath.Ext(remoteURL)
+	if err := os.Rename(tf.Name(), finalName); err != nil {
+		os.Remove(tf.Name())
+		return "", err
+	}
+	return finalName, nil
 }
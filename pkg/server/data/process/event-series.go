@@ -0,0 +1,273 @@
+package process
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/warmans/dbr"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultSeriesMinEvents is the number of weeks an event must be listed in to be a series.
+	DefaultSeriesMinEvents = 3
+	// DefaultSeriesMinSimilarity is how similar the first line of the descriptions must be.
+	DefaultSeriesMinSimilarity = 0.8
+)
+
+// seriesPeriod is how far back events are considered (as an sqlite date modifier). Older events keep their series.
+const seriesPeriod = "-6 months"
+
+func GetSeriesRunner(interval time.Duration, logger *zap.Logger) *Runner {
+	return &Runner{
+		processor: &Series{MinEvents: DefaultSeriesMinEvents, MinSimilarity: DefaultSeriesMinSimilarity},
+		interval:  interval,
+		logger:    logger,
+	}
+}
+
+// SeriesEvent is an event considered for a series.
+type SeriesEvent struct {
+	ID          int64
+	VenueID     int64
+	Date        time.Time
+	Type        string
+	Description string
+	// SeriesID is the series the event was previously part of (if any).
+	SeriesID int64
+}
+
+// title is the part of the event that usually stays the same each week. The rest of the description tends to
+// change e.g. the menu or line-up.
+func (e *SeriesEvent) title() string {
+	return strings.TrimSpace(strings.SplitN(strings.TrimSpace(e.Description), "\n", 2)[0])
+}
+
+// sameSeries is true if the events have the same type and similar titles.
+func sameSeries(a, b *SeriesEvent, minSimilarity float64) bool {
+	if common.NormalizeName(a.Type) != common.NormalizeName(b.Type) {
+		return false
+	}
+	if a.title() == "" && b.title() == "" {
+		return true
+	}
+	return common.Similarity(a.title(), b.title()) >= minSimilarity || common.TokenOverlap(a.title(), b.title()) >= minSimilarity
+}
+
+// DetectSeries groups events at the same venue, weekday and (local) time with the same type and a similar title. Only
+// groups listed on at least minEvents different days are returned. Events are expected to be ordered by date.
+func DetectSeries(events []*SeriesEvent, minEvents int, minSimilarity float64) [][]*SeriesEvent {
+
+	slots := make(map[string][][]*SeriesEvent)
+	slotKeys := make([]string, 0)
+	for _, e := range events {
+		key := fmt.Sprintf("%d/%d/%s", e.VenueID, e.Date.Weekday(), e.Date.Format("15:04"))
+		if _, ok := slots[key]; !ok {
+			slotKeys = append(slotKeys, key)
+		}
+
+		//compare with the first event of each group to stop groups drifting
+		matched := false
+		for k, group := range slots[key] {
+			if sameSeries(group[0], e, minSimilarity) {
+				slots[key][k] = append(group, e)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			slots[key] = append(slots[key], []*SeriesEvent{e})
+		}
+	}
+
+	series := make([][]*SeriesEvent, 0)
+	for _, key := range slotKeys {
+		for _, group := range slots[key] {
+			days := make(map[string]bool)
+			for _, e := range group {
+				days[e.Date.Format("2006-01-02")] = true
+			}
+			if len(days) >= minEvents {
+				series = append(series, group)
+			}
+		}
+	}
+	return series
+}
+
+// Series links recurring events (e.g. a weekly Vokü) to an event_series.
+type Series struct {
+	MinEvents     int
+	MinSimilarity float64
+}
+
+func (p *Series) Update(db *dbr.Session) error {
+
+	events, err := p.findEvents(db)
+	if err != nil {
+		return err
+	}
+	detected := DetectSeries(events, p.MinEvents, p.MinSimilarity)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = func(tr *dbr.Tx) error {
+
+		//events are re-assigned each time since e.g. a series may have ended or changed its time
+		if _, err := tr.Exec("UPDATE event SET series_id=NULL WHERE date > date('now', ?)", seriesPeriod); err != nil {
+			return fmt.Errorf("failed to reset event series because %s", err.Error())
+		}
+
+		claimed := make(map[int64]bool)
+		for _, members := range detected {
+			seriesID, err := p.storeSeries(tr, members, claimed)
+			if err != nil {
+				return err
+			}
+			for _, e := range members {
+				if _, err := tr.Exec("UPDATE event SET series_id=? WHERE id=?", seriesID, e.ID); err != nil {
+					return fmt.Errorf("failed to link event %d to series because %s", e.ID, err.Error())
+				}
+			}
+		}
+
+		if _, err := tr.Exec(
+			`UPDATE event_series SET
+				num_events=(SELECT count(*) FROM event WHERE series_id = event_series.id),
+				first_date=(SELECT min(date) FROM event WHERE series_id = event_series.id),
+				last_date=(SELECT max(date) FROM event WHERE series_id = event_series.id)`,
+		); err != nil {
+			return fmt.Errorf("failed to update series stats because %s", err.Error())
+		}
+
+		_, err := tr.Exec("DELETE FROM event_series WHERE id NOT IN (SELECT series_id FROM event WHERE series_id IS NOT NULL)")
+		if err != nil {
+			return fmt.Errorf("failed to remove empty series because %s", err.Error())
+		}
+		return nil
+	}(tx)
+
+	if err != nil {
+		if txerr := tx.Rollback(); txerr != nil {
+			return fmt.Errorf("%s -> %s", err, txerr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// findEvents returns recent and upcoming events ordered by date.
+func (p *Series) findEvents(db *dbr.Session) ([]*SeriesEvent, error) {
+
+	res, err := db.Query(
+		`SELECT id, venue_id, date, coalesce(type, ''), coalesce(description, ''), coalesce(series_id, 0)
+		FROM event
+		WHERE date > date('now', ?) AND venue_id IS NOT NULL
+		ORDER BY date, id`,
+		seriesPeriod,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find series candidates because %s", err.Error())
+	}
+	defer res.Close()
+
+	events := make([]*SeriesEvent, 0)
+	for res.Next() {
+		e := &SeriesEvent{}
+		if err := res.Scan(&e.ID, &e.VenueID, &e.Date, &e.Type, &e.Description, &e.SeriesID); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, res.Err()
+}
+
+// storeSeries creates or updates the series for the events. The series most of the events were previously
+// linked to is kept so IDs are stable between updates unless another group already claimed it.
+func (p *Series) storeSeries(tr *dbr.Tx, members []*SeriesEvent, claimed map[int64]bool) (int64, error) {
+
+	previous := make(map[int64]int)
+	var seriesID int64
+	for _, e := range members {
+		if e.SeriesID == 0 || claimed[e.SeriesID] {
+			continue
+		}
+		previous[e.SeriesID]++
+		if previous[e.SeriesID] > previous[seriesID] {
+			seriesID = e.SeriesID
+		}
+	}
+
+	latest := members[len(members)-1]
+	now := time.Now().Format(common.DateFormatSQL)
+
+	if seriesID == 0 {
+		res, err := tr.Exec(
+			"INSERT INTO event_series (venue_id, weekday, time, name, description, created, updated) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			latest.VenueID,
+			int(latest.Date.Weekday()),
+			latest.Date.Format("15:04"),
+			seriesName(members),
+			latest.Description,
+			now,
+			now,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert series because %s", err.Error())
+		}
+		seriesID, err = res.LastInsertId()
+		claimed[seriesID] = true
+		return seriesID, err
+	}
+	claimed[seriesID] = true
+
+	_, err := tr.Exec(
+		"UPDATE event_series SET venue_id=?, weekday=?, time=?, name=?, description=?, updated=? WHERE id=?",
+		latest.VenueID,
+		int(latest.Date.Weekday()),
+		latest.Date.Format("15:04"),
+		seriesName(members),
+		latest.Description,
+		now,
+		seriesID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update series %d because %s", seriesID, err.Error())
+	}
+	return seriesID, nil
+}
+
+// seriesName is the most common title of the events (the latest wins ties) or the type if none have a title.
+func seriesName(members []*SeriesEvent) string {
+	counts := make(map[string]int)
+	for _, e := range members {
+		if title := e.title(); title != "" {
+			counts[title]++
+		}
+	}
+	titles := make([]string, 0, len(counts))
+	for title := range counts {
+		titles = append(titles, title)
+	}
+	if len(titles) == 0 {
+		return members[len(members)-1].Type
+	}
+
+	lastSeen := make(map[string]int)
+	for k, e := range members {
+		lastSeen[e.title()] = k
+	}
+	sort.Slice(titles, func(i, j int) bool {
+		if counts[titles[i]] != counts[titles[j]] {
+			return counts[titles[i]] > counts[titles[j]]
+		}
+		return lastSeen[titles[i]] > lastSeen[titles[j]]
+	})
+	return titles[0]
+}
@@ -0,0 +1,110 @@
+package process
+
+import (
+	"testing"
+	"time"
+)
+
+func weekly(firstID int64, venueID int64, start time.Time, weeks int, eventType string, description string) []*SeriesEvent {
+	events := make([]*SeriesEvent, 0, weeks)
+	for i := 0; i < weeks; i++ {
+		events = append(events, &SeriesEvent{
+			ID:          firstID + int64(i),
+			VenueID:     venueID,
+			Date:        start.AddDate(0, 0, 7*i),
+			Type:        eventType,
+			Description: description,
+		})
+	}
+	return events
+}
+
+func TestDetectSeries(t *testing.T) {
+
+	tuesday := time.Date(2018, 7, 3, 20, 0, 0, 0, time.UTC)
+
+	examples := []struct {
+		name     string
+		events   []*SeriesEvent
+		expected [][]int64
+	}{
+		{
+			name:     "weekly event",
+			events:   weekly(1, 1, tuesday, 4, "Kneipe & Vokü", "Vokü im Keller\nvegan"),
+			expected: [][]int64{{1, 2, 3, 4}},
+		},
+		{
+			name:     "too few weeks",
+			events:   weekly(1, 1, tuesday, 2, "Kneipe & Vokü", "Vokü im Keller"),
+			expected: [][]int64{},
+		},
+		{
+			name: "only the first line of the description is compared",
+			events: []*SeriesEvent{
+				{ID: 1, VenueID: 1, Date: tuesday, Type: "Kneipe", Description: "Offene Bühne\nFoo spielt"},
+				{ID: 2, VenueID: 1, Date: tuesday.AddDate(0, 0, 7), Type: "Kneipe", Description: "Offene Bühne!\nBar liest"},
+				{ID: 3, VenueID: 1, Date: tuesday.AddDate(0, 0, 21), Type: "Kneipe", Description: "offene bühne"},
+			},
+			expected: [][]int64{{1, 2, 3}},
+		},
+		{
+			name: "different events in the same slot",
+			events: []*SeriesEvent{
+				{ID: 1, VenueID: 1, Date: tuesday, Type: "Konzert", Description: "Kotzreiz live"},
+				{ID: 2, VenueID: 1, Date: tuesday.AddDate(0, 0, 7), Type: "Konzert", Description: "Lost Lyrics"},
+				{ID: 3, VenueID: 1, Date: tuesday.AddDate(0, 0, 14), Type: "Konzert", Description: "Foo"},
+				{ID: 4, VenueID: 1, Date: tuesday.AddDate(0, 0, 21), Type: "Film", Description: "Kotzreiz live"},
+			},
+			expected: [][]int64{},
+		},
+		{
+			name: "different venue, weekday or time",
+			events: []*SeriesEvent{
+				{ID: 1, VenueID: 1, Date: tuesday, Type: "Vokü", Description: "Vokü"},
+				{ID: 2, VenueID: 2, Date: tuesday.AddDate(0, 0, 7), Type: "Vokü", Description: "Vokü"},
+				{ID: 3, VenueID: 1, Date: tuesday.AddDate(0, 0, 15), Type: "Vokü", Description: "Vokü"},
+				{ID: 4, VenueID: 1, Date: tuesday.AddDate(0, 0, 21).Add(time.Hour), Type: "Vokü", Description: "Vokü"},
+			},
+			expected: [][]int64{},
+		},
+		{
+			name:     "two series at the same venue",
+			events:   append(weekly(1, 1, tuesday, 3, "Vokü", "Vokü"), weekly(10, 1, tuesday.AddDate(0, 0, 2), 3, "Kneipe", "Tresenabend")...),
+			expected: [][]int64{{1, 2, 3}, {10, 11, 12}},
+		},
+	}
+
+	for _, ex := range examples {
+		detected := DetectSeries(ex.events, DefaultSeriesMinEvents, DefaultSeriesMinSimilarity)
+		if len(detected) != len(ex.expected) {
+			t.Errorf("%s: expected %d series got %d", ex.name, len(ex.expected), len(detected))
+			continue
+		}
+		for k, members := range detected {
+			if len(members) != len(ex.expected[k]) {
+				t.Errorf("%s: expected series %d to have %d events got %d", ex.name, k, len(ex.expected[k]), len(members))
+				continue
+			}
+			for i, e := range members {
+				if e.ID != ex.expected[k][i] {
+					t.Errorf("%s: expected event %d at %d in series %d got %d", ex.name, ex.expected[k][i], i, k, e.ID)
+				}
+			}
+		}
+	}
+}
+
+func TestSeriesName(t *testing.T) {
+	members := []*SeriesEvent{
+		{Type: "Kneipe", Description: "Tresen\nfoo"},
+		{Type: "Kneipe", Description: "Tresenabend"},
+		{Type: "Kneipe", Description: "Tresen"},
+		{Type: "Kneipe", Description: "Tresenabend\nbar"},
+	}
+	if name := seriesName(members); name != "Tresenabend" {
+		t.Errorf("Expected latest of the most common titles got %s", name)
+	}
+	if name := seriesName([]*SeriesEvent{{Type: "Vokü"}}); name != "Vokü" {
+		t.Errorf("Expected type when there are no titles got %s", name)
+	}
+}
@@ -1,6 +1,7 @@
 package process
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -14,12 +15,13 @@ type Runner struct {
 	logger    *zap.Logger
 }
 
-func (r *Runner) Run(db *dbr.Session) {
+// Run updates the processor every interval until ctx is cancelled.
+func (r *Runner) Run(ctx context.Context, db *dbr.Session) {
 
 	logger := r.logger.With(zap.String("processor", fmt.Sprintf("%T", r.processor)))
 
 	logger.Info(fmt.Sprintf("Starting processor. Updating every %d minutes", int64(r.interval / time.Minute)))
-	for {
+	for ctx.Err() == nil {
 		startTime := time.Now()
 		if err := r.processor.Update(db); err != nil {
 			logger.Error(fmt.Sprintf("Processor failed to complete update with error: %s", err.Error()))
@@ -29,9 +31,13 @@ func (r *Runner) Run(db *dbr.Session) {
 		logger.Info(fmt.Sprintf("Ran in %d seconds", int64(runDuration / time.Second)))
 
 		if waitTime := r.interval - runDuration; waitTime > 0 {
-			time.Sleep(waitTime)
+			select {
+			case <-time.After(waitTime):
+			case <-ctx.Done():
+			}
 		}
 	}
+	logger.Info("Stopped processor")
 }
 
 type Processor interface {
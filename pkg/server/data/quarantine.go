@@ -0,0 +1,128 @@
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/warmans/dbr"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"go.uber.org/zap"
+)
+
+// ErrNoQuarantine is returned by Resubmit if the ingest has no quarantine store.
+var ErrNoQuarantine = errors.New("quarantine is not configured")
+
+// quarantineRecord creates a quarantine record for an event or performer. The source is taken from the event.
+func (i *Ingest) quarantineRecord(recordType string, record interface{}) *common.QuarantinedRecord {
+	q := &common.QuarantinedRecord{Type: recordType}
+	if ev, ok := record.(*common.Event); ok {
+		q.Source = ev.Source
+	}
+	payload, err := json.Marshal(record)
+	if err != nil {
+		//should never happen, keep the record anyway so there is at least a trace of it
+		payload, _ = json.Marshal(fmt.Sprintf("%+v", record))
+	}
+	q.Payload = payload
+	return q
+}
+
+// quarantine stores the rejected record so it can be fixed and re-submitted later.
+func (i *Ingest) quarantine(record *common.QuarantinedRecord, rejected *RejectedError, logger *zap.Logger) {
+	if i.QuarantineStore == nil {
+		return
+	}
+	record.Reason = rejected.Reason
+	if err := i.QuarantineStore.AddRecord(record); err != nil {
+		logger.Error("Failed to quarantine rejected record", zap.Error(err))
+	}
+}
+
+// Resubmit ingests a quarantined record again (e.g. after its payload was edited) and removes it from quarantine.
+// If it is rejected again the reason is updated and a *RejectedError returned. The stored event or performer is
+// returned on success.
+func (i *Ingest) Resubmit(id int64) (interface{}, error) {
+
+	if i.QuarantineStore == nil {
+		return nil, ErrNoQuarantine
+	}
+
+	record, err := i.QuarantineStore.FindRecord(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var stored interface{}
+	switch record.Type {
+	case common.QuarantineTypeEvent:
+		event := &common.Event{}
+		if decodeErr := json.Unmarshal(record.Payload, event); decodeErr != nil {
+			err = &RejectedError{Reason: fmt.Sprintf("invalid event payload: %s", decodeErr.Error())}
+			break
+		}
+		if event.Source == "" {
+			event.Source = record.Source
+		}
+		_, err = i.Ingest(event)
+		stored = event
+	case common.QuarantineTypePerformer:
+		performer := &common.Performer{}
+		if decodeErr := json.Unmarshal(record.Payload, performer); decodeErr != nil {
+			err = &RejectedError{Reason: fmt.Sprintf("invalid performer payload: %s", decodeErr.Error())}
+			break
+		}
+		err = i.ingestPerformer(performer, record.EventID)
+		stored = performer
+	default:
+		return nil, fmt.Errorf("unknown quarantined record type %s", record.Type)
+	}
+
+	if rejected, ok := err.(*RejectedError); ok {
+		record.Reason = rejected.Reason
+		if updateErr := i.QuarantineStore.UpdateRecord(record); updateErr != nil {
+			return nil, updateErr
+		}
+		return nil, rejected
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stored, i.QuarantineStore.DiscardRecord(record.ID)
+}
+
+// ingestPerformer stores a performer on its own, adding it to the event it was listed with (if any).
+func (i *Ingest) ingestPerformer(performer *common.Performer, eventID int64) error {
+
+	if rejected := performerRejection(performer); rejected != nil {
+		return rejected
+	}
+
+	tx, err := i.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = func(tr *dbr.Tx) error {
+		if err := i.PerformerStore.PerformerMustExist(tr, performer); err != nil {
+			return err
+		}
+		if eventID == 0 {
+			return nil
+		}
+		_, err := tr.Exec(
+			"INSERT OR IGNORE INTO event_performer (event_id, performer_id) SELECT id, ? FROM event WHERE id=?",
+			performer.ID,
+			eventID,
+		)
+		return err
+	}(tx)
+
+	if err == nil {
+		return tx.Commit()
+	}
+	if txerr := tx.Rollback(); txerr != nil {
+		return fmt.Errorf("%s -> %s", err, txerr)
+	}
+	return err
+}
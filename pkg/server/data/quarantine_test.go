@@ -0,0 +1,127 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"github.com/warmans/fakt-api/pkg/server/data/store/quarantine"
+)
+
+func TestCrawlQuarantinesRepeatedRejectionsOnce(t *testing.T) {
+
+	ingest, cleanup := newTestIngest(t)
+	defer cleanup()
+
+	date := time.Now().Truncate(time.Hour*24).AddDate(0, 0, 7).Add(time.Hour * 20)
+	listings := func() []*common.Event {
+		return []*common.Event{
+			{Date: date, Venue: &common.Venue{Name: "K9"}, Type: "Konzert", Performers: []*common.Performer{{Name: ""}}},
+			{Date: date, Venue: &common.Venue{}, Type: "Konzert"},
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := ingest.Crawl(context.Background(), &staticCrawler{events: listings()}); err != nil {
+			t.Fatalf("crawl %d failed: %s", i, err.Error())
+		}
+	}
+
+	f := &quarantine.Filter{}
+	f.PageSize = 100
+	records, err := ingest.QuarantineStore.FindRecords(f)
+	if err != nil {
+		t.Fatalf("failed to find records: %s", err.Error())
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected an event and a performer to be quarantined once got %d records", len(records))
+	}
+	for _, r := range records {
+		if r.Updated == nil {
+			t.Errorf("expected %s record to be updated by later crawls", r.Type)
+		}
+	}
+}
+
+func TestResubmit(t *testing.T) {
+
+	ingest, cleanup := newTestIngest(t)
+	defer cleanup()
+
+	date := time.Now().Truncate(time.Hour*24).AddDate(0, 0, 7).Add(time.Hour * 20)
+	listings := []*common.Event{
+		{Date: date, Venue: &common.Venue{Name: "K9"}, Type: "Konzert", Performers: []*common.Performer{{Name: ""}}},
+		{Date: date.AddDate(0, 0, 1), Venue: &common.Venue{}, Type: "Konzert"},
+	}
+	if _, err := ingest.Crawl(context.Background(), &staticCrawler{events: listings}); err != nil {
+		t.Fatalf("crawl failed: %s", err.Error())
+	}
+
+	records := make(map[string]*common.QuarantinedRecord)
+	for _, recordType := range []string{common.QuarantineTypeEvent, common.QuarantineTypePerformer} {
+		f := &quarantine.Filter{Type: recordType}
+		f.PageSize = 10
+		found, err := ingest.QuarantineStore.FindRecords(f)
+		if err != nil || len(found) != 1 {
+			t.Fatalf("expected one quarantined %s got %d (%v)", recordType, len(found), err)
+		}
+		records[recordType] = found[0]
+	}
+
+	examples := []struct {
+		Name     string
+		Record   *common.QuarantinedRecord
+		Payload  string
+		Rejected bool
+	}{
+		{Name: "event still invalid", Record: records[common.QuarantineTypeEvent], Rejected: true},
+		{Name: "event fixed", Record: records[common.QuarantineTypeEvent], Payload: `{"date":"` + date.AddDate(0, 0, 1).Format(time.RFC3339) + `","venue":{"name":"Köpi"},"type":"Konzert"}`},
+		{Name: "performer still invalid", Record: records[common.QuarantineTypePerformer], Rejected: true},
+		{Name: "performer fixed", Record: records[common.QuarantineTypePerformer], Payload: `{"name":"Kotzreiz","genre":"Punk"}`},
+	}
+
+	for _, ex := range examples {
+		if ex.Payload != "" {
+			ex.Record.Payload = []byte(ex.Payload)
+			if err := ingest.QuarantineStore.UpdateRecord(ex.Record); err != nil {
+				t.Fatalf("%s: failed to update record: %s", ex.Name, err.Error())
+			}
+		}
+		_, err := ingest.Resubmit(ex.Record.ID)
+		if _, rejected := err.(*RejectedError); rejected != ex.Rejected {
+			t.Errorf("%s: expected rejected %v got %v", ex.Name, ex.Rejected, err)
+			continue
+		}
+		if err != nil && !ex.Rejected {
+			t.Fatalf("%s: resubmit failed: %s", ex.Name, err.Error())
+		}
+
+		stored, err := ingest.QuarantineStore.FindRecord(ex.Record.ID)
+		if ex.Rejected {
+			if err != nil || stored.Updated == nil {
+				t.Errorf("%s: expected record to be kept and updated got %+v (%v)", ex.Name, stored, err)
+			}
+		} else if err != quarantine.ErrRecordNotFound {
+			t.Errorf("%s: expected record to be removed got %v", ex.Name, err)
+		}
+	}
+
+	events := mustFindEvents(t, ingest)
+	if len(events) != 2 {
+		t.Fatalf("expected resubmitted event to be stored got %d events", len(events))
+	}
+	if events[1].Venue.Name != "Köpi" {
+		t.Errorf("expected resubmitted event at Köpi got %s", events[1].Venue.Name)
+	}
+	if len(events[0].Performers) != 1 || events[0].Performers[0].Name != "Kotzreiz" {
+		t.Errorf("expected resubmitted performer to be added to its event got %+v", events[0].Performers)
+	}
+
+	if _, err := ingest.Resubmit(records[common.QuarantineTypeEvent].ID); err != quarantine.ErrRecordNotFound {
+		t.Errorf("expected resubmitting a removed record to fail got %v", err)
+	}
+	if _, err := (&Ingest{}).Resubmit(1); err != ErrNoQuarantine {
+		t.Errorf("expected resubmit without a quarantine to fail got %v", err)
+	}
+}
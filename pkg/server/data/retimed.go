@@ -0,0 +1,77 @@
+package data
+
+import (
+	"math"
+	"time"
+
+	"github.com/warmans/dbr"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+const (
+	// retimedWindow is how far a source can move an event's start time and still have it matched to the event.
+	retimedWindow = time.Hour * 24
+	// minRetimedSimilarity is how similar the descriptions of a re-timed listing and the event must be.
+	minRetimedSimilarity = 0.9
+)
+
+// crawlListings are the start times (unix) and descriptions of everything a crawler listed.
+type crawlListings map[int64][]string
+
+func newCrawlListings(events []*common.Event) crawlListings {
+	listings := make(crawlListings)
+	for _, e := range events {
+		listings[e.Date.Unix()] = append(listings[e.Date.Unix()], e.Description)
+	}
+	return listings
+}
+
+// Listed is true if something similar to the event is listed at the event's start time.
+func (l crawlListings) Listed(e *common.Event) bool {
+	for _, description := range l[e.Date.Unix()] {
+		if similarDescription(description, e.Description) {
+			return true
+		}
+	}
+	return false
+}
+
+func similarDescription(a, b string) bool {
+	return math.Max(common.Similarity(a, b), common.TokenOverlap(a, b)) >= minRetimedSimilarity
+}
+
+// findRetimed returns the event the listing replaces if its source moved the start time i.e. the closest event
+// from the same source at the same venue within retimedWindow with a matching description that isn't still
+// listed at its old time. The listing's venue must already be stored.
+func (i *Ingest) findRetimed(tr *dbr.Tx, event *common.Event, listed crawlListings) (*common.Event, error) {
+
+	if event.Source == "" {
+		return nil, nil
+	}
+
+	candidates, err := i.EventStore.FindEventCandidates(tr, event.Date, retimedWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *common.Event
+	for _, candidate := range candidates {
+		if candidate.Source != event.Source || candidate.Venue.ID != event.Venue.ID || candidate.Date.Equal(event.Date) {
+			continue
+		}
+		if !similarDescription(candidate.Description, event.Description) || listed.Listed(candidate) {
+			continue
+		}
+		if best == nil || absDuration(candidate.Date.Sub(event.Date)) < absDuration(best.Date.Sub(event.Date)) {
+			best = candidate
+		}
+	}
+	return best, nil
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
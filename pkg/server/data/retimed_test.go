@@ -0,0 +1,67 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+func TestCrawlMatchesRetimedEvents(t *testing.T) {
+
+	ingest, cleanup := newTestIngest(t)
+	defer cleanup()
+
+	day := time.Now().Truncate(time.Hour*24).AddDate(0, 0, 7)
+	listing := func(hour int, description string) *common.Event {
+		return &common.Event{Date: day.Add(time.Hour * time.Duration(hour)), Venue: &common.Venue{Name: "K9"}, Type: "Film", Description: description}
+	}
+	crawl := func(events ...*common.Event) {
+		if _, err := ingest.Crawl(context.Background(), &staticCrawler{events: events}); err != nil {
+			t.Fatalf("crawl failed: %s", err.Error())
+		}
+	}
+
+	//two screenings of the same film and a concert
+	crawl(listing(18, "Film: Der Baader Meinhof Komplex"), listing(21, "Film: Der Baader Meinhof Komplex"), listing(20, "Konzert: Kotzreiz"))
+	original := mustFindEvents(t, ingest)
+	if len(original) != 3 {
+		t.Fatalf("expected 3 events got %d", len(original))
+	}
+
+	//the concert moves to 22:00 and a third screening is added at 23:00
+	crawl(
+		listing(18, "Film: Der Baader Meinhof Komplex"),
+		listing(21, "Film: Der Baader Meinhof Komplex"),
+		listing(22, "Konzert: Kotzreiz"),
+		listing(23, "Film: Der Baader Meinhof Komplex"),
+	)
+
+	events := mustFindEvents(t, ingest)
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events got %d", len(events))
+	}
+	for k, hour := range []int{18, 21, 22, 23} {
+		if !events[k].Date.Equal(day.Add(time.Hour * time.Duration(hour))) {
+			t.Errorf("expected event %d at %d:00 got %s", k, hour, events[k].Date)
+		}
+		if events[k].Status != common.EventStatusActive {
+			t.Errorf("expected event %d to be active got %s", k, events[k].Status)
+		}
+	}
+	if events[0].ID != original[0].ID || events[1].ID != original[2].ID {
+		t.Error("screenings that are still listed should not have been moved")
+	}
+	if events[2].ID != original[1].ID {
+		t.Errorf("expected the concert (%d) to be moved got event %d", original[1].ID, events[2].ID)
+	}
+
+	revisions, err := ingest.EventStore.FindEventRevisions(original[1].ID)
+	if err != nil {
+		t.Fatalf("failed to find revisions: %s", err.Error())
+	}
+	if len(revisions) != 1 || len(revisions[0].Changes) != 1 || revisions[0].Changes[0].Field != "date" {
+		t.Fatalf("expected a single date revision got %+v", revisions)
+	}
+}
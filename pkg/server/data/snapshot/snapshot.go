@@ -0,0 +1,340 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/warmans/dbr"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"github.com/warmans/fakt-api/pkg/server/data/store/event"
+	"github.com/warmans/fakt-api/pkg/server/data/store/performer"
+	"github.com/warmans/fakt-api/pkg/server/data/store/tag"
+	"github.com/warmans/fakt-api/pkg/server/data/store/venue"
+)
+
+// Version is written to the header of every export. Exports with a different version cannot be imported.
+const Version = 1
+
+// Record types. Records are exported in this order so everything a record refers to comes before it.
+const (
+	TypeHeader    = "header"
+	TypeTag       = "tag"
+	TypeVenue     = "venue"
+	TypePerformer = "performer"
+	TypeEvent     = "event"
+)
+
+// pageSize is how many records are loaded at once when exporting.
+const pageSize = 500
+
+// Record is one line of an export. Only the field matching the type is set.
+type Record struct {
+	Type string `json:"type"`
+
+	// Version and Created are only set on the header.
+	Version int        `json:"version,omitempty"`
+	Created *time.Time `json:"created,omitempty"`
+
+	Tag       *common.Tag       `json:"tag,omitempty"`
+	Venue     *common.Venue     `json:"venue,omitempty"`
+	Performer *common.Performer `json:"performer,omitempty"`
+	Event     *common.Event     `json:"event,omitempty"`
+	// Deleted is set for past events that have been cleaned up.
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// Counts is the number of records of each type exported or imported.
+type Counts map[string]int
+
+// Snapshot exports the database to and imports it from JSON lines. Imported records go through the same
+// store methods as ingested events so they are merged with existing venues, performers and events rather than
+// duplicating them, and the IDs they refer to are remapped to the IDs in the importing database.
+type Snapshot struct {
+	DB             *dbr.Session
+	EventStore     *event.Store
+	VenueStore     *venue.Store
+	PerformerStore *performer.Store
+	TagStore       *tag.Store
+}
+
+func (s *Snapshot) Export(ctx context.Context, w io.Writer) (Counts, error) {
+
+	counts := Counts{}
+	enc := json.NewEncoder(w)
+
+	write := func(rec *Record) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := enc.Encode(rec); err != nil {
+			return fmt.Errorf("failed to write %s because %s", rec.Type, err.Error())
+		}
+		counts[rec.Type]++
+		return nil
+	}
+
+	now := time.Now()
+	if err := write(&Record{Type: TypeHeader, Version: Version, Created: &now}); err != nil {
+		return counts, err
+	}
+
+	for page := int64(1); ; page++ {
+		f := &tag.Filter{}
+		f.Page, f.PageSize = page, pageSize
+		tags, err := s.TagStore.FindTags(f)
+		if err != nil {
+			return counts, fmt.Errorf("failed to export tags because %s", err.Error())
+		}
+		for _, t := range tags {
+			if err := write(&Record{Type: TypeTag, Tag: t}); err != nil {
+				return counts, err
+			}
+		}
+		if len(tags) < pageSize {
+			break
+		}
+	}
+
+	for page := int64(1); ; page++ {
+		f := &venue.Filter{SortCol: "name", SortAsc: true}
+		f.Page, f.PageSize = page, pageSize
+		venues, err := s.VenueStore.FindVenues(f)
+		if err != nil {
+			return counts, fmt.Errorf("failed to export venues because %s", err.Error())
+		}
+		for _, v := range venues {
+			if err := write(&Record{Type: TypeVenue, Venue: v}); err != nil {
+				return counts, err
+			}
+		}
+		if len(venues) < pageSize {
+			break
+		}
+	}
+
+	for page := int64(1); ; page++ {
+		f := &performer.Filter{}
+		f.Page, f.PageSize = page, pageSize
+		performers, err := s.PerformerStore.FindPerformers(f)
+		if err != nil {
+			return counts, fmt.Errorf("failed to export performers because %s", err.Error())
+		}
+		for _, p := range performers {
+			if err := write(&Record{Type: TypePerformer, Performer: p}); err != nil {
+				return counts, err
+			}
+		}
+		if len(performers) < pageSize {
+			break
+		}
+	}
+
+	//current and deleted events can only be loaded separately
+	for _, deleted := range []bool{false, true} {
+		for page := int64(1); ; page++ {
+			f := &event.Filter{ShowDeleted: deleted}
+			f.Page, f.PageSize = page, pageSize
+			events, err := s.EventStore.FindEvents(f)
+			if err != nil {
+				return counts, fmt.Errorf("failed to export events because %s", err.Error())
+			}
+			for _, e := range events {
+				if err := write(&Record{Type: TypeEvent, Event: e, Deleted: deleted}); err != nil {
+					return counts, err
+				}
+			}
+			if len(events) < pageSize {
+				break
+			}
+		}
+	}
+
+	return counts, nil
+}
+
+// Import reads an export into the database. Nothing is imported if any record fails.
+func (s *Snapshot) Import(ctx context.Context, r io.Reader) (Counts, error) {
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := Counts{}
+	err = func(tr *dbr.Tx) error {
+
+		im := &importer{
+			snapshot:     s,
+			tr:           tr,
+			venueIDs:     map[int64]int64{},
+			performerIDs: map[int64]int64{},
+			festivalIDs:  map[int64]int64{},
+		}
+
+		dec := json.NewDecoder(r)
+		for line := 1; ; line++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			rec := &Record{}
+			if err := dec.Decode(rec); err != nil {
+				if err == io.EOF {
+					if line == 1 {
+						return errors.New("export is empty")
+					}
+					return nil
+				}
+				return fmt.Errorf("line %d: failed to decode record because %s", line, err.Error())
+			}
+			if line == 1 && rec.Type != TypeHeader {
+				return errors.New("export has no header")
+			}
+			if err := im.importRecord(rec); err != nil {
+				return fmt.Errorf("line %d: %s", line, err.Error())
+			}
+			counts[rec.Type]++
+		}
+	}(tx)
+
+	if err == nil {
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+	} else {
+		if txerr := tx.Rollback(); txerr != nil {
+			return nil, fmt.Errorf("%s -> %s", err, txerr)
+		}
+		return nil, err
+	}
+	return counts, nil
+}
+
+type importer struct {
+	snapshot *Snapshot
+	tr       *dbr.Tx
+
+	// exported IDs mapped to IDs in this database
+	venueIDs     map[int64]int64
+	performerIDs map[int64]int64
+	festivalIDs  map[int64]int64
+}
+
+func (im *importer) importRecord(rec *Record) error {
+	switch rec.Type {
+	case TypeHeader:
+		if rec.Version != Version {
+			return fmt.Errorf("export version %d is not supported (expected %d)", rec.Version, Version)
+		}
+		return nil
+	case TypeTag:
+		if rec.Tag == nil {
+			return errors.New("tag record has no tag")
+		}
+		return im.importTag(rec.Tag)
+	case TypeVenue:
+		if rec.Venue == nil {
+			return errors.New("venue record has no venue")
+		}
+		return im.importVenue(rec.Venue)
+	case TypePerformer:
+		if rec.Performer == nil {
+			return errors.New("performer record has no performer")
+		}
+		return im.importPerformer(rec.Performer)
+	case TypeEvent:
+		if rec.Event == nil {
+			return errors.New("event record has no event")
+		}
+		return im.importEvent(rec.Event, rec.Deleted)
+	default:
+		return fmt.Errorf("unknown record type %s", rec.Type)
+	}
+}
+
+func (im *importer) importTag(t *common.Tag) error {
+	//tags are referred to by name so there is no need to remap them, parents come from the taxonomy
+	normalized, ok := tag.Normalize(t.Tag)
+	if !ok {
+		return nil
+	}
+	if _, err := tag.MustExist(im.tr, normalized); err != nil {
+		return fmt.Errorf("failed to import tag %s because %s", t.Tag, err.Error())
+	}
+	return nil
+}
+
+func (im *importer) importVenue(v *common.Venue) error {
+	exportedID := v.ID
+	v.ID = 0
+	if err := im.snapshot.VenueStore.VenueMustExist(im.tr, v); err != nil {
+		return fmt.Errorf("failed to import venue %s because %s", v.Name, err.Error())
+	}
+	im.venueIDs[exportedID] = v.ID
+	return nil
+}
+
+func (im *importer) importPerformer(p *common.Performer) error {
+	//exported performers were validated when they were first stored so they are treated like structured data
+	//(i.e. they don't need a genre)
+	p.Extractor = common.PerformerExtractorStructured
+	if !p.IsValid() {
+		return nil
+	}
+	exportedID := p.ID
+	p.ID = 0
+	if err := im.snapshot.PerformerStore.PerformerMustExist(im.tr, p); err != nil {
+		return fmt.Errorf("failed to import performer %s because %s", p.Name, err.Error())
+	}
+	im.performerIDs[exportedID] = p.ID
+	return nil
+}
+
+func (im *importer) importEvent(e *common.Event, deleted bool) error {
+
+	if e.Venue == nil {
+		return errors.New("event has no venue")
+	}
+	//venues and performers not exported separately (e.g. a hand written file) are imported with the event
+	if id, ok := im.venueIDs[e.Venue.ID]; ok {
+		e.Venue.ID = id
+	} else if err := im.importVenue(e.Venue); err != nil {
+		return err
+	}
+	for _, p := range e.Performers {
+		if id, ok := im.performerIDs[p.ID]; ok {
+			p.ID = id
+		} else if err := im.importPerformer(p); err != nil {
+			return err
+		}
+	}
+
+	//festivals are only exported with their events so the first event of each festival finds or creates it
+	var exportedFestivalID int64
+	if e.Festival != nil {
+		exportedFestivalID = e.Festival.ID
+		e.Festival.ID = im.festivalIDs[exportedFestivalID]
+	}
+
+	e.ID = 0
+	if _, err := im.snapshot.EventStore.EventMustExist(im.tr, e); err != nil {
+		return fmt.Errorf("failed to import event because %s", err.Error())
+	}
+	if e.Festival != nil && exportedFestivalID != 0 {
+		im.festivalIDs[exportedFestivalID] = e.Festival.ID
+	}
+	for _, src := range e.Sources {
+		if err := im.snapshot.EventStore.StoreEventSource(im.tr, e.ID, src.Source, src.Score); err != nil {
+			return err
+		}
+	}
+	if deleted {
+		if _, err := im.tr.Exec("UPDATE event SET deleted=1 WHERE id=?", e.ID); err != nil {
+			return fmt.Errorf("failed to mark event deleted because %s", err.Error())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/warmans/dbr"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"github.com/warmans/fakt-api/pkg/server/data/store/event"
+	"github.com/warmans/fakt-api/pkg/server/data/store/performer"
+	"github.com/warmans/fakt-api/pkg/server/data/store/storetest"
+	"github.com/warmans/fakt-api/pkg/server/data/store/tag"
+	"github.com/warmans/fakt-api/pkg/server/data/store/venue"
+	"go.uber.org/zap"
+)
+
+func newTestSnapshot(t *testing.T) (*Snapshot, func()) {
+	db, cleanup := storetest.MustOpenDB(t)
+	performerStore := &performer.Store{DB: db.NewSession(nil), Logger: zap.NewNop()}
+	eventStore := &event.Store{DB: db.NewSession(nil), PerformerStore: performerStore}
+	return &Snapshot{
+		DB:             db.NewSession(nil),
+		EventStore:     eventStore,
+		VenueStore:     &venue.Store{DB: db.NewSession(nil), EventStore: eventStore},
+		PerformerStore: performerStore,
+		TagStore:       &tag.Store{DB: db.NewSession(nil)},
+	}, cleanup
+}
+
+// mustStoreEvents stores the events along with their venues and performers.
+func mustStoreEvents(t *testing.T, s *Snapshot, events ...*common.Event) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = func(tr *dbr.Tx) error {
+		for _, e := range events {
+			if err := s.VenueStore.VenueMustExist(tr, e.Venue); err != nil {
+				return err
+			}
+			for _, p := range e.Performers {
+				if err := s.PerformerStore.PerformerMustExist(tr, p); err != nil {
+					return err
+				}
+			}
+			if _, err := s.EventStore.EventMustExist(tr, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}(tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("failed to store events: %s", err.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// mustFindEvents returns all events by date.
+func mustFindEvents(t *testing.T, s *Snapshot) []*common.Event {
+	f := &event.Filter{}
+	f.PageSize = 100
+	events, err := s.EventStore.FindEvents(f)
+	if err != nil {
+		t.Fatalf("failed to find events: %s", err.Error())
+	}
+	return events
+}
+
+func TestImportRemapsFestivals(t *testing.T) {
+
+	exporter, cleanup := newTestSnapshot(t)
+	defer cleanup()
+	importer, cleanup := newTestSnapshot(t)
+	defer cleanup()
+
+	start := time.Now().Truncate(time.Hour*24).AddDate(0, 0, 7)
+	k9 := func() *common.Venue {
+		return &common.Venue{Name: "K9", Address: "Kinzigstr. 9"}
+	}
+
+	//the importing database has a different festival with the same ID
+	mustStoreEvents(t, importer, &common.Event{Date: start, Venue: k9(), Type: "Konzert", Festival: &common.Festival{Name: "Monis Rache"}})
+	mustStoreEvents(
+		t,
+		exporter,
+		&common.Event{Date: start.Add(time.Hour), Venue: k9(), Type: "Konzert", Festival: &common.Festival{Name: "Fusion", StartDate: start, EndDate: start.AddDate(0, 0, 1)}},
+		&common.Event{Date: start.AddDate(0, 0, 1), Venue: k9(), Type: "Party", Festival: &common.Festival{Name: "Fusion", StartDate: start, EndDate: start.AddDate(0, 0, 1)}},
+	)
+
+	buf := &bytes.Buffer{}
+	if _, err := exporter.Export(context.Background(), buf); err != nil {
+		t.Fatalf("failed to export: %s", err.Error())
+	}
+	if _, err := importer.Import(context.Background(), buf); err != nil {
+		t.Fatalf("failed to import: %s", err.Error())
+	}
+
+	events := mustFindEvents(t, importer)
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events got %d", len(events))
+	}
+	if events[0].Festival == nil || events[0].Festival.Name != "Monis Rache" {
+		t.Errorf("expected existing event to stay in its festival got %+v", events[0].Festival)
+	}
+	for _, e := range events[1:] {
+		if e.Festival == nil || e.Festival.Name != "Fusion" {
+			t.Errorf("expected imported event %d to be at Fusion got %+v", e.ID, e.Festival)
+		}
+	}
+	if events[1].Festival.ID != events[2].Festival.ID || events[1].Festival.ID == events[0].Festival.ID {
+		t.Errorf("expected imported events to share a new festival got %d and %d", events[1].Festival.ID, events[2].Festival.ID)
+	}
+}
+
+// mustCount returns the number of rows in the table.
+func mustCount(t *testing.T, s *Snapshot, table string) int {
+	var count int
+	if err := s.DB.QueryRow("SELECT count(*) FROM " + table).Scan(&count); err != nil {
+		t.Fatalf("failed to count %s: %s", table, err.Error())
+	}
+	return count
+}
+
+func TestExportImport(t *testing.T) {
+
+	exporter, cleanup := newTestSnapshot(t)
+	defer cleanup()
+	importer, cleanup := newTestSnapshot(t)
+	defer cleanup()
+
+	date := time.Now().Truncate(time.Hour*24).AddDate(0, 0, 7).Add(time.Hour * 20)
+	listings := func() []*common.Event {
+		return []*common.Event{
+			{
+				Date:        date,
+				Venue:       &common.Venue{Name: "K9", Address: "Kinzigstr. 9"},
+				Type:        "Konzert",
+				Description: "Kotzreiz",
+				Performers:  []*common.Performer{{Name: "Kotzreiz", Genre: "Punk"}},
+				Tags:        []string{"punk"},
+			},
+			{
+				Date:        date.AddDate(0, 0, 1),
+				Venue:       &common.Venue{Name: "Köpi", Address: "Köpenicker Str. 137"},
+				Type:        "Konzert",
+				Description: "Zaunpfahl und Kotzreiz",
+				Performers:  []*common.Performer{{Name: "Zaunpfahl", Genre: "Punk"}, {Name: "Kotzreiz", Genre: "Punk"}},
+			},
+		}
+	}
+
+	//the importing database already has the first event (with different IDs) and something of its own
+	mustStoreEvents(
+		t,
+		importer,
+		&common.Event{Date: date.AddDate(0, 0, -1), Venue: &common.Venue{Name: "Tommy-Weisbecker-Haus", Address: "Wilhelmstr. 9"}, Type: "Kneipe", Description: "Tresen"},
+		listings()[0],
+	)
+	mustStoreEvents(t, exporter, listings()...)
+
+	buf := &bytes.Buffer{}
+	if _, err := exporter.Export(context.Background(), buf); err != nil {
+		t.Fatalf("failed to export: %s", err.Error())
+	}
+	export := buf.Bytes()
+
+	//importing twice must not change anything the second time
+	for i := 0; i < 2; i++ {
+		if _, err := importer.Import(context.Background(), bytes.NewReader(export)); err != nil {
+			t.Fatalf("import %d failed: %s", i, err.Error())
+		}
+		for table, expected := range map[string]int{"venue": 3, "performer": 2, "event": 3, "event_performer": 3} {
+			if count := mustCount(t, importer, table); count != expected {
+				t.Errorf("import %d: expected %d rows in %s got %d", i, expected, table, count)
+			}
+		}
+	}
+
+	events := mustFindEvents(t, importer)
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events got %d", len(events))
+	}
+	examples := []struct {
+		Venue      string
+		Performers []string
+		Tags       []string
+	}{
+		{Venue: "Tommy-Weisbecker-Haus"},
+		{Venue: "K9", Performers: []string{"Kotzreiz"}, Tags: []string{"punk"}},
+		{Venue: "Köpi", Performers: []string{"Kotzreiz", "Zaunpfahl"}},
+	}
+	for k, ex := range examples {
+		e := events[k]
+		vf := &venue.Filter{Name: ex.Venue}
+		vf.PageSize = 10
+		v, err := importer.VenueStore.FindVenues(vf)
+		if err != nil || len(v) != 1 {
+			t.Fatalf("failed to find venue %s: %v", ex.Venue, err)
+		}
+		if e.Venue.ID != v[0].ID {
+			t.Errorf("event %d: expected venue %s (%d) got %d", k, ex.Venue, v[0].ID, e.Venue.ID)
+		}
+
+		performers := make(map[string]bool)
+		for _, p := range e.Performers {
+			f := &performer.Filter{}
+			f.IDs, f.PageSize = []int64{p.ID}, 10
+			found, err := importer.PerformerStore.FindPerformers(f)
+			if err != nil || len(found) != 1 {
+				t.Fatalf("event %d: failed to find performer %d: %v", k, p.ID, err)
+			}
+			performers[found[0].Name] = true
+		}
+		if len(performers) != len(ex.Performers) {
+			t.Errorf("event %d: expected performers %v got %v", k, ex.Performers, performers)
+		}
+		for _, name := range ex.Performers {
+			if !performers[name] {
+				t.Errorf("event %d: expected performer %s got %v", k, name, performers)
+			}
+		}
+		if len(e.Tags) != len(ex.Tags) || (len(ex.Tags) > 0 && e.Tags[0] != ex.Tags[0]) {
+			t.Errorf("event %d: expected tags %v got %v", k, ex.Tags, e.Tags)
+		}
+	}
+}
+
+func TestFailedImportChangesNothing(t *testing.T) {
+
+	exporter, cleanup := newTestSnapshot(t)
+	defer cleanup()
+	importer, cleanup := newTestSnapshot(t)
+	defer cleanup()
+
+	date := time.Now().Truncate(time.Hour*24).AddDate(0, 0, 7).Add(time.Hour * 20)
+	mustStoreEvents(t, importer, &common.Event{Date: date, Venue: &common.Venue{Name: "K9", Address: "Kinzigstr. 9"}, Type: "Konzert", Description: "Kotzreiz"})
+	mustStoreEvents(
+		t,
+		exporter,
+		&common.Event{Date: date, Venue: &common.Venue{Name: "K9", Address: "Kinzigstr. 9"}, Type: "Konzert", Description: "Kotzreiz und Gäste", Tags: []string{"punk"}},
+		&common.Event{Date: date.AddDate(0, 0, 1), Venue: &common.Venue{Name: "Köpi", Address: "Köpenicker Str. 137"}, Type: "Konzert", Performers: []*common.Performer{{Name: "Zaunpfahl", Genre: "Punk"}}},
+	)
+
+	buf := &bytes.Buffer{}
+	if _, err := exporter.Export(context.Background(), buf); err != nil {
+		t.Fatalf("failed to export: %s", err.Error())
+	}
+	//everything has been imported by the time the broken record is reached
+	buf.WriteString(`{"type":"event","event":{"date":"2018-07-20T20:00:00Z"}}` + "\n")
+
+	tables := []string{"tag", "venue", "performer", "event", "event_performer", "event_tag", "event_revision"}
+	before := make(map[string]int)
+	for _, table := range tables {
+		before[table] = mustCount(t, importer, table)
+	}
+	original := mustFindEvents(t, importer)
+
+	if _, err := importer.Import(context.Background(), buf); err == nil {
+		t.Fatal("expected import to fail")
+	}
+
+	for _, table := range tables {
+		if count := mustCount(t, importer, table); count != before[table] {
+			t.Errorf("expected %d rows in %s got %d", before[table], table, count)
+		}
+	}
+	events := mustFindEvents(t, importer)
+	if len(events) != 1 || events[0].Description != original[0].Description || len(events[0].Tags) != 0 {
+		t.Errorf("expected existing event to be unchanged got %+v", events)
+	}
+}
@@ -1,6 +1,7 @@
 package source
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -8,7 +9,8 @@ import (
 )
 
 type Crawler interface {
-	Crawl() ([]*common.Event, error)
+	// Crawl fetches all events from the source. It should give up once ctx is done.
+	Crawl(ctx context.Context) ([]*common.Event, error)
 	Name() string
 }
 
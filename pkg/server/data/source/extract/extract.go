@@ -0,0 +1,96 @@
+// Package extract finds the performers playing an event in its listing text.
+package extract
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+// Candidate is a performer found by an extractor with its confidence (0-1) that it really is a performer.
+type Candidate struct {
+	Performer  *common.Performer
+	Confidence float64
+}
+
+// PerformerExtractor is a strategy for finding performers in an event.
+type PerformerExtractor interface {
+	Name() string
+	Extract(e *common.Event) []*Candidate
+}
+
+var extractors = map[string]PerformerExtractor{
+	StrategyQuoted:     &Quoted{},
+	StrategyLineup:     &Lineup{},
+	StrategyLines:      &Lines{},
+	StrategyCaps:       &Caps{},
+	StrategyStructured: &Structured{},
+}
+
+// Default is used by crawlers asked to guess performers if nothing else is configured.
+var Default = Chain{extractors[StrategyQuoted]}
+
+// Chain runs several extractors and keeps the performers from the most confident one.
+type Chain []PerformerExtractor
+
+// NewChain looks up extractors by name.
+func NewChain(names []string) (Chain, error) {
+	chain := make(Chain, 0, len(names))
+	for _, name := range names {
+		ex, ok := extractors[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown performer extractor %s", name)
+		}
+		chain = append(chain, ex)
+	}
+	return chain, nil
+}
+
+// Apply replaces the event's performers with those found by the extractor with the highest average confidence.
+// Earlier extractors win ties. Each performer records the winning extractor and its own confidence.
+func (c Chain) Apply(e *common.Event) {
+
+	var winner PerformerExtractor
+	var best []*Candidate
+	bestScore := 0.0
+
+	for _, ex := range c {
+		candidates := dedupe(ex.Extract(e))
+		if len(candidates) == 0 {
+			continue
+		}
+		if score := meanConfidence(candidates); score > bestScore {
+			winner, best, bestScore = ex, candidates, score
+		}
+	}
+
+	e.Performers = make([]*common.Performer, 0, len(best))
+	for _, cand := range best {
+		cand.Performer.Extractor = winner.Name()
+		cand.Performer.Confidence = cand.Confidence
+		e.Performers = append(e.Performers, cand.Performer)
+	}
+}
+
+func meanConfidence(candidates []*Candidate) float64 {
+	total := 0.0
+	for _, c := range candidates {
+		total += c.Confidence
+	}
+	return total / float64(len(candidates))
+}
+
+func dedupe(candidates []*Candidate) []*Candidate {
+	seen := make(map[string]bool)
+	unique := make([]*Candidate, 0, len(candidates))
+	for _, c := range candidates {
+		key := common.NormalizeName(c.Performer.Name)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, c)
+	}
+	return unique
+}
@@ -0,0 +1,103 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+func names(performers []*common.Performer) []string {
+	result := make([]string, 0, len(performers))
+	for _, p := range performers {
+		result = append(result, p.Name)
+	}
+	return result
+}
+
+func TestStrategies(t *testing.T) {
+
+	examples := []struct {
+		Name        string
+		Extractor   PerformerExtractor
+		Description string
+		Expected    []string
+	}{
+		{
+			Name:        "quoted",
+			Extractor:   &Quoted{},
+			Description: `Konzert mit "Kotzreiz" (Punk aus Leipzig) und "Lost Lyrics" (Anarcho Punk)`,
+			Expected:    []string{"Kotzreiz", "Lost Lyrics"},
+		},
+		{
+			Name:        "lineup with w/",
+			Extractor:   &Lineup{},
+			Description: "Soli-Abend w/ Kotzreiz, Lost Lyrics & Dead Moon",
+			Expected:    []string{"Kotzreiz", "Lost Lyrics", "Dead Moon"},
+		},
+		{
+			Name:        "lineup with +",
+			Extractor:   &Lineup{},
+			Description: "Kotzreiz (Punk) + Lost Lyrics + special guests",
+			Expected:    []string{"Kotzreiz", "Lost Lyrics"},
+		},
+		{
+			Name:        "line per act",
+			Extractor:   &Lines{},
+			Description: "Kotzreiz (Punk, Leipzig)\nLost Lyrics - Anarcho Punk",
+			Expected:    []string{"Kotzreiz", "Lost Lyrics"},
+		},
+		{
+			Name:        "single line is not a lineup",
+			Extractor:   &Lines{},
+			Description: "Kneipenabend",
+			Expected:    []string{},
+		},
+		{
+			Name:        "caps",
+			Extractor:   &Caps{},
+			Description: "Heute: KOTZREIZ, LOST LYRICS und DJ Lala LIVE",
+			Expected:    []string{"KOTZREIZ", "LOST LYRICS"},
+		},
+	}
+
+	for _, ex := range examples {
+		actual := names(dedupePerformers(ex.Extractor.Extract(&common.Event{Description: ex.Description})))
+		if !reflect.DeepEqual(actual, ex.Expected) {
+			t.Errorf("%s: expected %v got %v", ex.Name, ex.Expected, actual)
+		}
+	}
+}
+
+func dedupePerformers(candidates []*Candidate) []*common.Performer {
+	performers := make([]*common.Performer, 0)
+	for _, c := range dedupe(candidates) {
+		performers = append(performers, c.Performer)
+	}
+	return performers
+}
+
+func TestChainApply(t *testing.T) {
+
+	chain, err := NewChain([]string{"caps", "lineup", "quoted"})
+	if err != nil {
+		t.Fatalf("Unexpected error creating chain: %s", err.Error())
+	}
+
+	e := &common.Event{Description: `KONZERT: "Kotzreiz" (Punk aus Leipzig) + "Lost Lyrics" (Punk)`}
+	chain.Apply(e)
+
+	if got := names(e.Performers); !reflect.DeepEqual(got, []string{"Kotzreiz", "Lost Lyrics"}) {
+		t.Fatalf("unexpected performers %v", got)
+	}
+	if e.Performers[0].Extractor != StrategyQuoted || e.Performers[0].Confidence != 0.9 {
+		t.Errorf("unexpected extractor %s (%f)", e.Performers[0].Extractor, e.Performers[0].Confidence)
+	}
+	if e.Performers[0].Home != "Leipzig" {
+		t.Errorf("expected home to be extracted from genre got %s", e.Performers[0].Home)
+	}
+
+	if _, err := NewChain([]string{"nope"}); err == nil {
+		t.Error("expected error for unknown extractor")
+	}
+}
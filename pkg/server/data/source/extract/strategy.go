@@ -0,0 +1,235 @@
+package extract
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+// Extractor names as used in crawler config.
+const (
+	StrategyQuoted     = "quoted"
+	StrategyLineup     = "lineup"
+	StrategyLines      = "lines"
+	StrategyCaps       = "caps"
+	StrategyStructured = common.PerformerExtractorStructured
+)
+
+var (
+	quotedRe      = regexp.MustCompile(`"[^"]+"[\s]+?\([^(]+\)`)
+	quotedSpaceRe = regexp.MustCompile(`\"[\s]+\(`)
+	fromRe        = regexp.MustCompile(`(aus|from)\s+([^,\.\;]+)`)
+	genreRe       = regexp.MustCompile(`^(.+?)\s*\(([^()]+)\)\s*$`)
+	dashGenreRe   = regexp.MustCompile(`^(.+?)\s+[-–]\s+(.+)$`)
+	withRe        = regexp.MustCompile(`(?i)(?:\bw/|\bwith\b|\bmit\b|\bfeat\.?)\s*(.+)`)
+	lineupSepRe   = regexp.MustCompile(`\s*(?:\+|,|&|\band\b|\bund\b)\s*`)
+)
+
+// notNames are upper case words commonly found in listings that aren't performers.
+var notNames = map[string]bool{
+	"live": true, "dj": true, "djs": true, "tba": true, "konzert": true, "party": true, "soli": true,
+	"eintritt": true, "free": true, "vokü": true, "voku": true, "kneipe": true, "tresen": true, "open": true,
+	"doors": true, "einlass": true, "beginn": true, "uhr": true, "film": true, "lesung": true, "special": true,
+	"guests": true, "gäste": true, "und": true, "and": true, "mit": true, "with": true,
+}
+
+// Quoted finds performers written as `"Name" (genre aus City)`, the stressfaktor convention.
+type Quoted struct{}
+
+func (x *Quoted) Name() string {
+	return StrategyQuoted
+}
+
+func (x *Quoted) Extract(e *common.Event) []*Candidate {
+	candidates := make([]*Candidate, 0)
+	for _, raw := range quotedRe.FindAllString(e.Description, -1) {
+		parts := quotedSpaceRe.Split(raw, -1)
+		if len(parts) != 2 {
+			continue
+		}
+		perf := performer(strings.Trim(parts[0], `" `), strings.Trim(parts[1], "() "))
+		candidates = append(candidates, &Candidate{Performer: perf, Confidence: 0.9})
+	}
+	return candidates
+}
+
+// Lineup finds performers listed after "w/" or "with" or separated by "+" e.g. "Kotzreiz + Lost Lyrics (Punk)".
+type Lineup struct{}
+
+func (x *Lineup) Name() string {
+	return StrategyLineup
+}
+
+func (x *Lineup) Extract(e *common.Event) []*Candidate {
+	candidates := make([]*Candidate, 0)
+	for _, line := range lines(e.Description) {
+		list := ""
+		if match := withRe.FindStringSubmatch(line); match != nil {
+			list = match[1]
+		} else if strings.Contains(line, " + ") {
+			list = line
+		}
+		if list == "" {
+			continue
+		}
+		for _, part := range lineupSepRe.Split(list, -1) {
+			name, genre := splitGenre(part)
+			if !plausibleName(name) {
+				continue
+			}
+			candidates = append(candidates, &Candidate{Performer: performer(name, genre), Confidence: 0.6})
+		}
+	}
+	return candidates
+}
+
+// Lines treats each short line of the description as an act e.g. "Kotzreiz (Punk, Leipzig)" or
+// "Lost Lyrics - Anarcho Punk". At least two such lines are needed.
+type Lines struct{}
+
+func (x *Lines) Name() string {
+	return StrategyLines
+}
+
+func (x *Lines) Extract(e *common.Event) []*Candidate {
+	candidates := make([]*Candidate, 0)
+	for _, line := range lines(e.Description) {
+		name, genre := splitGenre(line)
+		if !plausibleName(name) || strings.HasSuffix(name, ":") {
+			continue
+		}
+		confidence := 0.4
+		if genre != "" {
+			confidence = 0.6
+		}
+		candidates = append(candidates, &Candidate{Performer: performer(name, genre), Confidence: confidence})
+	}
+	if len(candidates) < 2 {
+		return nil
+	}
+	return candidates
+}
+
+// Caps finds runs of upper case words e.g. "Heute: KOTZREIZ und LOST LYRICS live".
+type Caps struct{}
+
+func (x *Caps) Name() string {
+	return StrategyCaps
+}
+
+func (x *Caps) Extract(e *common.Event) []*Candidate {
+	candidates := make([]*Candidate, 0)
+	words := make([]string, 0)
+	flush := func() {
+		if len(words) > 0 {
+			name := strings.Join(words, " ")
+			if plausibleName(name) && len([]rune(name)) >= 3 {
+				candidates = append(candidates, &Candidate{Performer: performer(name, ""), Confidence: 0.4})
+			}
+		}
+		words = words[:0]
+	}
+	for _, line := range lines(e.Description) {
+		for _, word := range strings.Fields(line) {
+			trimmed := strings.TrimFunc(word, func(r rune) bool { return unicode.IsPunct(r) })
+			if isUpper(trimmed) && !notNames[strings.ToLower(trimmed)] {
+				words = append(words, trimmed)
+				//punctuation after a word e.g. a comma ends the name
+				if !strings.HasSuffix(word, trimmed) {
+					flush()
+				}
+				continue
+			}
+			flush()
+		}
+		flush()
+	}
+	return candidates
+}
+
+// Structured keeps performers the crawler took from structured data (e.g. JSON-LD).
+type Structured struct{}
+
+func (x *Structured) Name() string {
+	return StrategyStructured
+}
+
+func (x *Structured) Extract(e *common.Event) []*Candidate {
+	candidates := make([]*Candidate, 0)
+	for _, perf := range e.Performers {
+		if perf.Extractor == StrategyStructured {
+			candidates = append(candidates, &Candidate{Performer: perf, Confidence: 1})
+		}
+	}
+	return candidates
+}
+
+// performer creates a performer from a name and genre text, moving any "aus City" part of the genre to home.
+func performer(name, genre string) *common.Performer {
+	home := ""
+	if fromMatch := fromRe.FindStringSubmatch(genre); len(fromMatch) == 3 {
+		home = fromMatch[2]
+		genre = strings.Replace(genre, fromMatch[0], "", -1)
+	}
+	genre = strings.TrimSpace(genre)
+	tags := make([]string, 0)
+	for _, tag := range strings.Split(genre, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	if home != "" {
+		tags = append(tags, home)
+	}
+	return &common.Performer{Name: name, Genre: genre, Home: home, Tags: tags}
+}
+
+func splitGenre(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	if match := genreRe.FindStringSubmatch(s); match != nil {
+		return strings.Trim(match[1], `" `), strings.TrimSpace(match[2])
+	}
+	if match := dashGenreRe.FindStringSubmatch(s); match != nil {
+		return strings.Trim(match[1], `" `), strings.TrimSpace(match[2])
+	}
+	return strings.Trim(s, `" `), ""
+}
+
+// plausibleName rejects text that is too long or short to be a name or is only filler words.
+func plausibleName(name string) bool {
+	words := strings.Fields(name)
+	if len(words) == 0 || len(words) > 5 || len([]rune(name)) > 40 {
+		return false
+	}
+	for _, w := range words {
+		if !notNames[strings.ToLower(w)] {
+			return strings.IndexFunc(name, unicode.IsLetter) >= 0
+		}
+	}
+	return false
+}
+
+func isUpper(word string) bool {
+	letters := 0
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			if !unicode.IsUpper(r) {
+				return false
+			}
+			letters++
+		}
+	}
+	return letters >= 2
+}
+
+func lines(s string) []string {
+	result := make([]string, 0)
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
@@ -0,0 +1,253 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/goodsign/monday"
+	"github.com/warmans/fakt-api/pkg/server/data/source"
+	"github.com/warmans/fakt-api/pkg/server/data/source/extract"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"go.uber.org/zap"
+)
+
+const Type = "feed"
+
+// Fields an event date can be extracted from.
+const (
+	DateFieldTitle       = "title"
+	DateFieldDescription = "description"
+	DateFieldPubDate     = "pubdate"
+)
+
+// Performer extraction modes.
+const (
+	PerformersNone  = "none"
+	PerformersGuess = "guess"
+)
+
+// pubDateLayouts are tried when extracting dates from pubDate/published with no configured formats.
+var pubDateLayouts = []string{time.RFC1123Z, time.RFC1123, time.RFC822Z, time.RFC822, time.RFC3339}
+
+// DateFormat extracts a date from some text. The regex capture groups are joined with a single space
+// and parsed with layout e.g. `([0-9]{2})\.([0-9]{2})\.([0-9]{4})` with the layout "02 01 2006". If the regex
+// has no groups the whole match is used. Locale (e.g. de_DE) allows parsing non-english month/day names.
+type DateFormat struct {
+	Regex  string `json:"regex"`
+	Layout string `json:"layout"`
+	Locale string `json:"locale"`
+}
+
+// Options are the feed specific crawler options.
+type Options struct {
+	VenueName    string        `json:"venue_name"`
+	VenueAddress string        `json:"venue_address"`
+	DateField    string        `json:"date_field"`
+	DateFormats  []*DateFormat `json:"date_formats"`
+	Performers   string        `json:"performers"`
+}
+
+func init() {
+	source.Register(Type, func(conf *source.CrawlerConfig, tz *time.Location, client *http.Client, logger *zap.Logger) (source.Crawler, error) {
+		if conf.URI == "" {
+			return nil, errors.New("a feed URI is required")
+		}
+		opts := &Options{}
+		if err := conf.DecodeOptions(opts); err != nil {
+			return nil, err
+		}
+		c, err := NewCrawler(conf.URI, opts, tz, logger)
+		if err != nil {
+			return nil, err
+		}
+		c.HTTP = client
+		return c, nil
+	})
+}
+
+func NewCrawler(uri string, opts *Options, tz *time.Location, logger *zap.Logger) (*Crawler, error) {
+
+	if opts.VenueName == "" {
+		return nil, errors.New("venue_name is required")
+	}
+
+	c := &Crawler{
+		URI:        uri,
+		Timezone:   tz,
+		Logger:     logger,
+		Venue:      &common.Venue{Name: opts.VenueName, Address: opts.VenueAddress},
+		DateField:  strings.ToLower(opts.DateField),
+		Performers: strings.ToLower(opts.Performers),
+	}
+
+	switch c.DateField {
+	case "":
+		c.DateField = DateFieldTitle
+	case DateFieldTitle, DateFieldDescription, DateFieldPubDate:
+	default:
+		return nil, fmt.Errorf("unknown date_field %s", opts.DateField)
+	}
+
+	switch c.Performers {
+	case "":
+		c.Performers = PerformersNone
+	case PerformersNone, PerformersGuess:
+	default:
+		return nil, fmt.Errorf("unknown performers mode %s", opts.Performers)
+	}
+
+	for _, f := range opts.DateFormats {
+		parser, err := NewDateParser(f)
+		if err != nil {
+			return nil, err
+		}
+		c.DateParsers = append(c.DateParsers, parser)
+	}
+	if len(c.DateParsers) == 0 && c.DateField != DateFieldPubDate {
+		return nil, errors.New("at least one date format is required unless dates come from pubdate")
+	}
+
+	return c, nil
+}
+
+// Crawler creates one event per item in an RSS or Atom feed published by a single venue.
+type Crawler struct {
+	URI         string
+	Timezone    *time.Location
+	HTTP        *http.Client
+	Logger      *zap.Logger
+	Venue       *common.Venue
+	DateField   string
+	DateParsers []*DateParser
+	Performers  string
+}
+
+func (c *Crawler) Name() string {
+	return Type
+}
+
+func (c *Crawler) Crawl(ctx context.Context) ([]*common.Event, error) {
+
+	events := make([]*common.Event, 0)
+
+	body, err := source.Get(ctx, c.HTTP, c.URI)
+	if err != nil {
+		return events, fmt.Errorf("feed crawler fetch failed: %s", err.Error())
+	}
+	defer body.Close()
+
+	items, err := Parse(body)
+	if err != nil {
+		return events, fmt.Errorf("feed crawler failed to parse result: %s", err.Error())
+	}
+
+	for _, itm := range items {
+		if event := c.EventFromItem(itm); event != nil {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (c *Crawler) EventFromItem(item *Item) *common.Event {
+
+	date, err := c.Date(item)
+	if err != nil {
+		c.Logger.Error("Failed to parse date in feed item", zap.String("title", item.Title), zap.Error(err))
+		return nil
+	}
+
+	e := &common.Event{
+		Date: date,
+		Venue: &common.Venue{
+			Name:    c.Venue.Name,
+			Address: c.Venue.Address,
+		},
+		Description: item.Description,
+		//e.g. the K9 feed has the start and end time in the title
+		ExtraText: item.Title,
+	}
+
+	if c.Performers == PerformersGuess {
+		extract.Default.Apply(e)
+	}
+
+	return e
+}
+
+// Date extracts the event date from the configured item field using the first matching date format.
+func (c *Crawler) Date(item *Item) (time.Time, error) {
+
+	var text string
+	switch c.DateField {
+	case DateFieldDescription:
+		text = item.Description
+	case DateFieldPubDate:
+		text = item.Published
+	default:
+		text = item.Title
+	}
+
+	for _, p := range c.DateParsers {
+		if date, err := p.Parse(text, c.Timezone); err == nil {
+			return date, nil
+		}
+	}
+
+	if c.DateField == DateFieldPubDate {
+		for _, layout := range pubDateLayouts {
+			if date, err := time.Parse(layout, text); err == nil {
+				return date.In(c.Timezone), nil
+			}
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("no date found in %s (%s)", c.DateField, text)
+}
+
+type DateParser struct {
+	regex  *regexp.Regexp
+	layout string
+	locale monday.Locale
+}
+
+func NewDateParser(f *DateFormat) (*DateParser, error) {
+	if f.Layout == "" {
+		return nil, errors.New("date format has no layout")
+	}
+	p := &DateParser{layout: f.Layout, locale: monday.Locale(f.Locale)}
+	if f.Regex != "" {
+		var err error
+		if p.regex, err = regexp.Compile(f.Regex); err != nil {
+			return nil, fmt.Errorf("invalid date regex %s: %s", f.Regex, err.Error())
+		}
+	}
+	return p, nil
+}
+
+func (p *DateParser) Parse(text string, localTime *time.Location) (time.Time, error) {
+
+	value := strings.TrimSpace(text)
+
+	if p.regex != nil {
+		matches := p.regex.FindStringSubmatch(text)
+		if len(matches) == 0 {
+			return time.Time{}, fmt.Errorf("no date found in (%s)", text)
+		}
+		if len(matches) == 1 {
+			value = matches[0]
+		} else {
+			value = strings.Join(matches[1:], " ")
+		}
+	}
+
+	if p.locale != "" {
+		return monday.ParseInLocation(p.layout, value, localTime, p.locale)
+	}
+	return time.ParseInLocation(p.layout, value, localTime)
+}
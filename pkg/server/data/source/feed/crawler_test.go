@@ -0,0 +1,106 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/warmans/fakt-api/pkg/server/data/source"
+	"go.uber.org/zap"
+)
+
+func TestDateFromTitle(t *testing.T) {
+
+	examples := []struct {
+		RawDateString      string
+		ExpectedParsedDate string
+		ExpectError        bool
+	}{
+		{RawDateString: "Freitag, 02.09.2016 – ab 19.00 h – Größenwahn und Leichtsinn", ExpectedParsedDate: "02-09-2016 19:00", ExpectError: false},
+		{RawDateString: "Donnerstag, 15.09.2016, 18.30 h – 21.30 h, Größenwahn und Leichtsinn", ExpectedParsedDate: "15-09-2016 18:30", ExpectError: false},
+		{RawDateString: "Donnerstag, 22.09.2016, 18.30 h – 21.30 h, Größenwahn und Leichtsinn", ExpectedParsedDate: "22-09-2016 18:30", ExpectError: false},
+		{RawDateString: "Sonntag, 25.09.2016 – 20.00 h – Größenwahn", ExpectedParsedDate: "25-09-2016 20:00", ExpectError: false},
+		{RawDateString: "Größenwahn", ExpectError: true},
+	}
+
+	tz, err := source.MustMakeTimeLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("Unexpected error creating timzone: %s", err.Error())
+	}
+
+	c, err := NewCrawler(
+		"http://www.kinzig9.de/rss.xml",
+		&Options{
+			VenueName:   "K9",
+			DateField:   DateFieldTitle,
+			DateFormats: []*DateFormat{{Regex: `[^0-9]+([0-9]{2})\.([0-9]{2})\.([0-9]{4})[^0-9]+([0-9]{2})\.([0-9]{2})`, Layout: "02 01 2006 15 04"}},
+		},
+		tz,
+		zap.NewNop(),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error creating crawler: %s", err.Error())
+	}
+
+	for _, ex := range examples {
+		date, err := c.Date(&Item{Title: ex.RawDateString})
+		if (err != nil) != ex.ExpectError {
+			t.Errorf("Unexpected error result parsing %s: %v", ex.RawDateString, err)
+			continue
+		}
+		if ex.ExpectError {
+			continue
+		}
+		if formatted := date.Format("02-01-2006 15:04"); formatted != ex.ExpectedParsedDate {
+			t.Errorf("Date did not parse to correct value: %s (expected %s)", formatted, ex.ExpectedParsedDate)
+		}
+	}
+}
+
+func TestDateWithLocale(t *testing.T) {
+
+	tz, err := source.MustMakeTimeLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("Unexpected error creating timzone: %s", err.Error())
+	}
+
+	p, err := NewDateParser(&DateFormat{Regex: `([0-9]{1,2})\. ([\p{L}]+) ([0-9]{4}), ([0-9]{2}:[0-9]{2})`, Layout: "2 January 2006 15:04", Locale: "de_DE"})
+	if err != nil {
+		t.Fatalf("Unexpected error creating parser: %s", err.Error())
+	}
+	date, err := p.Parse("Konzert am 3. März 2017, 20:00 Uhr", tz)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing date: %s", err.Error())
+	}
+	if formatted := date.Format("02-01-2006 15:04"); formatted != "03-03-2017 20:00" {
+		t.Errorf("Date did not parse to correct value: %s", formatted)
+	}
+}
+
+func TestParse(t *testing.T) {
+
+	rss := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>K9</title>
+<item><title>Freitag, 02.09.2016 – ab 19.00 h – Foo</title><description>Some text</description><pubDate>Mon, 29 Aug 2016 10:00:00 +0200</pubDate></item>
+</channel></rss>`
+
+	atom := `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom"><title>Venue</title>
+<entry><title>Bar</title><link rel="alternate" href="http://example.com/bar"/><summary>Summary</summary><updated>2016-09-02T19:00:00+02:00</updated></entry>
+</feed>`
+
+	items, err := Parse(strings.NewReader(rss))
+	if err != nil || len(items) != 1 {
+		t.Fatalf("Failed to parse RSS: %v (%d items)", err, len(items))
+	}
+	if items[0].Description != "Some text" || items[0].Published != "Mon, 29 Aug 2016 10:00:00 +0200" {
+		t.Errorf("Unexpected RSS item %+v", items[0])
+	}
+
+	items, err = Parse(strings.NewReader(atom))
+	if err != nil || len(items) != 1 {
+		t.Fatalf("Failed to parse Atom: %v (%d items)", err, len(items))
+	}
+	if items[0].Title != "Bar" || items[0].Description != "Summary" || items[0].Link != "http://example.com/bar" || items[0].Published != "2016-09-02T19:00:00+02:00" {
+		t.Errorf("Unexpected Atom item %+v", items[0])
+	}
+}
@@ -0,0 +1,120 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/paulrosania/go-charset/charset"
+	_ "github.com/paulrosania/go-charset/data" //initialize only
+)
+
+// Item is a feed entry normalized from either RSS or Atom.
+type Item struct {
+	Title       string
+	Description string
+	Link        string
+	Published   string
+}
+
+type rssDocument struct {
+	Channel struct {
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			Description string `xml:"description"`
+			PubDate     string `xml:"pubDate"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomDocument struct {
+	Entries []struct {
+		Title     string     `xml:"title"`
+		Summary   string     `xml:"summary"`
+		Content   string     `xml:"content"`
+		Links     []atomLink `xml:"link"`
+		Published string     `xml:"published"`
+		Updated   string     `xml:"updated"`
+	} `xml:"entry"`
+}
+
+// Parse reads an RSS 2.0 or Atom feed.
+func Parse(r io.Reader) ([]*Item, error) {
+
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charset.NewReader
+
+	//find the root element to decide which format this is
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find feed root element: %s", err.Error())
+		}
+		root, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch strings.ToLower(root.Name.Local) {
+		case "rss":
+			return parseRSS(decoder, root)
+		case "feed":
+			return parseAtom(decoder, root)
+		default:
+			return nil, fmt.Errorf("unknown feed type %s", root.Name.Local)
+		}
+	}
+}
+
+func parseRSS(decoder *xml.Decoder, root xml.StartElement) ([]*Item, error) {
+	doc := &rssDocument{}
+	if err := decoder.DecodeElement(doc, &root); err != nil {
+		return nil, err
+	}
+	items := make([]*Item, 0, len(doc.Channel.Items))
+	for _, itm := range doc.Channel.Items {
+		items = append(items, &Item{
+			Title:       strings.TrimSpace(itm.Title),
+			Description: strings.TrimSpace(itm.Description),
+			Link:        strings.TrimSpace(itm.Link),
+			Published:   strings.TrimSpace(itm.PubDate),
+		})
+	}
+	return items, nil
+}
+
+func parseAtom(decoder *xml.Decoder, root xml.StartElement) ([]*Item, error) {
+	doc := &atomDocument{}
+	if err := decoder.DecodeElement(doc, &root); err != nil {
+		return nil, err
+	}
+	items := make([]*Item, 0, len(doc.Entries))
+	for _, entry := range doc.Entries {
+		itm := &Item{
+			Title:       strings.TrimSpace(entry.Title),
+			Description: strings.TrimSpace(entry.Content),
+			Published:   strings.TrimSpace(entry.Published),
+		}
+		if itm.Description == "" {
+			itm.Description = strings.TrimSpace(entry.Summary)
+		}
+		if itm.Published == "" {
+			itm.Published = strings.TrimSpace(entry.Updated)
+		}
+		for _, link := range entry.Links {
+			if link.Rel == "" || link.Rel == "alternate" {
+				itm.Link = link.Href
+				break
+			}
+		}
+		items = append(items, itm)
+	}
+	return items, nil
+}
@@ -0,0 +1,338 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultUserAgent       = "fakt-api/1.0 (+https://github.com/warmans/fakt-api)"
+	DefaultRequestTimeout  = time.Second * 30
+	DefaultRequestInterval = time.Second * 2
+
+	// robotsTTL is how long a robots.txt is used before being fetched again.
+	robotsTTL = time.Hour * 24
+	// robotsRetry is how soon an unreachable robots.txt is tried again.
+	robotsRetry = time.Hour
+)
+
+// ErrDisallowed is returned for requests the site's robots.txt doesn't allow.
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// Fetcher is a http.RoundTripper that tries to be a good citizen towards the (often small) sites being crawled.
+// It identifies itself with UserAgent, respects robots.txt, waits at least Interval (or the site's crawl delay)
+// between requests to the same host and, if CacheDir is set, keeps responses on disk so they can be revalidated
+// with conditional GETs (ETag/Last-Modified) even after a restart.
+type Fetcher struct {
+	UserAgent string
+	Interval  time.Duration
+	// CacheDir enables caching responses on disk.
+	CacheDir string
+	// CacheTTL allows serving cached responses without revalidating them while they are younger than this.
+	CacheTTL  time.Duration
+	Transport http.RoundTripper
+	Logger    *zap.Logger
+
+	mu    sync.Mutex
+	hosts map[string]*fetchHost
+}
+
+type fetchHost struct {
+	mu   sync.Mutex
+	next time.Time
+
+	//robotsMu is held while robots.txt is fetched so concurrent requests don't all fetch it
+	robotsMu sync.Mutex
+	robots   *robotsRules
+}
+
+// NewFetcher creates a fetcher using the default user agent and interval.
+func NewFetcher(cacheDir string, logger *zap.Logger) *Fetcher {
+	return &Fetcher{
+		UserAgent: DefaultUserAgent,
+		Interval:  DefaultRequestInterval,
+		CacheDir:  cacheDir,
+		Logger:    logger,
+	}
+}
+
+// Client returns a client using the fetcher. The timeout applies to each request (including reading the body)
+// from when it is sent so time spent waiting for the host's interval doesn't count towards it.
+func (f *Fetcher) Client(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: &fetcherTransport{fetcher: f, timeout: timeout}}
+}
+
+func (f *Fetcher) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f.roundTrip(req, 0)
+}
+
+func (f *Fetcher) roundTrip(req *http.Request, timeout time.Duration) (*http.Response, error) {
+
+	//the request must not be modified so work on a copy
+	req = req.WithContext(req.Context())
+	req.Header = cloneHeader(req.Header)
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", f.UserAgent)
+	}
+
+	if req.Method != http.MethodGet {
+		return f.send(req, timeout)
+	}
+
+	robots, err := f.robots(req.Context(), req.URL, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if !robots.Allowed(req.URL.EscapedPath()) {
+		return nil, fmt.Errorf("%s: %s", req.URL.String(), ErrDisallowed.Error())
+	}
+
+	cached := f.loadCached(req)
+	if cached != nil {
+		if f.CacheTTL > 0 && time.Since(cached.stored) < f.CacheTTL {
+			return cached.response(req)
+		}
+		if etag := cached.header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if modified := cached.header.Get("Last-Modified"); modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	if err := f.wait(req.Context(), req.URL.Host, robots.crawlDelay); err != nil {
+		return nil, err
+	}
+	res, err := f.send(req, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNotModified && cached != nil {
+		res.Body.Close()
+		f.touchCached(req)
+		return cached.response(req)
+	}
+	if res.StatusCode == http.StatusOK && f.CacheDir != "" && !strings.Contains(res.Header.Get("Cache-Control"), "no-store") {
+		return f.storeCached(req, res)
+	}
+	return res, nil
+}
+
+// send makes the request, cancelling it if it takes longer than timeout (if set).
+func (f *Fetcher) send(req *http.Request, timeout time.Duration) (*http.Response, error) {
+	transport := f.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if timeout <= 0 {
+		return transport.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	res, err := transport.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	res.Body = &cancelBody{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// fetcherTransport applies a timeout to requests made with the fetcher.
+type fetcherTransport struct {
+	fetcher *Fetcher
+	timeout time.Duration
+}
+
+func (t *fetcherTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.fetcher.roundTrip(req, t.timeout)
+}
+
+// cancelBody releases the request's timeout once the body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func (f *Fetcher) host(name string) *fetchHost {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.hosts == nil {
+		f.hosts = make(map[string]*fetchHost)
+	}
+	if f.hosts[name] == nil {
+		f.hosts[name] = &fetchHost{}
+	}
+	return f.hosts[name]
+}
+
+// wait blocks until the host may be requested again. The host's next slot is only taken once the wait is over
+// so a cancelled request doesn't delay the ones after it.
+func (f *Fetcher) wait(ctx context.Context, hostName string, crawlDelay time.Duration) error {
+
+	interval := f.Interval
+	if crawlDelay > interval {
+		interval = crawlDelay
+	}
+
+	h := f.host(hostName)
+	for {
+		h.mu.Lock()
+		now := time.Now()
+		delay := h.next.Sub(now)
+		if delay <= 0 {
+			h.next = now.Add(interval)
+			h.mu.Unlock()
+			return nil
+		}
+		h.mu.Unlock()
+
+		//another request may take the slot first in which case the wait starts again
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+func (f *Fetcher) robots(ctx context.Context, u *url.URL, timeout time.Duration) (*robotsRules, error) {
+
+	h := f.host(u.Host)
+	h.robotsMu.Lock()
+	defer h.robotsMu.Unlock()
+
+	if h.robots != nil && time.Now().Before(h.robots.expires) {
+		return h.robots, nil
+	}
+
+	robotsURL := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	req, err := http.NewRequest(http.MethodGet, robotsURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("User-Agent", f.UserAgent)
+
+	if err := f.wait(ctx, u.Host, 0); err != nil {
+		return nil, err
+	}
+	var rules *robotsRules
+	res, err := f.send(req, timeout)
+	switch {
+	case err != nil:
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		f.Logger.Warn("Failed to fetch robots.txt", zap.String("host", u.Host), zap.Error(err))
+		rules = &robotsRules{expires: time.Now().Add(robotsRetry)}
+	case res.StatusCode == http.StatusOK:
+		rules = parseRobots(res.Body, f.UserAgent)
+		rules.expires = time.Now().Add(robotsTTL)
+		res.Body.Close()
+	default:
+		//no robots.txt (or an error page) means no restrictions
+		res.Body.Close()
+		rules = &robotsRules{expires: time.Now().Add(robotsTTL)}
+		if res.StatusCode >= 500 {
+			rules.expires = time.Now().Add(robotsRetry)
+		}
+	}
+
+	h.robots = rules
+	return rules, nil
+}
+
+type cachedResponse struct {
+	stored time.Time
+	header http.Header
+	raw    []byte
+}
+
+func (c *cachedResponse) response(req *http.Request) (*http.Response, error) {
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(c.raw)), req)
+}
+
+func (f *Fetcher) cachePath(req *http.Request) string {
+	sum := sha1.Sum([]byte(req.URL.String()))
+	return filepath.Join(f.CacheDir, req.URL.Host, hex.EncodeToString(sum[:])+".http")
+}
+
+func (f *Fetcher) loadCached(req *http.Request) *cachedResponse {
+	if f.CacheDir == "" {
+		return nil
+	}
+	path := f.cachePath(req)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	res, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(raw)), req)
+	if err != nil {
+		return nil
+	}
+	res.Body.Close()
+	return &cachedResponse{stored: info.ModTime(), header: res.Header, raw: raw}
+}
+
+// touchCached marks a revalidated response as fresh.
+func (f *Fetcher) touchCached(req *http.Request) {
+	now := time.Now()
+	if err := os.Chtimes(f.cachePath(req), now, now); err != nil {
+		f.Logger.Warn("Failed to update cached response", zap.Error(err))
+	}
+}
+
+func (f *Fetcher) storeCached(req *http.Request, res *http.Response) (*http.Response, error) {
+	raw, err := httputil.DumpResponse(res, true)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	path := f.cachePath(req)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+		err = ioutil.WriteFile(path, raw, 0644)
+	}
+	if err != nil {
+		f.Logger.Warn("Failed to cache response", zap.String("url", req.URL.String()), zap.Error(err))
+	}
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(raw)), req)
+}
+
+func cloneHeader(h http.Header) http.Header {
+	clone := make(http.Header, len(h))
+	for k, v := range h {
+		clone[k] = append([]string(nil), v...)
+	}
+	return clone
+}
@@ -0,0 +1,227 @@
+package source
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestRobotsAllowed(t *testing.T) {
+
+	robots := `
+User-agent: *
+Disallow: /private/
+Allow: /private/public
+
+User-agent: fakt-api
+User-agent: other-bot
+Disallow: /*.php$
+Crawl-delay: 5
+`
+	examples := []struct {
+		agent      string
+		path       string
+		allowed    bool
+		crawlDelay time.Duration
+	}{
+		{agent: "some-bot/1.0", path: "/", allowed: true},
+		{agent: "some-bot/1.0", path: "/private/page", allowed: false},
+		{agent: "some-bot/1.0", path: "/private/public/page", allowed: true},
+		{agent: "fakt-api/1.0", path: "/private/page", allowed: true, crawlDelay: time.Second * 5},
+		{agent: "Fakt-API/1.0", path: "/termine.php", allowed: false, crawlDelay: time.Second * 5},
+		{agent: "fakt-api/1.0", path: "/termine.php?display=30", allowed: true, crawlDelay: time.Second * 5},
+	}
+
+	for _, ex := range examples {
+		rules := parseRobots(strings.NewReader(robots), ex.agent)
+		if allowed := rules.Allowed(ex.path); allowed != ex.allowed {
+			t.Errorf("%s %s: expected allowed %v got %v", ex.agent, ex.path, ex.allowed, allowed)
+		}
+		if rules.crawlDelay != ex.crawlDelay {
+			t.Errorf("%s: expected crawl delay %s got %s", ex.agent, ex.crawlDelay, rules.crawlDelay)
+		}
+	}
+}
+
+func TestFetcherRespectsRobots(t *testing.T) {
+
+	var userAgent string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+		if r.URL.Path == "/robots.txt" {
+			rw.Write([]byte("User-agent: *\nDisallow: /private"))
+			return
+		}
+		rw.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	f := NewFetcher("", zap.NewNop())
+	f.Interval = 0
+
+	body, err := Get(context.Background(), f.Client(time.Second), srv.URL+"/events")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	body.Close()
+	if userAgent != DefaultUserAgent {
+		t.Errorf("expected user agent %s got %s", DefaultUserAgent, userAgent)
+	}
+
+	if _, err := Get(context.Background(), f.Client(time.Second), srv.URL+"/private/events"); err == nil || !strings.Contains(err.Error(), ErrDisallowed.Error()) {
+		t.Errorf("expected request to be disallowed, got %v", err)
+	}
+}
+
+func TestFetcherConditionalGet(t *testing.T) {
+
+	cacheDir, err := ioutil.TempDir("", "fetcher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cacheDir)
+
+	requests, notModified := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(rw, r)
+			return
+		}
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			rw.WriteHeader(http.StatusNotModified)
+			return
+		}
+		rw.Header().Set("ETag", `"v1"`)
+		rw.Write([]byte("listing"))
+	}))
+	defer srv.Close()
+
+	//each fetcher is a new process run sharing the same cache
+	for i := 0; i < 2; i++ {
+		f := NewFetcher(cacheDir, zap.NewNop())
+		f.Interval = 0
+
+		body, err := Get(context.Background(), f.Client(time.Second), srv.URL+"/events")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		content, _ := ioutil.ReadAll(body)
+		body.Close()
+		if string(content) != "listing" {
+			t.Errorf("run %d: expected cached body got %s", i, string(content))
+		}
+	}
+	if requests != 2 || notModified != 1 {
+		t.Errorf("expected the second request to be conditional, got %d requests %d not modified", requests, notModified)
+	}
+}
+
+func TestFetcherRateLimitsHosts(t *testing.T) {
+
+	var mu sync.Mutex
+	times := make([]time.Time, 0)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(rw, r)
+			return
+		}
+		mu.Lock()
+		times = append(times, time.Now())
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	f := NewFetcher("", zap.NewNop())
+	f.Interval = time.Millisecond * 50
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if body, err := Get(context.Background(), f.Client(time.Second), srv.URL+"/events"); err == nil {
+				body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(times) != 3 {
+		t.Fatalf("expected 3 requests got %d", len(times))
+	}
+	//allow a little leeway as times are recorded by the handler rather than the fetcher
+	if elapsed := times[2].Sub(times[0]); elapsed < f.Interval*2-time.Millisecond*10 {
+		t.Errorf("expected requests to be at least %s apart, 3 requests took %s", f.Interval, elapsed)
+	}
+}
+
+func TestFetcherCancelledWaitKeepsSlot(t *testing.T) {
+
+	f := NewFetcher("", zap.NewNop())
+	f.Interval = time.Millisecond * 100
+
+	start := time.Now()
+	if err := f.wait(context.Background(), "example.com", 0); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	if err := f.wait(ctx, "example.com", 0); err != context.DeadlineExceeded {
+		t.Fatalf("expected wait to be cancelled got %v", err)
+	}
+
+	//the cancelled request must not have pushed the next one back by another interval
+	if err := f.wait(context.Background(), "example.com", 0); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed >= f.Interval*2 {
+		t.Errorf("expected next request after %s got %s", f.Interval, elapsed)
+	}
+}
+
+func TestFetcherTimeoutExcludesWait(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(rw, r)
+			return
+		}
+		if r.URL.Path == "/slow" {
+			time.Sleep(time.Millisecond * 200)
+		}
+		rw.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	f := NewFetcher("", zap.NewNop())
+	f.Interval = time.Millisecond * 100
+	client := f.Client(time.Millisecond * 50)
+
+	//each request waits longer than the timeout for its slot
+	for i := 0; i < 2; i++ {
+		body, err := Get(context.Background(), client, srv.URL+"/events")
+		if err != nil {
+			t.Fatalf("request %d: unexpected error: %s", i, err)
+		}
+		content, err := ioutil.ReadAll(body)
+		body.Close()
+		if err != nil || string(content) != "ok" {
+			t.Errorf("request %d: expected body got %s (%v)", i, string(content), err)
+		}
+	}
+
+	if _, err := Get(context.Background(), client, srv.URL+"/slow"); err == nil {
+		t.Error("expected slow request to time out")
+	}
+}
@@ -0,0 +1,35 @@
+package source
+
+import (
+	"bytes"
+	"strings"
+)
+
+// HTML Parsing partially stolen from: https://github.com/kennygrant/sanitize/blob/master/sanitize.go
+func StripHTML(s string) string {
+	output := ""
+	if !strings.ContainsAny(s, "<>") {
+		output = s
+	} else {
+
+		s = strings.Replace(s, "\n", " ", -1)
+
+		// Walk through the string removing all tags
+		b := bytes.NewBufferString("")
+		inTag := false
+		for _, r := range s {
+			switch r {
+			case '<':
+				inTag = true
+			case '>':
+				inTag = false
+			default:
+				if !inTag {
+					b.WriteRune(r)
+				}
+			}
+		}
+		output = b.String()
+	}
+	return output
+}
@@ -0,0 +1,29 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Get fetches uri and returns the body of a successful response. The caller must close the body. If client
+// is nil http.DefaultClient is used. The request is cancelled when ctx is done.
+func Get(ctx context.Context, client *http.Client, uri string) (io.ReadCloser, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("unexpected response status %s", res.Status)
+	}
+	return res.Body, nil
+}
@@ -0,0 +1,294 @@
+package ics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/warmans/fakt-api/pkg/server/data/source"
+	"github.com/warmans/fakt-api/pkg/server/data/source/extract"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"go.uber.org/zap"
+)
+
+const (
+	Type               = "ics"
+	DefaultHorizonDays = 90
+)
+
+// Options are the ics specific crawler options.
+type Options struct {
+	// HorizonDays limits how far into the future events (and recurrences) are returned.
+	HorizonDays int `json:"horizon_days"`
+	// VenueName and VenueAddress are used for events with no LOCATION, or for all events if IgnoreLocation is set
+	// (useful for calendars published by a single venue).
+	VenueName      string `json:"venue_name"`
+	VenueAddress   string `json:"venue_address"`
+	IgnoreLocation bool   `json:"ignore_location"`
+}
+
+func init() {
+	source.Register(Type, func(conf *source.CrawlerConfig, tz *time.Location, client *http.Client, logger *zap.Logger) (source.Crawler, error) {
+		if conf.URI == "" {
+			return nil, errors.New("a calendar URI or file path is required")
+		}
+		opts := &Options{HorizonDays: DefaultHorizonDays}
+		if err := conf.DecodeOptions(opts); err != nil {
+			return nil, err
+		}
+		c := &Crawler{
+			URI:            conf.URI,
+			Timezone:       tz,
+			HTTP:           client,
+			Logger:         logger,
+			Horizon:        time.Duration(opts.HorizonDays) * time.Hour * 24,
+			IgnoreLocation: opts.IgnoreLocation,
+		}
+		if opts.VenueName != "" {
+			c.Venue = &common.Venue{Name: opts.VenueName, Address: opts.VenueAddress}
+		}
+		return c, nil
+	})
+}
+
+// Crawler reads events from an RFC 5545 (iCalendar) feed.
+type Crawler struct {
+	URI            string
+	Timezone       *time.Location
+	HTTP           *http.Client
+	Logger         *zap.Logger
+	Horizon        time.Duration
+	Venue          *common.Venue
+	IgnoreLocation bool
+}
+
+func (c *Crawler) Name() string {
+	return Type
+}
+
+func (c *Crawler) Crawl(ctx context.Context) ([]*common.Event, error) {
+
+	events := make([]*common.Event, 0)
+
+	body, err := c.open(ctx)
+	if err != nil {
+		return events, fmt.Errorf("ics crawler fetch failed: %s", err.Error())
+	}
+	defer body.Close()
+
+	calendars, err := Parse(body)
+	if err != nil {
+		return events, fmt.Errorf("ics crawler failed to parse calendar: %s", err.Error())
+	}
+
+	for _, cal := range calendars {
+		if cal.Name != "VCALENDAR" {
+			continue
+		}
+		events = append(events, c.EventsFromCalendar(cal, time.Now())...)
+	}
+	return events, nil
+}
+
+// open supports http(s), webcal and file URIs as well as plain file paths.
+func (c *Crawler) open(ctx context.Context) (io.ReadCloser, error) {
+
+	u, err := url.Parse(c.URI)
+	if err != nil || u.Scheme == "" {
+		return os.Open(c.URI)
+	}
+
+	switch u.Scheme {
+	case "file":
+		return os.Open(u.Path)
+	case "webcal":
+		u.Scheme = "https"
+	}
+
+	return source.Get(ctx, c.HTTP, u.String())
+}
+
+// EventsFromCalendar returns one event per VEVENT occurrence between the start of the current day and the
+// horizon. Recurring events are expanded and cancelled events are included with the cancelled status.
+func (c *Crawler) EventsFromCalendar(cal *Component, now time.Time) []*common.Event {
+
+	localTime := c.Timezone
+	if tzName := cal.Text("X-WR-TIMEZONE"); tzName != "" {
+		if tz, err := time.LoadLocation(tzName); err == nil {
+			localTime = tz
+		}
+	}
+
+	nowLocal := now.In(localTime)
+	from := time.Date(nowLocal.Year(), nowLocal.Month(), nowLocal.Day(), 0, 0, 0, 0, localTime)
+	to := now.Add(c.Horizon)
+
+	//modified instances of recurring events replace the original occurrence
+	overridden := make(map[string]bool)
+	for _, vevent := range cal.Children {
+		if vevent.Name != "VEVENT" || vevent.Get("RECURRENCE-ID") == nil {
+			continue
+		}
+		if recurrenceID, err := ParseTime(vevent.Get("RECURRENCE-ID"), localTime); err == nil {
+			overridden[occurrenceKey(vevent.Text("UID"), recurrenceID)] = true
+		}
+	}
+
+	events := make([]*common.Event, 0)
+	for _, vevent := range cal.Children {
+		if vevent.Name != "VEVENT" {
+			continue
+		}
+		dtstartProp := vevent.Get("DTSTART")
+		if dtstartProp == nil {
+			c.Logger.Error("Event has no DTSTART", zap.String("uid", vevent.Text("UID")))
+			continue
+		}
+		dtstart, err := ParseTime(dtstartProp, localTime)
+		if err != nil {
+			c.Logger.Error("Failed to parse DTSTART", zap.String("uid", vevent.Text("UID")), zap.Error(err))
+			continue
+		}
+
+		for _, date := range c.occurrences(vevent, dtstart, from, to, localTime) {
+			if date.Before(from) || !date.Before(to) {
+				continue
+			}
+			if vevent.Get("RECURRENCE-ID") == nil && overridden[occurrenceKey(vevent.Text("UID"), date)] {
+				continue
+			}
+			events = append(events, c.createEvent(vevent, date, duration(vevent, dtstart, localTime)))
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Date.Before(events[j].Date) })
+	return events
+}
+
+func (c *Crawler) occurrences(vevent *Component, dtstart time.Time, from time.Time, to time.Time, localTime *time.Location) []time.Time {
+
+	rruleProp := vevent.Get("RRULE")
+	if rruleProp == nil || vevent.Get("RECURRENCE-ID") != nil {
+		return []time.Time{dtstart}
+	}
+
+	rule, err := ParseRecurrence(rruleProp.Value, localTime)
+	if err != nil {
+		c.Logger.Error("Unsupported RRULE, using first occurrence only", zap.String("uid", vevent.Text("UID")), zap.Error(err))
+		return []time.Time{dtstart}
+	}
+
+	excluded := make(map[int64]bool)
+	for _, exdateProp := range vevent.Properties["EXDATE"] {
+		exdates, err := ParseTimeList(exdateProp, localTime)
+		if err != nil {
+			c.Logger.Error("Failed to parse EXDATE", zap.String("uid", vevent.Text("UID")), zap.Error(err))
+			continue
+		}
+		for _, ex := range exdates {
+			excluded[ex.Unix()] = true
+		}
+	}
+
+	occurrences := make([]time.Time, 0)
+	for _, date := range rule.Expand(dtstart, from, to) {
+		if !excluded[date.Unix()] {
+			occurrences = append(occurrences, date)
+		}
+	}
+	return occurrences
+}
+
+// duration is the time between DTSTART and DTEND (zero if there is no DTEND).
+func duration(vevent *Component, dtstart time.Time, localTime *time.Location) time.Duration {
+	dtendProp := vevent.Get("DTEND")
+	if dtendProp == nil {
+		return 0
+	}
+	dtend, err := ParseTime(dtendProp, localTime)
+	if err != nil || !dtend.After(dtstart) {
+		return 0
+	}
+	return dtend.Sub(dtstart)
+}
+
+func (c *Crawler) createEvent(vevent *Component, date time.Time, duration time.Duration) *common.Event {
+
+	description := strings.TrimSpace(vevent.Text("SUMMARY"))
+	if body := strings.TrimSpace(vevent.Text("DESCRIPTION")); body != "" {
+		description = strings.TrimSpace(fmt.Sprintf("%s\n%s", description, body))
+	}
+
+	tags := []string{}
+	for _, prop := range vevent.Properties["CATEGORIES"] {
+		for _, cat := range splitUnescaped(prop.Value) {
+			if cat = strings.TrimSpace(UnescapeText(cat)); cat != "" {
+				tags = append(tags, cat)
+			}
+		}
+	}
+
+	e := &common.Event{
+		Date:        date,
+		Venue:       c.venue(vevent.Text("LOCATION")),
+		Description: description,
+		Tags:        tags,
+	}
+	if strings.ToUpper(vevent.Text("STATUS")) == "CANCELLED" {
+		e.Status = common.EventStatusCancelled
+	}
+	if duration > 0 {
+		end := date.Add(duration)
+		e.EndDate = &end
+	}
+
+	//populate performers from description
+	extract.Default.Apply(e)
+
+	return e
+}
+
+// venue maps a LOCATION of the form "Name, Street 1, 10247 Berlin" to a venue.
+func (c *Crawler) venue(location string) *common.Venue {
+	location = strings.TrimSpace(location)
+	if c.IgnoreLocation || location == "" {
+		if c.Venue == nil {
+			return nil
+		}
+		return &common.Venue{Name: c.Venue.Name, Address: c.Venue.Address}
+	}
+	parts := strings.SplitN(location, ",", 2)
+	v := &common.Venue{Name: strings.TrimSpace(parts[0])}
+	if len(parts) == 2 {
+		v.Address = strings.TrimSpace(parts[1])
+	}
+	return v
+}
+
+func occurrenceKey(uid string, date time.Time) string {
+	return fmt.Sprintf("%s@%d", uid, date.Unix())
+}
+
+// splitUnescaped splits a multi value TEXT property on commas that are not escaped.
+func splitUnescaped(value string) []string {
+	parts := make([]string, 0)
+	last := 0
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' {
+			i++
+			continue
+		}
+		if value[i] == ',' {
+			parts = append(parts, value[last:i])
+			last = i + 1
+		}
+	}
+	return append(parts, value[last:])
+}
@@ -0,0 +1,186 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/warmans/fakt-api/pkg/server/data/source"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"go.uber.org/zap"
+)
+
+const testCalendar = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Test//EN
+BEGIN:VEVENT
+UID:single@example.com
+DTSTART;TZID=Europe/Berlin:20161002T190000
+DTEND;TZID=Europe/Berlin:20161002T230000
+SUMMARY:Konzert
+DESCRIPTION:"Foo" (Punk aus Leipzig)\n"Bar" (Noise from Berlin)
+LOCATION:Kinzig 9\, Kinzigstr. 9, 10247 Berlin
+CATEGORIES:Konzert,Punk
+END:VEVENT
+BEGIN:VEVENT
+UID:weekly@example.com
+DTSTART:20160928T180000Z
+RRULE:FREQ=WEEKLY;COUNT=4
+EXDATE:20161005T180000Z
+SUMMARY:Vokü
+LOCATION:Tristeza
+END:VEVENT
+BEGIN:VEVENT
+UID:cancelled@example.com
+DTSTART:20161003T180000Z
+STATUS:CANCELLED
+SUMMARY:Nope
+LOCATION:Tristeza
+END:VEVENT
+BEGIN:VEVENT
+UID:old@example.com
+DTSTART:20160101T180000Z
+SUMMARY:Too old
+LOCATION:Tristeza
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestEventsFromCalendar(t *testing.T) {
+
+	tz, err := source.MustMakeTimeLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("Unexpected error creating timzone: %s", err.Error())
+	}
+
+	cals, err := Parse(strings.NewReader(strings.Replace(testCalendar, "\n", "\r\n", -1)))
+	if err != nil {
+		t.Fatalf("Failed to parse calendar: %s", err.Error())
+	}
+	if len(cals) != 1 {
+		t.Fatalf("Expected 1 calendar got %d", len(cals))
+	}
+
+	c := &Crawler{Timezone: tz, Logger: zap.NewNop(), Horizon: time.Hour * 24 * 30}
+	now := time.Date(2016, 9, 28, 12, 0, 0, 0, tz)
+	events := c.EventsFromCalendar(cals[0], now)
+
+	expected := []string{
+		"28-09-2016 20:00 Tristeza",
+		"02-10-2016 19:00 Kinzig 9",
+		"03-10-2016 20:00 Tristeza",
+		"12-10-2016 20:00 Tristeza",
+		"19-10-2016 20:00 Tristeza",
+	}
+
+	got := []string{}
+	for _, e := range events {
+		got = append(got, e.Date.In(tz).Format("02-01-2006 15:04")+" "+e.Venue.Name)
+	}
+	if strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Fatalf("Unexpected events:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+
+	single := events[1]
+	if single.Venue.Address != "Kinzigstr. 9, 10247 Berlin" {
+		t.Errorf("Unexpected address %s", single.Venue.Address)
+	}
+	if len(single.Performers) != 2 || single.Performers[0].Name != "Foo" || single.Performers[1].Home != "Berlin" {
+		t.Errorf("Performers were not guessed from description: %+v", single.Performers)
+	}
+	if strings.Join(single.Tags, ",") != "Konzert,Punk" {
+		t.Errorf("Unexpected tags %v", single.Tags)
+	}
+	if single.Status != "" || events[2].Status != common.EventStatusCancelled {
+		t.Errorf("Expected only the cancelled event to have a status got %s and %s", single.Status, events[2].Status)
+	}
+}
+
+func TestRecurrenceExpand(t *testing.T) {
+
+	tz, err := source.MustMakeTimeLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("Unexpected error creating timzone: %s", err.Error())
+	}
+
+	examples := []struct {
+		Rule     string
+		Start    time.Time
+		From     time.Time
+		End      time.Time
+		Expected []string
+	}{
+		{
+			Rule:     "FREQ=DAILY;INTERVAL=2;COUNT=3",
+			Start:    time.Date(2016, 10, 1, 20, 0, 0, 0, tz),
+			End:      time.Date(2017, 1, 1, 0, 0, 0, 0, tz),
+			Expected: []string{"01-10-2016 20:00", "03-10-2016 20:00", "05-10-2016 20:00"},
+		},
+		{
+			//crosses the DST change but keeps local time
+			Rule:     "FREQ=WEEKLY;BYDAY=TU,FR;UNTIL=20161104T000000Z",
+			Start:    time.Date(2016, 10, 25, 19, 0, 0, 0, tz),
+			End:      time.Date(2017, 1, 1, 0, 0, 0, 0, tz),
+			Expected: []string{"25-10-2016 19:00", "28-10-2016 19:00", "01-11-2016 19:00"},
+		},
+		{
+			Rule:     "FREQ=MONTHLY;BYDAY=1FR",
+			Start:    time.Date(2016, 10, 7, 21, 0, 0, 0, tz),
+			End:      time.Date(2017, 1, 1, 0, 0, 0, 0, tz),
+			Expected: []string{"07-10-2016 21:00", "04-11-2016 21:00", "02-12-2016 21:00"},
+		},
+		{
+			Rule:     "FREQ=MONTHLY;BYMONTHDAY=-1",
+			Start:    time.Date(2016, 10, 31, 21, 0, 0, 0, tz),
+			End:      time.Date(2017, 1, 1, 0, 0, 0, 0, tz),
+			Expected: []string{"31-10-2016 21:00", "30-11-2016 21:00", "31-12-2016 21:00"},
+		},
+		{
+			Rule:     "FREQ=YEARLY",
+			Start:    time.Date(2016, 2, 29, 12, 0, 0, 0, tz),
+			End:      time.Date(2025, 1, 1, 0, 0, 0, 0, tz),
+			Expected: []string{"29-02-2016 12:00", "29-02-2020 12:00", "29-02-2024 12:00"},
+		},
+		{
+			//started far more than maxPeriods days ago
+			Rule:     "FREQ=DAILY",
+			Start:    time.Date(1990, 3, 1, 20, 0, 0, 0, tz),
+			From:     time.Date(2018, 7, 20, 0, 0, 0, 0, tz),
+			End:      time.Date(2018, 7, 23, 0, 0, 0, 0, tz),
+			Expected: []string{"20-07-2018 20:00", "21-07-2018 20:00", "22-07-2018 20:00"},
+		},
+		{
+			Rule:     "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO",
+			Start:    time.Date(1990, 1, 1, 20, 0, 0, 0, tz),
+			From:     time.Date(2018, 7, 1, 0, 0, 0, 0, tz),
+			End:      time.Date(2018, 8, 1, 0, 0, 0, 0, tz),
+			Expected: []string{"09-07-2018 20:00", "23-07-2018 20:00"},
+		},
+		{
+			Rule:     "FREQ=MONTHLY;INTERVAL=5;BYDAY=-1SA",
+			Start:    time.Date(1980, 1, 26, 22, 0, 0, 0, tz),
+			From:     time.Date(2018, 1, 1, 0, 0, 0, 0, tz),
+			End:      time.Date(2019, 1, 1, 0, 0, 0, 0, tz),
+			Expected: []string{"26-05-2018 22:00", "27-10-2018 22:00"},
+		},
+	}
+
+	for _, ex := range examples {
+		rule, err := ParseRecurrence(ex.Rule, tz)
+		if err != nil {
+			t.Errorf("Failed to parse %s: %s", ex.Rule, err.Error())
+			continue
+		}
+		got := []string{}
+		from := ex.From
+		if from.IsZero() {
+			from = ex.Start
+		}
+		for _, o := range rule.Expand(ex.Start, from, ex.End) {
+			got = append(got, o.In(tz).Format("02-01-2006 15:04"))
+		}
+		if strings.Join(got, "|") != strings.Join(ex.Expected, "|") {
+			t.Errorf("%s expanded to %v expected %v", ex.Rule, got, ex.Expected)
+		}
+	}
+}
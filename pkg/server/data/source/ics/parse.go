@@ -0,0 +1,195 @@
+package ics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Property is a single content line e.g. DTSTART;TZID=Europe/Berlin:20161002T190000
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Component is a BEGIN/END block e.g. VEVENT. Properties are keyed by their (upper case) name.
+type Component struct {
+	Name       string
+	Properties map[string][]*Property
+	Children   []*Component
+}
+
+// Get returns the first property with the given name or nil.
+func (c *Component) Get(name string) *Property {
+	if props := c.Properties[name]; len(props) > 0 {
+		return props[0]
+	}
+	return nil
+}
+
+// Text returns the unescaped value of the first property with the given name or an empty string.
+func (c *Component) Text(name string) string {
+	if prop := c.Get(name); prop != nil {
+		return UnescapeText(prop.Value)
+	}
+	return ""
+}
+
+// Parse reads a calendar and returns all top level components (normally a single VCALENDAR).
+func Parse(r io.Reader) ([]*Component, error) {
+
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := make([]*Component, 0)
+	stack := make([]*Component, 0)
+
+	for num, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", num+1, err.Error())
+		}
+
+		switch prop.Name {
+		case "BEGIN":
+			stack = append(stack, &Component{Name: strings.ToUpper(prop.Value), Properties: make(map[string][]*Property)})
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", num+1, prop.Value)
+			}
+			done := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				roots = append(roots, done)
+			} else {
+				stack[len(stack)-1].Children = append(stack[len(stack)-1].Children, done)
+			}
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: property %s outside of component", num+1, prop.Name)
+			}
+			cur := stack[len(stack)-1]
+			cur.Properties[prop.Name] = append(cur.Properties[prop.Name], prop)
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("unterminated component %s", stack[len(stack)-1].Name)
+	}
+	return roots, nil
+}
+
+// unfold joins continuation lines (lines starting with a space or tab) to the previous line.
+func unfold(r io.Reader) ([]string, error) {
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func parseLine(line string) (*Property, error) {
+
+	//find the first colon that isn't inside a quoted param value
+	inQuote := false
+	valueStart := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuote = !inQuote
+		}
+		if r == ':' && !inQuote {
+			valueStart = i
+			break
+		}
+	}
+	if valueStart == -1 {
+		return nil, fmt.Errorf("no value in %s", line)
+	}
+
+	prop := &Property{Params: make(map[string]string), Value: line[valueStart+1:]}
+
+	parts := splitUnquoted(line[:valueStart], ';')
+	prop.Name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		prop.Params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+	}
+	return prop, nil
+}
+
+func splitUnquoted(s string, sep rune) []string {
+	parts := make([]string, 0)
+	inQuote := false
+	last := 0
+	for i, r := range s {
+		if r == '"' {
+			inQuote = !inQuote
+		}
+		if r == sep && !inQuote {
+			parts = append(parts, s[last:i])
+			last = i + 1
+		}
+	}
+	return append(parts, s[last:])
+}
+
+// UnescapeText reverses RFC 5545 TEXT escaping.
+func UnescapeText(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
+
+// ParseTime handles the three DATE-TIME forms (UTC, floating and TZID) as well as DATE values. Floating
+// and DATE values are interpreted in the given location as are times with an unknown TZID.
+func ParseTime(prop *Property, localTime *time.Location) (time.Time, error) {
+	return parseTimeValue(prop.Value, prop.Params, localTime)
+}
+
+// ParseTimeList parses properties that may contain a comma separated list of times (e.g. EXDATE).
+func ParseTimeList(prop *Property, localTime *time.Location) ([]time.Time, error) {
+	times := make([]time.Time, 0)
+	for _, val := range strings.Split(prop.Value, ",") {
+		t, err := parseTimeValue(val, prop.Params, localTime)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, nil
+}
+
+func parseTimeValue(value string, params map[string]string, localTime *time.Location) (time.Time, error) {
+
+	value = strings.TrimSpace(value)
+
+	loc := localTime
+	if tzid := params["TZID"]; tzid != "" {
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			loc = tz
+		}
+	}
+
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		return time.ParseInLocation("20060102", value, loc)
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse("20060102T150405Z", value)
+	}
+	return time.ParseInLocation("20060102T150405", value, loc)
+}
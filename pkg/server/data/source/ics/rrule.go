@@ -0,0 +1,265 @@
+package ics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPeriods stops badly formed rules (e.g. no UNTIL/COUNT and a huge horizon) from expanding forever. Periods
+// before the expanded range aren't counted (see Expand).
+const maxPeriods = 5000
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// WeekdayNum is a BYDAY entry. N is the optional ordinal e.g. 1FR = first Friday, -1SA = last Saturday.
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// Recurrence is the supported subset of an RRULE: DAILY, WEEKLY, MONTHLY and YEARLY frequencies with
+// INTERVAL, COUNT, UNTIL, BYDAY and BYMONTHDAY.
+type Recurrence struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+}
+
+func ParseRecurrence(value string, localTime *time.Location) (*Recurrence, error) {
+
+	r := &Recurrence{Interval: 1}
+
+	for _, part := range strings.Split(value, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key, val := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		var err error
+		switch key {
+		case "FREQ":
+			r.Freq = val
+		case "INTERVAL":
+			if r.Interval, err = strconv.Atoi(val); err != nil || r.Interval < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL %s", val)
+			}
+		case "COUNT":
+			if r.Count, err = strconv.Atoi(val); err != nil {
+				return nil, fmt.Errorf("invalid COUNT %s", val)
+			}
+		case "UNTIL":
+			if r.Until, err = parseTimeValue(val, map[string]string{}, localTime); err != nil {
+				return nil, fmt.Errorf("invalid UNTIL %s", val)
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				if len(day) < 2 {
+					return nil, fmt.Errorf("invalid BYDAY %s", val)
+				}
+				wd, ok := weekdays[day[len(day)-2:]]
+				if !ok {
+					return nil, fmt.Errorf("invalid BYDAY %s", val)
+				}
+				n := 0
+				if ord := day[:len(day)-2]; ord != "" {
+					if n, err = strconv.Atoi(ord); err != nil {
+						return nil, fmt.Errorf("invalid BYDAY %s", val)
+					}
+				}
+				r.ByDay = append(r.ByDay, WeekdayNum{N: n, Day: wd})
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(day)
+				if err != nil || n == 0 {
+					return nil, fmt.Errorf("invalid BYMONTHDAY %s", val)
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		case "WKST":
+			//only Monday week starts are supported which is the default anyway
+		default:
+			return nil, fmt.Errorf("unsupported recurrence rule part %s", key)
+		}
+	}
+
+	switch r.Freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return nil, fmt.Errorf("unsupported FREQ %s", r.Freq)
+	}
+	return r, nil
+}
+
+// Expand returns the occurrences of the rule starting from dtstart (which is always the first occurrence)
+// that begin between from and end. Rules without a COUNT skip straight to the periods around from so rules that
+// started long ago still have occurrences. COUNT includes earlier occurrences so those rules are always expanded
+// from dtstart.
+func (r *Recurrence) Expand(dtstart time.Time, from time.Time, end time.Time) []time.Time {
+
+	occurrences := make([]time.Time, 0)
+	generated := 0
+
+	first := 0
+	if r.Count == 0 {
+		first = r.periodsBefore(dtstart, from)
+	}
+
+	for period := first; period < first+maxPeriods; period++ {
+
+		candidates, periodStart := r.candidates(dtstart, period)
+		if periodStart.After(end) || (!r.Until.IsZero() && periodStart.After(r.Until)) {
+			break
+		}
+
+		for _, c := range candidates {
+			if c.Before(dtstart) {
+				continue
+			}
+			if !r.Until.IsZero() && c.After(r.Until) {
+				return occurrences
+			}
+			if r.Count > 0 && generated >= r.Count {
+				return occurrences
+			}
+			generated++
+			if !c.Before(end) {
+				return occurrences
+			}
+			if c.Before(from) {
+				continue
+			}
+			occurrences = append(occurrences, c)
+		}
+	}
+	return occurrences
+}
+
+// periodsBefore is the number of whole periods between dtstart and from. It is one less than the exact number
+// so DST changes and uneven month lengths never skip a period that could contain occurrences after from.
+func (r *Recurrence) periodsBefore(dtstart time.Time, from time.Time) int {
+
+	if !from.After(dtstart) {
+		return 0
+	}
+
+	var periods int
+	switch r.Freq {
+	case "DAILY":
+		periods = int(from.Sub(dtstart).Hours()/24) / r.Interval
+	case "WEEKLY":
+		periods = int(from.Sub(dtstart).Hours()/24) / 7 / r.Interval
+	case "MONTHLY":
+		periods = ((from.Year()-dtstart.Year())*12 + int(from.Month()) - int(dtstart.Month())) / r.Interval
+	case "YEARLY":
+		periods = (from.Year() - dtstart.Year()) / r.Interval
+	}
+	if periods < 1 {
+		return 0
+	}
+	return periods - 1
+}
+
+// candidates returns the possible occurrences in the nth period after dtstart along with the start of the period.
+func (r *Recurrence) candidates(dtstart time.Time, n int) ([]time.Time, time.Time) {
+
+	at := func(year int, month time.Month, day int) (time.Time, bool) {
+		t := time.Date(year, month, day, dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, dtstart.Location())
+		return t, t.Day() == day && t.Month() == month
+	}
+
+	candidates := make([]time.Time, 0)
+	var periodStart time.Time
+
+	switch r.Freq {
+	case "DAILY":
+		periodStart = dtstart.AddDate(0, 0, n*r.Interval)
+		candidates = append(candidates, periodStart)
+
+	case "WEEKLY":
+		weekStart := dtstart.AddDate(0, 0, -((int(dtstart.Weekday()) + 6) % 7))
+		periodStart = weekStart.AddDate(0, 0, 7*n*r.Interval)
+		if len(r.ByDay) == 0 {
+			candidates = append(candidates, dtstart.AddDate(0, 0, 7*n*r.Interval))
+			break
+		}
+		for _, bd := range r.ByDay {
+			day := periodStart.AddDate(0, 0, (int(bd.Day)+6)%7)
+			if t, ok := at(day.Year(), day.Month(), day.Day()); ok {
+				candidates = append(candidates, t)
+			}
+		}
+
+	case "MONTHLY":
+		monthStart := time.Date(dtstart.Year(), dtstart.Month(), 1, 0, 0, 0, 0, dtstart.Location()).AddDate(0, n*r.Interval, 0)
+		periodStart = monthStart
+		year, month := monthStart.Year(), monthStart.Month()
+		daysInMonth := monthStart.AddDate(0, 1, -1).Day()
+
+		switch {
+		case len(r.ByDay) > 0:
+			for _, bd := range r.ByDay {
+				matching := make([]int, 0)
+				for d := 1; d <= daysInMonth; d++ {
+					if time.Date(year, month, d, 0, 0, 0, 0, dtstart.Location()).Weekday() == bd.Day {
+						matching = append(matching, d)
+					}
+				}
+				switch {
+				case bd.N == 0:
+					for _, d := range matching {
+						if t, ok := at(year, month, d); ok {
+							candidates = append(candidates, t)
+						}
+					}
+				case bd.N > 0 && bd.N <= len(matching):
+					if t, ok := at(year, month, matching[bd.N-1]); ok {
+						candidates = append(candidates, t)
+					}
+				case bd.N < 0 && -bd.N <= len(matching):
+					if t, ok := at(year, month, matching[len(matching)+bd.N]); ok {
+						candidates = append(candidates, t)
+					}
+				}
+			}
+		case len(r.ByMonthDay) > 0:
+			for _, d := range r.ByMonthDay {
+				if d < 0 {
+					d = daysInMonth + d + 1
+				}
+				if t, ok := at(year, month, d); ok {
+					candidates = append(candidates, t)
+				}
+			}
+		default:
+			if t, ok := at(year, month, dtstart.Day()); ok {
+				candidates = append(candidates, t)
+			}
+		}
+
+	case "YEARLY":
+		year := dtstart.Year() + n*r.Interval
+		periodStart = time.Date(year, 1, 1, 0, 0, 0, 0, dtstart.Location())
+		if t, ok := at(year, dtstart.Month(), dtstart.Day()); ok {
+			candidates = append(candidates, t)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+	return candidates, periodStart
+}
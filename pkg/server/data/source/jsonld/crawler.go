@@ -0,0 +1,338 @@
+package jsonld
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/warmans/fakt-api/pkg/server/data/source"
+	"github.com/warmans/fakt-api/pkg/server/data/source/extract"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"go.uber.org/zap"
+)
+
+const Type = "jsonld"
+
+// Options are the jsonld specific crawler options.
+type Options struct {
+	// Pages are fetched in addition to the crawler URI.
+	Pages []string `json:"pages"`
+	// VenueName and VenueAddress are used for events with no location.
+	VenueName    string `json:"venue_name"`
+	VenueAddress string `json:"venue_address"`
+}
+
+func init() {
+	source.Register(Type, func(conf *source.CrawlerConfig, tz *time.Location, client *http.Client, logger *zap.Logger) (source.Crawler, error) {
+		opts := &Options{}
+		if err := conf.DecodeOptions(opts); err != nil {
+			return nil, err
+		}
+		c := &Crawler{Pages: opts.Pages, Timezone: tz, HTTP: client, Logger: logger}
+		if conf.URI != "" {
+			c.Pages = append([]string{conf.URI}, c.Pages...)
+		}
+		if len(c.Pages) == 0 {
+			return nil, errors.New("at least one page is required")
+		}
+		if opts.VenueName != "" {
+			c.Venue = &common.Venue{Name: opts.VenueName, Address: opts.VenueAddress}
+		}
+		return c, nil
+	})
+}
+
+// Crawler extracts schema.org Event data embedded as JSON-LD in web pages.
+type Crawler struct {
+	Pages    []string
+	Timezone *time.Location
+	HTTP     *http.Client
+	Logger   *zap.Logger
+	Venue    *common.Venue
+}
+
+func (c *Crawler) Name() string {
+	return Type
+}
+
+func (c *Crawler) Crawl(ctx context.Context) ([]*common.Event, error) {
+
+	events := make([]*common.Event, 0)
+	failures := 0
+
+	for _, page := range c.Pages {
+		if err := ctx.Err(); err != nil {
+			return events, fmt.Errorf("jsonld crawler stopped: %s", err.Error())
+		}
+		pageEvents, err := c.crawlPage(ctx, page)
+		if err != nil {
+			c.Logger.Error("Failed to crawl page", zap.String("page", page), zap.Error(err))
+			failures++
+			continue
+		}
+		events = append(events, pageEvents...)
+	}
+
+	if failures == len(c.Pages) {
+		return events, errors.New("jsonld crawler failed to crawl any pages")
+	}
+	return events, nil
+}
+
+func (c *Crawler) crawlPage(ctx context.Context, page string) ([]*common.Event, error) {
+
+	body, err := source.Get(ctx, c.HTTP, page)
+	if err != nil {
+		return nil, fmt.Errorf("fetch failed: %s", err.Error())
+	}
+	defer body.Close()
+
+	return c.EventsFromPage(body, page)
+}
+
+// EventsFromPage extracts all events from the JSON-LD blocks in a HTML document.
+func (c *Crawler) EventsFromPage(body io.Reader, pageURL string) ([]*common.Event, error) {
+
+	doc, err := goquery.NewDocumentFromReader(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse page: %s", err.Error())
+	}
+
+	events := make([]*common.Event, 0)
+	doc.Find(`script[type="application/ld+json"]`).Each(func(i int, sel *goquery.Selection) {
+		nodes, err := FindEvents([]byte(sel.Text()))
+		if err != nil {
+			c.Logger.Error("Invalid JSON-LD block", zap.String("page", pageURL), zap.Error(err))
+			return
+		}
+		for _, n := range nodes {
+			event, err := c.EventFromNode(n, pageURL)
+			if err != nil {
+				c.Logger.Error("Failed to create event", zap.String("page", pageURL), zap.Error(err))
+				continue
+			}
+			if event != nil {
+				events = append(events, event)
+			}
+		}
+	})
+	return events, nil
+}
+
+// EventFromNode maps a schema.org Event. Cancelled and postponed events are included with that status.
+func (c *Crawler) EventFromNode(n Node, pageURL string) (*common.Event, error) {
+
+	date, err := n.Time("startDate", c.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	description := []string{}
+	if name := n.String("name"); name != "" {
+		description = append(description, cleanText(name))
+	}
+	if desc := n.String("description"); desc != "" {
+		description = append(description, cleanText(desc))
+	}
+
+	e := &common.Event{
+		Date:       date,
+		Venue:      c.venue(n),
+		Performers: c.performers(n, pageURL),
+		Tags:       []string{},
+		Links:      []*common.Link{},
+		Status:     eventStatus(n),
+	}
+	if types := n.Types(); len(types) > 0 {
+		e.Type = types[0]
+	}
+
+	if endDate, err := n.Time("endDate", c.Timezone); err == nil && endDate.After(date) {
+		e.EndDate = &endDate
+	}
+	if doors, err := n.Time("doorTime", c.Timezone); err == nil {
+		e.Doors = &doors
+	}
+	e.IsFree = n["isAccessibleForFree"] == true || n.String("isAccessibleForFree") == "true"
+	e.Festival = c.festival(n, e)
+
+	if eventURL := resolveURL(pageURL, n.String("url")); eventURL != "" {
+		e.Links = append(e.Links, &common.Link{URI: eventURL, Type: "info", Text: "More info"})
+	}
+
+	for _, offer := range n.Nodes("offers") {
+		if price := offerPrice(offer); price != "" {
+			description = append(description, price)
+		}
+		if offerURL := resolveURL(pageURL, offer.String("url")); offerURL != "" {
+			e.Links = append(e.Links, &common.Link{URI: offerURL, Type: "tickets", Text: "Tickets"})
+		}
+	}
+
+	e.Description = strings.Join(description, "\n")
+
+	//structured performers are always preferred but fall back to guessing if there are none
+	if len(e.Performers) == 0 {
+		extract.Default.Apply(e)
+	}
+
+	return e, nil
+}
+
+// eventStatus maps the schema.org eventStatus. Anything else (e.g. EventScheduled) is left to be guessed from the
+// description.
+func eventStatus(n Node) string {
+	switch status := n.String("eventStatus"); {
+	case strings.HasSuffix(status, "EventCancelled"):
+		return common.EventStatusCancelled
+	case strings.HasSuffix(status, "EventPostponed"):
+		return common.EventStatusPostponed
+	}
+	return ""
+}
+
+// festival returns the event's superEvent. A Festival with no superEvent is its own festival so that the
+// whole run can be listed together.
+func (c *Crawler) festival(n Node, e *common.Event) *common.Festival {
+	parents := n.Nodes("superEvent")
+	if len(parents) == 0 && strings.HasSuffix(e.Type, "Festival") {
+		parents = []Node{n}
+	}
+	for _, parent := range parents {
+		name := cleanText(parent.String("name"))
+		if name == "" {
+			continue
+		}
+		//missing dates default to the event's dates when stored
+		f := &common.Festival{Name: name, Description: cleanText(parent.String("description"))}
+		f.StartDate, _ = parent.Time("startDate", c.Timezone)
+		f.EndDate, _ = parent.Time("endDate", c.Timezone)
+		return f
+	}
+	return nil
+}
+
+func (c *Crawler) venue(n Node) *common.Venue {
+	for _, loc := range n.Nodes("location") {
+		name := loc.String("name")
+		if name == "" || isVirtual(loc) {
+			continue
+		}
+		return &common.Venue{Name: cleanText(name), Address: address(loc)}
+	}
+	if c.Venue == nil {
+		return nil
+	}
+	return &common.Venue{Name: c.Venue.Name, Address: c.Venue.Address}
+}
+
+func (c *Crawler) performers(n Node, pageURL string) []*common.Performer {
+	performers := make([]*common.Performer, 0)
+	for _, p := range n.Nodes("performer") {
+		name := cleanText(p.String("name"))
+		if name == "" {
+			continue
+		}
+		perf := &common.Performer{
+			Name:  name,
+			Genre: cleanText(p.String("genre")),
+			Tags:  []string{},
+			Links: []*common.Link{},
+
+			Extractor:  extract.StrategyStructured,
+			Confidence: 1,
+		}
+		for _, tag := range strings.Split(perf.Genre, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				perf.Tags = append(perf.Tags, tag)
+			}
+		}
+		if perfURL := resolveURL(pageURL, p.String("url")); perfURL != "" {
+			perf.Links = append(perf.Links, &common.Link{URI: perfURL, Type: "website"})
+		}
+		for _, sameAs := range p.Strings("sameAs") {
+			perf.Links = append(perf.Links, &common.Link{URI: sameAs, Type: "profile"})
+		}
+		performers = append(performers, perf)
+	}
+	return performers
+}
+
+func isVirtual(place Node) bool {
+	for _, t := range place.Types() {
+		if t == "VirtualLocation" {
+			return true
+		}
+	}
+	return false
+}
+
+// address handles both plain text and PostalAddress addresses.
+func address(place Node) string {
+	addrs := place.Nodes("address")
+	if len(addrs) == 0 {
+		return ""
+	}
+	addr := addrs[0]
+	if len(addr.Types()) == 0 {
+		return cleanText(addr.String("name")) //was a plain string
+	}
+	locality := strings.TrimSpace(fmt.Sprintf("%s %s", addr.String("postalCode"), addr.String("addressLocality")))
+	parts := []string{}
+	for _, part := range []string{addr.String("streetAddress"), locality} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return cleanText(strings.Join(parts, ", "))
+}
+
+// offerPrice describes an Offer (or AggregateOffer) price e.g. "Tickets: 8-12 EUR"
+func offerPrice(offer Node) string {
+	currency := offer.String("priceCurrency")
+	price := offer.String("price")
+	if price == "" {
+		low, high := offer.String("lowPrice"), offer.String("highPrice")
+		switch {
+		case low != "" && high != "" && low != high:
+			price = fmt.Sprintf("%s-%s", low, high)
+		case low != "":
+			price = low
+		default:
+			price = high
+		}
+	}
+	switch price {
+	case "":
+		return ""
+	case "0":
+		return "Tickets: free"
+	}
+	return strings.TrimSpace(fmt.Sprintf("Tickets: %s %s", price, currency))
+}
+
+func resolveURL(base string, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return refURL.String()
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
+
+func cleanText(s string) string {
+	return strings.TrimSpace(source.StripHTML(html.UnescapeString(s)))
+}
@@ -0,0 +1,179 @@
+package jsonld
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/warmans/fakt-api/pkg/server/data/source"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"go.uber.org/zap"
+)
+
+const testPage = `<html><head>
+<script type="application/ld+json">
+{
+  "@context": "http://schema.org",
+  "@graph": [
+    {"@type": "Organization", "name": "Some Venue"},
+    {
+      "@type": "MusicEvent",
+      "name": "Foo &amp; Bar live",
+      "description": "<p>Two bands</p>",
+      "startDate": "2016-10-02T20:00",
+      "endDate": "2016-10-02T23:00",
+      "url": "/events/1",
+      "location": {
+        "@type": "Place",
+        "name": "Kinzig 9",
+        "address": {"@type": "PostalAddress", "streetAddress": "Kinzigstr. 9", "postalCode": "10247", "addressLocality": "Berlin"}
+      },
+      "performer": [
+        {"@type": "MusicGroup", "name": "Foo", "genre": "Punk, Hardcore", "sameAs": ["https://foo.bandcamp.com"]},
+        {"@type": "MusicGroup", "name": "Bar"}
+      ],
+      "offers": {"@type": "AggregateOffer", "lowPrice": 8, "highPrice": 12, "priceCurrency": "EUR", "url": "https://tickets.example.com/1"}
+    }
+  ]
+}
+</script>
+<script type="application/ld+json">
+[{"@type": "Event", "name": "Cancelled", "startDate": "2016-10-03T20:00:00+02:00", "eventStatus": "https://schema.org/EventCancelled", "location": "Somewhere"},
+ {"@type": "Event", "name": "\"Baz\" (Noise aus Leipzig)", "startDate": "2016-10-04T20:00:00+02:00", "location": "Tristeza"}]
+</script>
+</head><body></body></html>`
+
+func TestEventsFromPage(t *testing.T) {
+
+	tz, err := source.MustMakeTimeLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("Unexpected error creating timzone: %s", err.Error())
+	}
+
+	c := &Crawler{Timezone: tz, Logger: zap.NewNop()}
+	events, err := c.EventsFromPage(strings.NewReader(testPage), "https://venue.example.com/program")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events got %d", len(events))
+	}
+
+	e := events[0]
+	if formatted := e.Date.Format("02-01-2006 15:04 -0700"); formatted != "02-10-2016 20:00 +0200" {
+		t.Errorf("Unexpected date %s", formatted)
+	}
+	if e.Type != "MusicEvent" {
+		t.Errorf("Unexpected type %s", e.Type)
+	}
+	if e.Description != "Foo & Bar live\nTwo bands\nTickets: 8-12 EUR" {
+		t.Errorf("Unexpected description %q", e.Description)
+	}
+	if e.Venue.Name != "Kinzig 9" || e.Venue.Address != "Kinzigstr. 9, 10247 Berlin" {
+		t.Errorf("Unexpected venue %+v", e.Venue)
+	}
+	if len(e.Performers) != 2 {
+		t.Fatalf("Expected 2 performers got %d", len(e.Performers))
+	}
+	if e.Performers[0].Name != "Foo" || strings.Join(e.Performers[0].Tags, "|") != "Punk|Hardcore" || e.Performers[0].Links[0].URI != "https://foo.bandcamp.com" {
+		t.Errorf("Unexpected performer %+v", e.Performers[0])
+	}
+	if len(e.Links) != 2 || e.Links[0].URI != "https://venue.example.com/events/1" || e.Links[1].Type != "tickets" {
+		t.Errorf("Unexpected links %+v %+v", e.Links[0], e.Links[1])
+	}
+
+	if e.Status != "" || events[1].Status != common.EventStatusCancelled {
+		t.Errorf("Expected only the cancelled event to have a status got %s and %s", e.Status, events[1].Status)
+	}
+
+	//no structured performers so they are guessed from the name
+	e = events[2]
+	if e.Venue.Name != "Tristeza" {
+		t.Errorf("Unexpected venue %+v", e.Venue)
+	}
+	if len(e.Performers) != 1 || e.Performers[0].Name != "Baz" || e.Performers[0].Home != "Leipzig" {
+		t.Errorf("Expected guessed performer got %+v", e.Performers)
+	}
+}
+
+const festivalPage = `<html><head>
+<script type="application/ld+json">
+{
+  "@context": "http://schema.org",
+  "@type": "Festival",
+  "name": "Fusion Nights",
+  "startDate": "2016-10-07T18:00",
+  "endDate": "2016-10-09T23:00",
+  "location": {"@type": "Place", "name": "Kinzig 9"},
+  "subEvent": [
+    {"@type": "MusicEvent", "name": "Foo", "startDate": "2016-10-07T20:00"},
+    {"@type": "MusicEvent", "name": "Bar", "startDate": "2016-10-08T20:00", "location": {"@type": "Place", "name": "Tristeza"}}
+  ]
+}
+</script>
+<script type="application/ld+json">
+{"@type": "Festival", "name": "Weekender", "startDate": "2016-10-14T18:00", "endDate": "2016-10-16T23:00", "location": "Kinzig 9"}
+</script>
+</head><body></body></html>`
+
+func TestEventsFromPageFestival(t *testing.T) {
+
+	tz, err := source.MustMakeTimeLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("Unexpected error creating timzone: %s", err.Error())
+	}
+
+	c := &Crawler{Timezone: tz, Logger: zap.NewNop()}
+	events, err := c.EventsFromPage(strings.NewReader(festivalPage), "https://venue.example.com/program")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	examples := []struct {
+		venue         string
+		festival      string
+		festivalStart string
+		festivalEnd   string
+	}{
+		//sub-events replace the festival and inherit its location if they have none
+		{venue: "Kinzig 9", festival: "Fusion Nights", festivalStart: "07-10-2016 18:00", festivalEnd: "09-10-2016 23:00"},
+		{venue: "Tristeza", festival: "Fusion Nights", festivalStart: "07-10-2016 18:00", festivalEnd: "09-10-2016 23:00"},
+		//a festival without sub-events is its own festival
+		{venue: "Kinzig 9", festival: "Weekender", festivalStart: "14-10-2016 18:00", festivalEnd: "16-10-2016 23:00"},
+	}
+	if len(events) != len(examples) {
+		t.Fatalf("Expected %d events got %d", len(examples), len(events))
+	}
+
+	for i, ex := range examples {
+		e := events[i]
+		if e.Venue == nil || e.Venue.Name != ex.venue {
+			t.Errorf("%d: Unexpected venue %+v", i, e.Venue)
+		}
+		if e.Festival == nil {
+			t.Errorf("%d: Expected festival", i)
+			continue
+		}
+		if e.Festival.Name != ex.festival {
+			t.Errorf("%d: Unexpected festival %s", i, e.Festival.Name)
+		}
+		if start := e.Festival.StartDate.Format("02-01-2006 15:04"); start != ex.festivalStart {
+			t.Errorf("%d: Unexpected festival start %s", i, start)
+		}
+		if end := e.Festival.EndDate.Format("02-01-2006 15:04"); end != ex.festivalEnd {
+			t.Errorf("%d: Unexpected festival end %s", i, end)
+		}
+	}
+}
+
+func TestEventFromNodeWithoutType(t *testing.T) {
+
+	c := &Crawler{Timezone: time.UTC, Logger: zap.NewNop()}
+	e, err := c.EventFromNode(Node{"name": "Untyped", "startDate": "2016-10-02T20:00", "location": "Tristeza"}, "https://venue.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if e.Type != "" || e.Venue.Name != "Tristeza" {
+		t.Errorf("Unexpected event %+v", e)
+	}
+}
@@ -0,0 +1,199 @@
+package jsonld
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// eventTypes are the schema.org Event sub-types that are extracted.
+var eventTypes = map[string]bool{
+	"Event":           true,
+	"MusicEvent":      true,
+	"Festival":        true,
+	"TheaterEvent":    true,
+	"ComedyEvent":     true,
+	"DanceEvent":      true,
+	"ScreeningEvent":  true,
+	"SocialEvent":     true,
+	"LiteraryEvent":   true,
+	"ExhibitionEvent": true,
+	"EducationEvent":  true,
+	"VisualArtsEvent": true,
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// Node is a decoded JSON-LD object.
+type Node map[string]interface{}
+
+// FindEvents decodes a JSON-LD block and returns all Event nodes. Events may be nested in arrays, @graph
+// or other nodes (e.g. a Place with an event property). An event with sub-events (e.g. a festival) is replaced
+// by its sub-events which get the parent as their superEvent.
+func FindEvents(raw []byte) ([]Node, error) {
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	events := make([]Node, 0)
+	walk(doc, func(n Node) {
+		if n.IsEvent() {
+			events = append(events, subEvents(n)...)
+		}
+	})
+	return events, nil
+}
+
+// subEvents returns the event's sub-events or the event itself if it has none. Sub-events without a
+// location are assumed to be at the parent's location.
+func subEvents(n Node) []Node {
+	children := make([]Node, 0)
+	for _, child := range n.Nodes("subEvent") {
+		if child.IsEvent() {
+			children = append(children, child)
+		}
+	}
+	if len(children) == 0 {
+		return []Node{n}
+	}
+
+	parent := Node{}
+	for k, v := range n {
+		if k != "subEvent" {
+			parent[k] = v
+		}
+	}
+	for _, child := range children {
+		if _, ok := child["superEvent"]; !ok {
+			child["superEvent"] = map[string]interface{}(parent)
+		}
+		if _, ok := child["location"]; !ok {
+			child["location"] = parent["location"]
+		}
+	}
+	return children
+}
+
+func walk(v interface{}, visit func(n Node)) {
+	switch val := v.(type) {
+	case []interface{}:
+		for _, item := range val {
+			walk(item, visit)
+		}
+	case map[string]interface{}:
+		n := Node(val)
+		visit(n)
+		if n.IsEvent() {
+			return //don't look for sub-events
+		}
+		for _, child := range val {
+			walk(child, visit)
+		}
+	}
+}
+
+// Types returns the @type value(s) of the node.
+func (n Node) Types() []string {
+	switch t := n["@type"].(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return []string{}
+}
+
+func (n Node) IsEvent() bool {
+	for _, t := range n.Types() {
+		if eventTypes[strings.TrimPrefix(strings.TrimPrefix(t, "http://schema.org/"), "https://schema.org/")] {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns a string property. Numbers are formatted and for nodes the name is used.
+func (n Node) String(key string) string {
+	switch v := n[key].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", v), "0"), ".")
+	case map[string]interface{}:
+		return Node(v).String("name")
+	case []interface{}:
+		if len(v) > 0 {
+			return (Node{key: v[0]}).String(key)
+		}
+	}
+	return ""
+}
+
+// Strings returns a property that may be a single string or a list of strings.
+func (n Node) Strings(key string) []string {
+	strs := make([]string, 0)
+	switch v := n[key].(type) {
+	case string:
+		if v = strings.TrimSpace(v); v != "" {
+			strs = append(strs, v)
+		}
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				strs = append(strs, strings.TrimSpace(s))
+			}
+		}
+	}
+	return strs
+}
+
+// Nodes returns a property that may be a single node or a list of nodes. Plain strings are treated as a
+// node with only a name.
+func (n Node) Nodes(key string) []Node {
+	nodes := make([]Node, 0)
+	var add func(v interface{})
+	add = func(v interface{}) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			nodes = append(nodes, Node(val))
+		case string:
+			if strings.TrimSpace(val) != "" {
+				nodes = append(nodes, Node{"name": val})
+			}
+		case []interface{}:
+			for _, item := range val {
+				add(item)
+			}
+		}
+	}
+	add(n[key])
+	return nodes
+}
+
+// Time parses a date property. Dates with no offset are interpreted in the given location.
+func (n Node) Time(key string, localTime *time.Location) (time.Time, error) {
+	raw := n.String(key)
+	if raw == "" {
+		return time.Time{}, fmt.Errorf("no %s", key)
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, raw, localTime); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown %s format %s", key, raw)
+}
@@ -0,0 +1,218 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/warmans/fakt-api/pkg/server/data/source/extract"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultSchedule = time.Hour
+	DefaultTimeout  = time.Minute * 5
+)
+
+// Factory creates a crawler from its configuration. Each crawler implementation should register one
+// via Register (usually in an init func) under a unique type name.
+type Factory func(conf *CrawlerConfig, tz *time.Location, client *http.Client, logger *zap.Logger) (Crawler, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// Register makes a crawler type available to configuration. It panics if the same type is registered twice.
+func Register(crawlerType string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if factory == nil {
+		panic("source: Register factory is nil")
+	}
+	if _, dup := factories[crawlerType]; dup {
+		panic("source: Register called twice for crawler type " + crawlerType)
+	}
+	factories[crawlerType] = factory
+}
+
+// Types lists the names of all registered crawler types.
+func Types() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	types := make([]string, 0, len(factories))
+	for name := range factories {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func lookupFactory(crawlerType string) (Factory, bool) {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	factory, ok := factories[crawlerType]
+	return factory, ok
+}
+
+// Config is the top level crawler configuration file.
+type Config struct {
+	Crawlers []*CrawlerConfig `json:"crawlers"`
+}
+
+// CrawlerConfig describes a single configured source.
+type CrawlerConfig struct {
+	// Name identifies the source. It is stored against every event the crawler finds so must be unique.
+	Name string `json:"name"`
+	// Type is the registered crawler type e.g. stressfaktor.
+	Type     string `json:"type"`
+	URI      string `json:"uri"`
+	Timezone string `json:"timezone"`
+	// Schedule is a duration string e.g. 1h30m or a cron expression e.g. "0 */6 * * *" (see ParseSchedule).
+	// Defaults to DefaultSchedule.
+	Schedule string `json:"schedule"`
+	// Timeout is the maximum duration of a single crawl e.g. 2m. Defaults to DefaultTimeout.
+	Timeout string `json:"timeout"`
+	Enabled bool   `json:"enabled"`
+	// PerformerExtractors replaces the crawler's own performer extraction with the named strategies e.g.
+	// ["quoted", "lineup"] (see extract.Chain).
+	PerformerExtractors []string `json:"performer_extractors,omitempty"`
+	// Options holds any crawler type specific config.
+	Options json.RawMessage `json:"options,omitempty"`
+}
+
+// DecodeOptions unmarshals the crawler specific options into v. v is left untouched if no options were given.
+func (c *CrawlerConfig) DecodeOptions(v interface{}) error {
+	if len(c.Options) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(c.Options, v); err != nil {
+		return fmt.Errorf("invalid options for crawler %s: %s", c.Name, err.Error())
+	}
+	return nil
+}
+
+func (c *CrawlerConfig) CrawlTimeout() (time.Duration, error) {
+	if c.Timeout == "" {
+		return DefaultTimeout, nil
+	}
+	timeout, err := time.ParseDuration(c.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %s: %s", c.Timeout, err.Error())
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("timeout must be positive (was %s)", c.Timeout)
+	}
+	return timeout, nil
+}
+
+// ConfiguredCrawler is a crawler instance built from a CrawlerConfig.
+type ConfiguredCrawler struct {
+	Crawler
+	Config     *CrawlerConfig
+	Schedule   Schedule
+	Timeout    time.Duration
+	Extractors extract.Chain
+}
+
+// Crawl re-extracts performers from the crawled events if extractors were configured.
+func (c *ConfiguredCrawler) Crawl(ctx context.Context) ([]*common.Event, error) {
+	events, err := c.Crawler.Crawl(ctx)
+	if len(c.Extractors) > 0 {
+		for _, e := range events {
+			c.Extractors.Apply(e)
+		}
+	}
+	return events, err
+}
+
+// Name overrides the underlying crawler's name with the configured one so multiple instances of the same
+// crawler type can be told apart.
+func (c *ConfiguredCrawler) Name() string {
+	return c.Config.Name
+}
+
+// LoadConfig reads a JSON crawler config file (YAML is not supported).
+func LoadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open crawler config: %s", err.Error())
+	}
+	defer f.Close()
+
+	conf := &Config{}
+	if err := json.NewDecoder(f).Decode(conf); err != nil {
+		return nil, fmt.Errorf("failed to parse crawler config %s: %s", path, err.Error())
+	}
+	return conf, nil
+}
+
+// Build creates all enabled crawlers in the config. defaultTimezone is used for any crawler that doesn't
+// specify its own. All crawlers share the given HTTP client.
+func (c *Config) Build(defaultTimezone string, client *http.Client, logger *zap.Logger) ([]*ConfiguredCrawler, error) {
+
+	crawlers := make([]*ConfiguredCrawler, 0)
+	seen := make(map[string]bool)
+
+	for _, conf := range c.Crawlers {
+		if conf.Name == "" {
+			return nil, fmt.Errorf("crawler of type %s has no name", conf.Type)
+		}
+		if seen[conf.Name] {
+			return nil, fmt.Errorf("crawler name %s is not unique", conf.Name)
+		}
+		seen[conf.Name] = true
+
+		if !conf.Enabled {
+			logger.Info("Crawler disabled", zap.String("crawler", conf.Name))
+			continue
+		}
+
+		factory, ok := lookupFactory(conf.Type)
+		if !ok {
+			return nil, fmt.Errorf("crawler %s has unknown type %s (known types: %s)", conf.Name, conf.Type, strings.Join(Types(), ", "))
+		}
+
+		tzName := conf.Timezone
+		if tzName == "" {
+			tzName = defaultTimezone
+		}
+		tz, err := MustMakeTimeLocation(tzName)
+		if err != nil {
+			return nil, err
+		}
+
+		schedule, err := ParseSchedule(conf.Schedule, tz)
+		if err != nil {
+			return nil, fmt.Errorf("crawler %s: %s", conf.Name, err.Error())
+		}
+		timeout, err := conf.CrawlTimeout()
+		if err != nil {
+			return nil, fmt.Errorf("crawler %s: %s", conf.Name, err.Error())
+		}
+
+		extractors, err := extract.NewChain(conf.PerformerExtractors)
+		if err != nil {
+			return nil, fmt.Errorf("crawler %s: %s", conf.Name, err.Error())
+		}
+
+		crawler, err := factory(conf, tz, client, logger.With(zap.String("component", fmt.Sprintf("%s crawler", conf.Name))))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create crawler %s: %s", conf.Name, err.Error())
+		}
+
+		crawlers = append(crawlers, &ConfiguredCrawler{Crawler: crawler, Config: conf, Schedule: schedule, Timeout: timeout, Extractors: extractors})
+	}
+
+	return crawlers, nil
+}
@@ -0,0 +1,91 @@
+package source
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"go.uber.org/zap"
+)
+
+type testCrawler struct {
+	uri string
+}
+
+func (c *testCrawler) Crawl(ctx context.Context) ([]*common.Event, error) {
+	return []*common.Event{}, nil
+}
+
+func (c *testCrawler) Name() string {
+	return "test"
+}
+
+func init() {
+	Register("test", func(conf *CrawlerConfig, tz *time.Location, client *http.Client, logger *zap.Logger) (Crawler, error) {
+		return &testCrawler{uri: conf.URI}, nil
+	})
+}
+
+func TestConfigBuild(t *testing.T) {
+
+	conf := &Config{
+		Crawlers: []*CrawlerConfig{
+			{Name: "one", Type: "test", URI: "http://one", Schedule: "30m", Enabled: true},
+			{Name: "two", Type: "test", URI: "http://two", Enabled: false},
+			{Name: "three", Type: "test", URI: "http://three", Enabled: true},
+		},
+	}
+
+	crawlers, err := conf.Build("Europe/Berlin", nil, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Unexpected error building crawlers: %s", err.Error())
+	}
+	if len(crawlers) != 2 {
+		t.Fatalf("Expected 2 enabled crawlers, got %d", len(crawlers))
+	}
+	if crawlers[0].Name() != "one" || crawlers[1].Name() != "three" {
+		t.Errorf("Crawlers did not use configured names: %s, %s", crawlers[0].Name(), crawlers[1].Name())
+	}
+	if crawlers[0].Schedule != Every(time.Minute*30) {
+		t.Errorf("Unexpected schedule %v", crawlers[0].Schedule)
+	}
+	if crawlers[1].Schedule != Every(DefaultSchedule) {
+		t.Errorf("Expected default schedule got %v", crawlers[1].Schedule)
+	}
+	if crawlers[1].Timeout != DefaultTimeout {
+		t.Errorf("Expected default timeout got %s", crawlers[1].Timeout)
+	}
+	if crawlers[0].Crawler.(*testCrawler).uri != "http://one" {
+		t.Errorf("Crawler was not given configured URI")
+	}
+}
+
+func TestConfigBuildErrors(t *testing.T) {
+
+	examples := []struct {
+		Name string
+		Conf *CrawlerConfig
+	}{
+		{Name: "unknown type", Conf: &CrawlerConfig{Name: "a", Type: "nope", Enabled: true}},
+		{Name: "no name", Conf: &CrawlerConfig{Type: "test", Enabled: true}},
+		{Name: "bad schedule", Conf: &CrawlerConfig{Name: "a", Type: "test", Schedule: "sometimes", Enabled: true}},
+		{Name: "bad cron", Conf: &CrawlerConfig{Name: "a", Type: "test", Schedule: "0 25 * * *", Enabled: true}},
+		{Name: "bad timeout", Conf: &CrawlerConfig{Name: "a", Type: "test", Timeout: "-1m", Enabled: true}},
+		{Name: "bad timezone", Conf: &CrawlerConfig{Name: "a", Type: "test", Timezone: "Nowhere/Special", Enabled: true}},
+		{Name: "bad extractor", Conf: &CrawlerConfig{Name: "a", Type: "test", PerformerExtractors: []string{"psychic"}, Enabled: true}},
+	}
+
+	for _, ex := range examples {
+		conf := &Config{Crawlers: []*CrawlerConfig{ex.Conf}}
+		if _, err := conf.Build("Europe/Berlin", nil, zap.NewNop()); err == nil {
+			t.Errorf("%s: expected error", ex.Name)
+		}
+	}
+
+	dupes := &Config{Crawlers: []*CrawlerConfig{{Name: "a", Type: "test"}, {Name: "a", Type: "test"}}}
+	if _, err := dupes.Build("Europe/Berlin", nil, zap.NewNop()); err == nil {
+		t.Error("expected error for duplicate names")
+	}
+}
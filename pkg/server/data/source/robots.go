@@ -0,0 +1,115 @@
+package source
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsRules are the rules from a robots.txt that apply to one user agent.
+type robotsRules struct {
+	allow      []*regexp.Regexp
+	disallow   []*regexp.Regexp
+	crawlDelay time.Duration
+	expires    time.Time
+}
+
+// Allowed applies the longest matching rule. Allow wins if an allow and disallow rule are the same length.
+func (r *robotsRules) Allowed(path string) bool {
+	longest := func(rules []*regexp.Regexp) int {
+		best := -1
+		for _, re := range rules {
+			if re.MatchString(path) && len(re.String()) > best {
+				best = len(re.String())
+			}
+		}
+		return best
+	}
+	disallowed := longest(r.disallow)
+	return disallowed < 0 || longest(r.allow) >= disallowed
+}
+
+// parseRobots reads the group for the agent (matched case insensitively against the product token e.g. "fakt-api"
+// in "fakt-api/1.0"), falling back to the * group.
+func parseRobots(body io.Reader, agent string) *robotsRules {
+
+	agent = strings.ToLower(strings.SplitN(agent, "/", 2)[0])
+
+	groups := make(map[string]*robotsRules)
+	current := make([]*robotsRules, 0)
+	inRules := false
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		field := strings.ToLower(strings.TrimSpace(parts[0]))
+		value := strings.TrimSpace(parts[1])
+
+		switch field {
+		case "user-agent":
+			//consecutive user-agent lines share the following rules
+			if inRules {
+				current = current[:0]
+				inRules = false
+			}
+			name := strings.ToLower(value)
+			if groups[name] == nil {
+				groups[name] = &robotsRules{}
+			}
+			current = append(current, groups[name])
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue //an empty disallow allows everything
+			}
+			re := robotsPattern(value)
+			for _, g := range current {
+				if field == "allow" {
+					g.allow = append(g.allow, re)
+				} else {
+					g.disallow = append(g.disallow, re)
+				}
+			}
+		case "crawl-delay":
+			inRules = true
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+				for _, g := range current {
+					g.crawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		}
+	}
+
+	if rules, ok := groups[agent]; ok && agent != "" {
+		return rules
+	}
+	if rules, ok := groups["*"]; ok {
+		return rules
+	}
+	return &robotsRules{}
+}
+
+// robotsPattern converts a robots.txt path (which may use * and $) to a regex matching from the start of the path.
+func robotsPattern(path string) *regexp.Regexp {
+	anchored := strings.HasSuffix(path, "$")
+	path = strings.TrimSuffix(path, "$")
+	parts := strings.Split(path, "*")
+	for k, p := range parts {
+		parts[k] = regexp.QuoteMeta(p)
+	}
+	pattern := "^" + strings.Join(parts, ".*")
+	if anchored {
+		pattern += "$"
+	}
+	return regexp.MustCompile(pattern)
+}
@@ -0,0 +1,205 @@
+package source
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when a crawler should next run.
+type Schedule interface {
+	// Next returns the next run time after t or a zero time if there isn't one.
+	Next(t time.Time) time.Time
+}
+
+// ParseSchedule accepts either a duration (e.g. 1h30m) or a five field cron expression
+// (minute hour day-of-month month day-of-week) e.g. "0 */6 * * *". The shortcuts @hourly, @daily,
+// @weekly, @monthly and @yearly are also supported. Cron expressions are evaluated in loc.
+func ParseSchedule(spec string, loc *time.Location) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return Every(DefaultSchedule), nil
+	}
+	if interval, err := time.ParseDuration(spec); err == nil {
+		if interval <= 0 {
+			return nil, fmt.Errorf("schedule must be positive (was %s)", spec)
+		}
+		return Every(interval), nil
+	}
+	return ParseCron(spec, loc)
+}
+
+// Every runs at a fixed interval after the previous run.
+type Every time.Duration
+
+func (e Every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+var cronShortcuts = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+var cronBounds = []struct{ min, max int }{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 7},  // day of week (0 and 7 are both Sunday)
+}
+
+// Cron is a parsed cron expression. Each field is a bit set of the allowed values.
+type Cron struct {
+	Minute, Hour, Dom, Month, Dow uint64
+	Location                      *time.Location
+
+	// as in standard cron if both day fields are restricted a day matching either is allowed
+	domStar, dowStar bool
+}
+
+func ParseCron(spec string, loc *time.Location) (*Cron, error) {
+	if expanded, ok := cronShortcuts[spec]; ok {
+		spec = expanded
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronBounds) {
+		return nil, fmt.Errorf("invalid schedule %s: expected a duration or %d cron fields", spec, len(cronBounds))
+	}
+	sets := make([]uint64, len(fields))
+	for k, field := range fields {
+		set, err := parseCronField(field, cronBounds[k].min, cronBounds[k].max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %s: %s", spec, err.Error())
+		}
+		sets[k] = set
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	c := &Cron{
+		Minute:   sets[0],
+		Hour:     sets[1],
+		Dom:      sets[2],
+		Month:    sets[3],
+		Dow:      sets[4],
+		Location: loc,
+		domStar:  strings.HasPrefix(fields[2], "*"),
+		dowStar:  strings.HasPrefix(fields[4], "*"),
+	}
+	//sunday can be given as 7
+	if c.Dow&(1<<7) > 0 {
+		c.Dow |= 1
+	}
+	if c.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("invalid schedule %s: never runs", spec)
+	}
+	return c, nil
+}
+
+// parseCronField handles comma separated lists of *, values and ranges each with an optional /step.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if idx := strings.Index(part, "/"); idx != -1 {
+			var err error
+			if step, err = strconv.Atoi(part[idx+1:]); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in %s", part)
+			}
+			rangePart = part[:idx]
+		}
+
+		start, end := min, max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if start, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value %s", part)
+			}
+			end = start
+			if len(bounds) == 2 {
+				if end, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value %s", part)
+				}
+			} else if step > 1 {
+				//e.g. 5/15 means every 15 starting at 5
+				end = max
+			}
+		}
+		if start < min || end > max || start > end {
+			return 0, fmt.Errorf("%s is out of range %d-%d", part, min, max)
+		}
+		for v := start; v <= end; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// Next returns the first matching minute after t. A zero time is returned if nothing matches within five years
+// (e.g. 30th of February).
+func (c *Cron) Next(t time.Time) time.Time {
+
+	t = t.In(c.Location)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, c.Location).Add(time.Minute)
+	limit := t.Year() + 5
+
+	for t.Year() <= limit {
+		if c.Month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, c.Location)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.Location)
+			continue
+		}
+		if c.Hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, c.Location)
+			continue
+		}
+		if c.Minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.Dom&(1<<uint(t.Day())) > 0
+	dow := c.Dow&(1<<uint(t.Weekday())) > 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Backoff stretches delay after consecutive failures. The delay doubles with each failure up to maxDelay (it is
+// never reduced below delay itself) and then up to 20% is taken off using random (0 <= random < 1) so
+// sources that fail together don't retry together.
+func Backoff(delay, maxDelay time.Duration, failures int, random float64) time.Duration {
+	if failures <= 0 {
+		return delay
+	}
+	if maxDelay < delay {
+		maxDelay = delay
+	}
+	backoff := delay
+	for n := 0; n < failures && backoff < maxDelay; n++ {
+		backoff *= 2
+	}
+	if backoff > maxDelay {
+		backoff = maxDelay
+	}
+	backoff -= time.Duration(float64(backoff) * 0.2 * random)
+	if backoff < delay {
+		return delay
+	}
+	return backoff
+}
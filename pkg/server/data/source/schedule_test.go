@@ -0,0 +1,73 @@
+package source
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+
+	tz, err := MustMakeTimeLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("Unexpected error creating timzone: %s", err.Error())
+	}
+
+	examples := []struct {
+		Spec     string
+		After    string
+		Expected string
+	}{
+		{Spec: "0 * * * *", After: "2016-09-02 10:00", Expected: "2016-09-02 11:00"},
+		{Spec: "*/15 * * * *", After: "2016-09-02 10:07", Expected: "2016-09-02 10:15"},
+		{Spec: "30 3 * * *", After: "2016-09-02 10:00", Expected: "2016-09-03 03:30"},
+		{Spec: "0 */6 * * *", After: "2016-09-02 19:00", Expected: "2016-09-03 00:00"},
+		{Spec: "0 9 * * 1-5", After: "2016-09-02 10:00", Expected: "2016-09-05 09:00"}, //friday -> monday
+		{Spec: "0 0 1 * 0", After: "2016-09-02 10:00", Expected: "2016-09-04 00:00"},   //dom OR dow
+		{Spec: "0 0 * * 7", After: "2016-09-02 10:00", Expected: "2016-09-04 00:00"},
+		{Spec: "@monthly", After: "2016-12-31 23:59", Expected: "2017-01-01 00:00"},
+		{Spec: "0 0 29 2 *", After: "2016-03-01 00:00", Expected: "2020-02-29 00:00"},
+	}
+
+	for _, ex := range examples {
+		cron, err := ParseCron(ex.Spec, tz)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", ex.Spec, err.Error())
+			continue
+		}
+		after, _ := time.ParseInLocation("2006-01-02 15:04", ex.After, tz)
+		if next := cron.Next(after).Format("2006-01-02 15:04"); next != ex.Expected {
+			t.Errorf("%s: expected %s got %s", ex.Spec, ex.Expected, next)
+		}
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, spec := range []string{"-1h", "* * * *", "60 * * * *", "0 0 30 2 *", "*/0 * * * *", "a b c d e"} {
+		if _, err := ParseSchedule(spec, time.UTC); err == nil {
+			t.Errorf("%s: expected error", spec)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+
+	examples := []struct {
+		Failures int
+		Random   float64
+		Expected time.Duration
+	}{
+		{Failures: 0, Random: 0.5, Expected: time.Hour},
+		{Failures: 1, Random: 0, Expected: time.Hour * 2},
+		{Failures: 3, Random: 0, Expected: time.Hour * 8},
+		{Failures: 3, Random: 0.5, Expected: time.Hour*8 - time.Minute*48},
+		{Failures: 10, Random: 0, Expected: time.Hour * 24},
+	}
+	for _, ex := range examples {
+		if actual := Backoff(time.Hour, time.Hour*24, ex.Failures, ex.Random); actual != ex.Expected {
+			t.Errorf("%d failures: expected %s got %s", ex.Failures, ex.Expected, actual)
+		}
+	}
+	if actual := Backoff(time.Hour*48, time.Hour*24, 2, 0.9); actual != time.Hour*48 {
+		t.Errorf("backoff should never be less than the scheduled delay, got %s", actual)
+	}
+}
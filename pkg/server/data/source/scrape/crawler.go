@@ -0,0 +1,255 @@
+package scrape
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/goodsign/monday"
+	"github.com/warmans/fakt-api/pkg/server/data/source"
+	"github.com/warmans/fakt-api/pkg/server/data/source/extract"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"go.uber.org/zap"
+)
+
+const Type = "scrape"
+
+// Performer extraction modes.
+const (
+	PerformersNone  = "none"
+	PerformersGuess = "guess"
+)
+
+// Options are the scrape specific crawler options.
+type Options struct {
+	// Container selects the element(s) holding the listing. Defaults to body.
+	Container string `json:"container"`
+	// Row selects each row within the container.
+	Row string `json:"row"`
+	// DateHeader optionally identifies rows that only contain a date which applies to all following rows. If set
+	// the Date field is read from the header row instead of each event row.
+	DateHeader string `json:"date_header"`
+	Date       *Field `json:"date"`
+	Time       *Field `json:"time"`
+	// DateLayouts are tried in order to parse the date and time joined with a single space (or just the date
+	// if there is no time field).
+	DateLayouts []string `json:"date_layouts"`
+	// Locale allows parsing non-english month and day names e.g. de_DE.
+	Locale       string `json:"locale"`
+	Venue        *Field `json:"venue"`
+	VenueAddress *Field `json:"venue_address"`
+	Type         *Field `json:"type"`
+	Description  *Field `json:"description"`
+	Tags         *Field `json:"tags"`
+	Performers   string `json:"performers"`
+}
+
+func (o *Options) fields() []*Field {
+	return []*Field{o.Date, o.Time, o.Venue, o.VenueAddress, o.Type, o.Description, o.Tags}
+}
+
+func init() {
+	source.Register(Type, func(conf *source.CrawlerConfig, tz *time.Location, client *http.Client, logger *zap.Logger) (source.Crawler, error) {
+		if conf.URI == "" {
+			return nil, errors.New("a URI is required")
+		}
+		opts := &Options{}
+		if err := conf.DecodeOptions(opts); err != nil {
+			return nil, err
+		}
+		c, err := NewCrawler(conf.URI, opts, tz, logger)
+		if err != nil {
+			return nil, err
+		}
+		c.HTTP = client
+		return c, nil
+	})
+}
+
+func NewCrawler(uri string, opts *Options, tz *time.Location, logger *zap.Logger) (*Crawler, error) {
+
+	if opts.Row == "" {
+		return nil, errors.New("row selector is required")
+	}
+	if opts.Date == nil || opts.Venue == nil {
+		return nil, errors.New("date and venue fields are required")
+	}
+	if len(opts.DateLayouts) == 0 {
+		return nil, errors.New("at least one date layout is required")
+	}
+	for _, f := range opts.fields() {
+		if f == nil {
+			continue
+		}
+		if err := f.compile(); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Container == "" {
+		opts.Container = "body"
+	}
+	switch opts.Performers {
+	case "":
+		opts.Performers = PerformersNone
+	case PerformersNone, PerformersGuess:
+	default:
+		return nil, fmt.Errorf("unknown performers mode %s", opts.Performers)
+	}
+
+	return &Crawler{URI: uri, Options: opts, Timezone: tz, Logger: logger}, nil
+}
+
+// Crawler scrapes events from HTML listing pages using configured CSS selectors.
+type Crawler struct {
+	URI      string
+	Options  *Options
+	Timezone *time.Location
+	HTTP     *http.Client
+	Logger   *zap.Logger
+}
+
+func (c *Crawler) Name() string {
+	return Type
+}
+
+func (c *Crawler) Crawl(ctx context.Context) ([]*common.Event, error) {
+
+	events := make([]*common.Event, 0)
+
+	body, err := source.Get(ctx, c.HTTP, c.URI)
+	if err != nil {
+		return events, fmt.Errorf("scrape crawler fetch failed: %s", err.Error())
+	}
+	defer body.Close()
+
+	return c.EventsFromPage(body)
+}
+
+// EventsFromPage extracts all events from a listing page.
+func (c *Crawler) EventsFromPage(body io.Reader) ([]*common.Event, error) {
+
+	events := make([]*common.Event, 0)
+
+	doc, err := goquery.NewDocumentFromReader(body)
+	if err != nil {
+		return events, fmt.Errorf("scrape crawler failed to parse result: %s", err.Error())
+	}
+
+	doc.Find(c.Options.Container).Each(func(i int, sel *goquery.Selection) {
+		events = append(events, c.HandleContainer(sel)...)
+	})
+
+	return events, nil
+}
+
+// HandleContainer creates events from the rows in a single container. Date header state does not carry over
+// between containers.
+func (c *Crawler) HandleContainer(sel *goquery.Selection) []*common.Event {
+
+	var events []*common.Event
+	var dateStr string
+
+	sel.Find(c.Options.Row).Each(func(i int, row *goquery.Selection) {
+
+		if c.Options.DateHeader != "" && row.Is(c.Options.DateHeader) {
+			var ok bool
+			if dateStr, ok = c.Options.Date.Value(row); !ok {
+				c.Logger.Error(fmt.Sprintf("Invalid date header: %s", strings.TrimSpace(row.Text())))
+			}
+			return //move on
+		}
+
+		if c.Options.DateHeader == "" {
+			var ok bool
+			if dateStr, ok = c.Options.Date.Value(row); !ok {
+				c.Logger.Error(fmt.Sprintf("Invalid date string (text: %s)", strings.TrimSpace(row.Text())))
+				return
+			}
+		}
+		if dateStr == "" {
+			return //still waiting for a valid header
+		}
+
+		dateTime, err := c.ParseTime(dateStr, row)
+		if err != nil {
+			c.Logger.Error(err.Error())
+			return
+		}
+
+		event, err := c.CreateEvent(dateTime, row)
+		if err != nil {
+			c.Logger.Error(fmt.Sprintf("Failed to create event: %s", err.Error()))
+			return
+		}
+
+		events = append(events, event)
+	})
+
+	return events
+}
+
+// ParseTime combines the date with the row's time (if configured) and tries each layout.
+func (c *Crawler) ParseTime(dateStr string, row *goquery.Selection) (time.Time, error) {
+
+	value := dateStr
+	if c.Options.Time != nil {
+		timeStr, ok := c.Options.Time.Value(row)
+		if !ok {
+			return time.Time{}, fmt.Errorf("invalid time string (text: %s)", strings.TrimSpace(row.Text()))
+		}
+		value = fmt.Sprintf("%s %s", dateStr, timeStr)
+	}
+
+	for _, layout := range c.Options.DateLayouts {
+		var t time.Time
+		var err error
+		if c.Options.Locale != "" {
+			t, err = monday.ParseInLocation(layout, value, c.Timezone, monday.Locale(c.Options.Locale))
+		} else {
+			t, err = time.ParseInLocation(layout, value, c.Timezone)
+		}
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("failed to parse date: %s", value)
+}
+
+func (c *Crawler) CreateEvent(date time.Time, row *goquery.Selection) (*common.Event, error) {
+
+	venueName, ok := c.Options.Venue.Value(row)
+	if !ok || venueName == "" {
+		return nil, errors.New("no venue")
+	}
+
+	e := &common.Event{
+		Date:  date,
+		Venue: &common.Venue{Name: venueName},
+		Tags:  []string{},
+	}
+
+	//optional fields
+	if c.Options.VenueAddress != nil {
+		e.Venue.Address, _ = c.Options.VenueAddress.Value(row)
+	}
+	if c.Options.Type != nil {
+		e.Type, _ = c.Options.Type.Value(row)
+	}
+	if c.Options.Description != nil {
+		e.Description, _ = c.Options.Description.Value(row)
+	}
+	if c.Options.Tags != nil {
+		e.Tags = c.Options.Tags.Values(row)
+	}
+
+	if c.Options.Performers == PerformersGuess {
+		extract.Default.Apply(e)
+	}
+
+	return e, nil
+}
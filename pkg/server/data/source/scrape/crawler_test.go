@@ -0,0 +1,97 @@
+package scrape
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/warmans/fakt-api/pkg/server/data/source"
+	"go.uber.org/zap"
+)
+
+const testPage = `<html><body><div id="column_center">
+<table>
+<tr><td class="termin_tag_titel">Montag, 21. Dezember 2015</td></tr>
+<tr class="termin_box">
+	<td class="spalte_uhrzeit"><span class="uhrzeit2">21:00</span></td>
+	<td class="spalte_termintext"><b><a title="Kinzigstr. 9">K9</a></b>: Konzert<br/>"Foo" (Punk aus Leipzig)<br>Eintritt 5&euro;</td>
+	<td class="termin_tags"><span class="termin_tag">Konzert</span><span class="termin_tag">Punk</span></td>
+</tr>
+<tr class="termin_box">
+	<td class="spalte_uhrzeit"><span class="uhrzeit2">ganztags</span></td>
+	<td class="spalte_termintext"><b>Tristeza</b>: Ausstellung</td>
+</tr>
+<tr><td class="termin_tag_titel">Dienstag, 22. Dezember 2015</td></tr>
+<tr class="termin_box">
+	<td class="spalte_uhrzeit"><span class="uhrzeit2">20:00</span></td>
+	<td class="spalte_termintext"><b>Tristeza</b>: Vokü<br>Essen für alle</td>
+</tr>
+</table>
+</div></body></html>`
+
+func TestEventsFromPage(t *testing.T) {
+
+	tz, err := source.MustMakeTimeLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("Unexpected error creating timzone: %s", err.Error())
+	}
+
+	c, err := NewCrawler(
+		"http://example.com",
+		&Options{
+			Container:    "#column_center",
+			Row:          ".termin_tag_titel, .termin_box",
+			DateHeader:   ".termin_tag_titel",
+			Date:         &Field{Regex: `^[A-Za-z]+, ([0-9]{2}\. [\p{L}]+ [0-9]{4})$`},
+			Time:         &Field{Selector: ".spalte_uhrzeit > .uhrzeit2", Regex: `^[0-9]{2}:[0-9]{2}$`},
+			DateLayouts:  []string{"02. January 2006 15:04"},
+			Locale:       "de_DE",
+			Venue:        &Field{Selector: ".spalte_termintext > b"},
+			VenueAddress: &Field{Selector: ".spalte_termintext > b a", Attr: "title"},
+			Type:         &Field{Selector: ".spalte_termintext", Lines: "0", Regex: `^[^:]*:\s*([^:]*)`},
+			Description:  &Field{Selector: ".spalte_termintext", Lines: "1:"},
+			Tags:         &Field{Selector: ".termin_tags > .termin_tag"},
+			Performers:   PerformersGuess,
+		},
+		tz,
+		zap.NewNop(),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error creating crawler: %s", err.Error())
+	}
+
+	events, err := c.EventsFromPage(strings.NewReader(testPage))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events got %d", len(events))
+	}
+
+	e := events[0]
+	if formatted := e.Date.Format("02-01-2006 15:04"); formatted != "21-12-2015 21:00" {
+		t.Errorf("Unexpected date %s", formatted)
+	}
+	if e.Venue.Name != "K9" || e.Venue.Address != "Kinzigstr. 9" {
+		t.Errorf("Unexpected venue %+v", e.Venue)
+	}
+	if e.Type != "Konzert" {
+		t.Errorf("Unexpected type %s", e.Type)
+	}
+	if e.Description != "\"Foo\" (Punk aus Leipzig)\nEintritt 5€" {
+		t.Errorf("Unexpected description %q", e.Description)
+	}
+	if strings.Join(e.Tags, ",") != "Konzert,Punk" {
+		t.Errorf("Unexpected tags %v", e.Tags)
+	}
+	if len(e.Performers) != 1 || e.Performers[0].Name != "Foo" {
+		t.Errorf("Unexpected performers %+v", e.Performers)
+	}
+
+	e = events[1]
+	if formatted := e.Date.Format("02-01-2006 15:04"); formatted != "22-12-2015 20:00" {
+		t.Errorf("Unexpected date %s", formatted)
+	}
+	if e.Venue.Name != "Tristeza" || e.Type != "Vokü" || e.Description != "Essen für alle" {
+		t.Errorf("Unexpected event %+v", e)
+	}
+}
@@ -0,0 +1,149 @@
+package scrape
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/warmans/fakt-api/pkg/server/data/source"
+)
+
+var lineBreak = regexp.MustCompile(`<br\s*/?>`)
+
+// Field describes how to extract a value from a row.
+type Field struct {
+	// Selector is relative to the row. If empty the row itself is used.
+	Selector string `json:"selector"`
+	// Attr reads an attribute instead of the element text.
+	Attr string `json:"attr"`
+	// Lines splits the element HTML on <br> and selects lines using a slice expression e.g. "0", "1:" or "1:3".
+	// Selected lines are stripped of HTML and joined with a newline.
+	Lines string `json:"lines"`
+	// Regex is applied to the extracted text. The first capture group is used or the whole match if there are
+	// no groups. If the regex doesn't match the field has no value.
+	Regex string `json:"regex"`
+
+	regex     *regexp.Regexp
+	lineFrom  int
+	lineTo    int
+	sliceLine bool
+}
+
+func (f *Field) compile() error {
+	if f.Regex != "" {
+		var err error
+		if f.regex, err = regexp.Compile(f.Regex); err != nil {
+			return fmt.Errorf("invalid regex %s: %s", f.Regex, err.Error())
+		}
+	}
+	if f.Lines != "" {
+		if err := f.parseLines(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *Field) parseLines() error {
+	f.sliceLine = true
+	f.lineTo = -1
+
+	parts := strings.SplitN(f.Lines, ":", 2)
+	var err error
+	if parts[0] != "" {
+		if f.lineFrom, err = strconv.Atoi(parts[0]); err != nil || f.lineFrom < 0 {
+			return fmt.Errorf("invalid lines %s", f.Lines)
+		}
+	}
+	if len(parts) == 1 {
+		//single line
+		f.lineTo = f.lineFrom + 1
+		return nil
+	}
+	if parts[1] != "" {
+		if f.lineTo, err = strconv.Atoi(parts[1]); err != nil || f.lineTo < f.lineFrom {
+			return fmt.Errorf("invalid lines %s", f.Lines)
+		}
+	}
+	return nil
+}
+
+// Value extracts the field from the first matching element in the row.
+func (f *Field) Value(row *goquery.Selection) (string, bool) {
+	target := row
+	if f.Selector != "" {
+		target = row.Find(f.Selector).First()
+	}
+	if target.Length() == 0 {
+		return "", false
+	}
+	return f.extract(target)
+}
+
+// Values extracts the field from every matching element in the row.
+func (f *Field) Values(row *goquery.Selection) []string {
+	target := row
+	if f.Selector != "" {
+		target = row.Find(f.Selector)
+	}
+	values := make([]string, 0)
+	target.Each(func(i int, sel *goquery.Selection) {
+		if val, ok := f.extract(sel); ok && val != "" {
+			values = append(values, val)
+		}
+	})
+	return values
+}
+
+func (f *Field) extract(sel *goquery.Selection) (string, bool) {
+
+	var raw string
+	switch {
+	case f.Attr != "":
+		var ok bool
+		if raw, ok = sel.Attr(f.Attr); !ok {
+			return "", false
+		}
+		raw = cleanText(raw)
+	case f.sliceLine:
+		body, err := sel.Html()
+		if err != nil {
+			return "", false
+		}
+		lines := lineBreak.Split(body, -1)
+		from, to := f.lineFrom, f.lineTo
+		if to == -1 || to > len(lines) {
+			to = len(lines)
+		}
+		if from >= to {
+			return "", false
+		}
+		cleaned := make([]string, 0, to-from)
+		for _, line := range lines[from:to] {
+			cleaned = append(cleaned, cleanText(line))
+		}
+		raw = strings.TrimSpace(strings.Join(cleaned, "\n"))
+	default:
+		raw = cleanText(sel.Text())
+	}
+
+	if f.regex != nil {
+		matches := f.regex.FindStringSubmatch(raw)
+		if len(matches) == 0 {
+			return "", false
+		}
+		if len(matches) > 1 {
+			raw = matches[1]
+		} else {
+			raw = matches[0]
+		}
+	}
+	return strings.TrimSpace(raw), true
+}
+
+func cleanText(s string) string {
+	return strings.TrimSpace(source.StripHTML(html.UnescapeString(s)))
+}
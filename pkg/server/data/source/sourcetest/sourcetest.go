@@ -0,0 +1,133 @@
+// Package sourcetest allows crawlers to be tested against recorded upstream responses.
+//
+// Responses are stored as raw HTTP dumps under a testdata directory and replayed by the client returned from
+// NewClient. Running the tests with -record fetches the real responses and overwrites the fixtures, -update
+// rewrites the golden files used by CompareGolden e.g.
+//
+//	go test ./pkg/server/... -args -record -update
+package sourcetest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+var (
+	record = flag.Bool("record", false, "record upstream HTTP responses to the fixture directory")
+	update = flag.Bool("update", false, "update golden files with the actual result")
+)
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// NewClient returns a client that replays responses from dir (or records them with -record).
+func NewClient(t *testing.T, dir string) *http.Client {
+	return &http.Client{Transport: &Transport{Dir: dir, Record: *record, Upstream: http.DefaultTransport, t: t}}
+}
+
+// Transport is a http.RoundTripper that serves responses from fixture files. Any request without a fixture
+// fails unless Record is set, in which case the request is sent Upstream and the response saved.
+type Transport struct {
+	Dir      string
+	Record   bool
+	Upstream http.RoundTripper
+
+	t *testing.T
+}
+
+func (tr *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := FixturePath(tr.Dir, req)
+	if tr.Record {
+		return tr.record(req, path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("no fixture for %s (run with -record to create it): %s", req.URL.String(), err.Error())
+	}
+	defer f.Close()
+
+	raw, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(raw)), req)
+}
+
+func (tr *Transport) record(req *http.Request, path string) (*http.Response, error) {
+	res, err := tr.Upstream.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := httputil.DumpResponse(res, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path, raw, 0644); err != nil {
+		return nil, err
+	}
+	if tr.t != nil {
+		tr.t.Logf("Recorded %s to %s", req.URL.String(), path)
+	}
+	return res, nil
+}
+
+// FixturePath maps a request to a file below dir e.g. http://www.kinzig9.de/rss.xml => dir/www.kinzig9.de/rss.xml.http
+func FixturePath(dir string, req *http.Request) string {
+	name := strings.Trim(req.URL.EscapedPath(), "/")
+	if req.URL.RawQuery != "" {
+		name += "_" + req.URL.RawQuery
+	}
+	if name == "" {
+		name = "index"
+	}
+	if req.Method != http.MethodGet {
+		name = req.Method + "_" + name
+	}
+	return filepath.Join(dir, unsafeChars.ReplaceAllString(req.URL.Host, "_"), unsafeChars.ReplaceAllString(name, "_")+".http")
+}
+
+// CompareGolden fails the test if the JSON encoded events differ from the content of the golden file
+// at path (or writes the file with -update).
+func CompareGolden(t *testing.T, path string, events []*common.Event) {
+	t.Helper()
+
+	actual, err := json.MarshalIndent(events, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to encode events: %s", err.Error())
+	}
+	actual = append(actual, '\n')
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create golden dir: %s", err.Error())
+		}
+		if err := ioutil.WriteFile(path, actual, 0644); err != nil {
+			t.Fatalf("Failed to write golden file: %s", err.Error())
+		}
+		return
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run with -update to create it): %s", err.Error())
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("Events do not match %s (run with -update to accept)\nexpected:\n%s\nactual:\n%s", path, expected, actual)
+	}
+}
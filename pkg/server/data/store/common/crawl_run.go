@@ -0,0 +1,37 @@
+package common
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxCrawlRunErrors limits the number of errors kept for a single run. A crawler with a broken selector can
+// otherwise produce one error for every event.
+const MaxCrawlRunErrors = 100
+
+// CrawlRun is the outcome of a single crawl.
+type CrawlRun struct {
+	ID         int64      `json:"id"`
+	Crawler    string     `json:"crawler"`
+	StartTime  time.Time  `json:"start_time"`
+	EndTime    *time.Time `json:"end_time"`
+	Discovered int64      `json:"discovered"`
+	Inserted   int64      `json:"inserted"`
+	Updated    int64      `json:"updated"`
+	Unchanged  int64      `json:"unchanged"`
+	Rejected   int64      `json:"rejected"`
+	Errors     []string   `json:"errors"`
+	// Succeeded is false if the crawl itself failed (individual events may fail to ingest either way).
+	Succeeded bool `json:"succeeded"`
+
+	droppedErrors int
+}
+
+func (r *CrawlRun) AddError(err error) {
+	if len(r.Errors) >= MaxCrawlRunErrors {
+		r.droppedErrors++
+		r.Errors[MaxCrawlRunErrors-1] = fmt.Sprintf("...and %d more", r.droppedErrors+1)
+		return
+	}
+	r.Errors = append(r.Errors, err.Error())
+}
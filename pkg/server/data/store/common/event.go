@@ -2,10 +2,28 @@ package common
 
 import (
 	"regexp"
-	"strings"
 	"time"
 )
 
+const (
+	EventStatusActive    = "active"
+	EventStatusCancelled = "cancelled"
+	EventStatusPostponed = "postponed"
+	// EventStatusRemoved is set when a future event is no longer listed by its source.
+	EventStatusRemoved = "removed_from_source"
+)
+
+// EventStatuses lists all valid statuses.
+var EventStatuses = []string{EventStatusActive, EventStatusCancelled, EventStatusPostponed, EventStatusRemoved}
+
+var statusKeywords = []struct {
+	status string
+	re     *regexp.Regexp
+}{
+	{status: EventStatusCancelled, re: regexp.MustCompile(`(?i)\b(abgesagt|f(ä|ae)llt(\s+\S+){0,2}\s+aus|entf(ä|ae)llt|cancell?ed)\b`)},
+	{status: EventStatusPostponed, re: regexp.MustCompile(`(?i)\b(verschoben|postponed)\b`)},
+}
+
 type EventVisitor interface {
 	Visit(event *Event)
 }
@@ -18,48 +36,72 @@ type Event struct {
 	Description string       `json:"description"`
 	Performers  []*Performer `json:"performer,omitempty"`
 	Tags        []string     `json:"tag"`
+	Links       []*Link      `json:"link,omitempty"`
 	Source      string       `json:"source"`
-}
+	Status      string       `json:"status"`
+	// Category is the canonical event type (see ClassifyEventType) whereas Type is the text used by the source.
+	Category string `json:"category"`
+	// Sources lists every source that has listed the event. Source is the one that first found it.
+	Sources []*EventSource `json:"sources,omitempty"`
 
-func (e *Event) GuessPerformers() {
+	// Details usually parsed from the description (see GuessDetails).
+	EndDate       *time.Time `json:"end_date,omitempty"`
+	Doors         *time.Time `json:"doors,omitempty"`
+	PriceMin      *float64   `json:"price_min,omitempty"`
+	PriceMax      *float64   `json:"price_max,omitempty"`
+	Currency      string     `json:"currency,omitempty"`
+	IsFree        bool       `json:"is_free"`
+	DonationBased bool       `json:"donation_based"`
+	MinAge        int        `json:"min_age,omitempty"`
 
-	//reset performer list
-	e.Performers = make([]*Performer, 0)
+	// Festival is set if the event is part of a larger event. Only the ID, name and dates are loaded with events.
+	Festival *Festival `json:"festival,omitempty"`
+	// SeriesID is set on recurring events by the series processor.
+	SeriesID int64 `json:"series_id,omitempty"`
 
-	re := regexp.MustCompile(`"[^"]+"[\s]+?\([^(]+\)`)
-	spaceRe := regexp.MustCompile(`\"[\s]+\(`)
-	fromRe := regexp.MustCompile(`(aus|from)\s+([^,\.\;]+)`)
-
-	result := re.FindAllString(e.Description, -1)
-	for _, raw := range result {
-		parts := spaceRe.Split(raw, -1)
-		if len(parts) != 2 {
-			continue
-		}
+	// ExtraText is listing text that isn't part of the description (e.g. a feed item's title) but may contain
+	// details. It is not stored.
+	ExtraText string `json:"-"`
+}
 
-		name := strings.Trim(parts[0], `" `)
-		genre := strings.Trim(parts[1], "() ")
+// EventSource records a source listing an event.
+type EventSource struct {
+	Source    string    `json:"source"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	// Score is how closely the source's listing matched the event (1 for the original source).
+	Score float64 `json:"score"`
+}
 
-		//try and find a location in the genre
-		home := ""
-		if fromMatch := fromRe.FindStringSubmatch(genre); len(fromMatch) == 3 {
-			//e.g. from Berlin, from, Berlin
-			home = fromMatch[2]
-			//if a location was found remove it from the genre
-			genre = strings.Replace(genre, fromMatch[0], "", -1)
+// GuessStatus sets the status to cancelled or postponed if the type or description says so. Otherwise a status
+// given by the source (e.g. an iCalendar STATUS) is kept.
+func (e *Event) GuessStatus() {
+	for _, kw := range statusKeywords {
+		if kw.re.MatchString(e.Type) || kw.re.MatchString(e.Description) {
+			e.Status = kw.status
+			return
 		}
+	}
+	if e.Status == "" {
+		e.Status = EventStatusActive
+	}
+}
 
-		tags := strings.Split(genre, ",")
-		tags = append(tags, home)
-
-		perf := &Performer{
-			Name:  name,
-			Genre: genre,
-			Home:  home,
-			Tags:  tags,
-		}
-		e.Performers = append(e.Performers, perf)
+// MergeStatus returns the status of an event after another source listed it with the other status. Any source
+// can cancel or postpone an event but only its own source can do anything else.
+func MergeStatus(current, other string) string {
+	if current == EventStatusCancelled {
+		return current
+	}
+	if other == EventStatusCancelled || other == EventStatusPostponed {
+		return other
 	}
+	return current
+}
+
+// GuessCategory sets the canonical category based on the type and description.
+func (e *Event) GuessCategory() {
+	e.Category = ClassifyEventType(e.Type, e.Description)
 }
 
 func (e *Event) IsValid() bool {
@@ -69,6 +111,14 @@ func (e *Event) IsValid() bool {
 	return true
 }
 
+// LastDate is when the event ends or the start date if the end is unknown.
+func (e *Event) LastDate() time.Time {
+	if e.EndDate != nil && e.EndDate.After(e.Date) {
+		return *e.EndDate
+	}
+	return e.Date
+}
+
 func (e *Event) Accept(visitor EventVisitor) {
 	visitor.Visit(e)
 }
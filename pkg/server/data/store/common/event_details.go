@@ -0,0 +1,183 @@
+package common
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CurrencyEUR is the only currency prices are currently recognised in.
+const CurrencyEUR = "EUR"
+
+var (
+	// e.g. "18.30 h – 21.30 h" or "20:00-23:00 Uhr"
+	timeRangeRegex = regexp.MustCompile(`(?i)\b(\d{1,2})[.:](\d{2})\b(?:\s*(?:h|uhr)\b)?\s*(?:–|—|-|bis)\s*(\d{1,2})[.:](\d{2})\b`)
+	// e.g. "Einlass 19 Uhr", "doors: 19.30"
+	doorsRegex = regexp.MustCompile(`(?i)\b(?:einlass|doors?(?:\s+open)?)\s*:?\s*(?:ab\s+)?(\d{1,2})(?:[.:](\d{2}))?\b`)
+	// e.g. "5€", "5,50 EUR", "€ 8" or a range "5-10€"
+	priceRangeRegex = regexp.MustCompile(`(?i)(\d{1,3}(?:[.,]\d{1,2})?)\s*(?:€|euro?\b)?\s*(?:–|-|/|bis)\s*(\d{1,3}(?:[.,]\d{1,2})?)\s*(?:€|euro?\b)`)
+	priceRegex      = regexp.MustCompile(`(?i)(?:(\d{1,3}(?:[.,]\d{1,2})?)(?:,-)?\s*(?:€|euro?\b)|€\s*(\d{1,3}(?:[.,]\d{1,2})?))`)
+	freeRegex       = regexp.MustCompile(`(?i)((?:eintritt|tickets?|entry|admission)\s*:?\s*(?:frei|free)\b|freier\s+eintritt|umsonst|kostenlos|gratis|free\s+(?:entry|admission))`)
+	donationRegex   = regexp.MustCompile(`(?i)(spende|donations?\b|pay\s+what\s+you|hutkasse|hut\s+geht\s+rum|selbsteinsch(ä|ae)tzung|sliding\s+scale)`)
+	// e.g. "ab 18" (but not "ab 19.00 h"), "18+", "FSK 16" or "ab 16 Jahren"
+	minAgeRegex = regexp.MustCompile(`(?i)(?:\bab\s+(\d{1,2})\b(\s*(?:[.:]\d|h\b|uhr\b|jahr|j\.))?|\b(\d{1,2})\+|\bfsk\s*(\d{1,2})\b)`)
+)
+
+// GuessDetails sets the end time, doors, price, free/donation entry and age limit from the type and description
+// (and any extra text the crawler found). Details the crawler already set are kept.
+func (e *Event) GuessDetails() {
+
+	text := strings.Join([]string{e.Type, e.Description, e.ExtraText}, "\n")
+
+	if e.EndDate == nil {
+		e.EndDate = parseEndTime(text, e.Date)
+	}
+	if e.Doors == nil {
+		e.Doors = parseDoors(text, e.Date)
+	}
+	if e.PriceMin == nil && e.PriceMax == nil {
+		if prices := parsePrices(text); len(prices) > 0 {
+			min, max := prices[0], prices[0]
+			for _, p := range prices {
+				if p < min {
+					min = p
+				}
+				if p > max {
+					max = p
+				}
+			}
+			e.PriceMin, e.PriceMax = &min, &max
+			e.Currency = CurrencyEUR
+		}
+	}
+	if !e.IsFree {
+		e.IsFree = freeRegex.MatchString(text) && (e.PriceMax == nil || *e.PriceMax == 0)
+	}
+	if !e.DonationBased {
+		e.DonationBased = donationRegex.MatchString(text)
+	}
+	if e.MinAge == 0 {
+		e.MinAge = parseMinAge(text)
+	}
+}
+
+// parseEndTime finds a time range starting at the event's start time. Ranges that don't start at the same time
+// are ignored since they are more likely something else (e.g. a date).
+func parseEndTime(text string, start time.Time) *time.Time {
+	if start.IsZero() {
+		return nil
+	}
+	for _, m := range timeRangeRegex.FindAllStringSubmatch(text, -1) {
+		fromHour, fromMinute, ok := clock(m[1], m[2])
+		if !ok || fromHour != start.Hour() || fromMinute != start.Minute() {
+			continue
+		}
+		toHour, toMinute, ok := clock(m[3], m[4])
+		if !ok {
+			continue
+		}
+		end := time.Date(start.Year(), start.Month(), start.Day(), toHour, toMinute, 0, 0, start.Location())
+		if !end.After(start) {
+			//ends after midnight
+			end = end.AddDate(0, 0, 1)
+		}
+		return &end
+	}
+	return nil
+}
+
+func parseDoors(text string, start time.Time) *time.Time {
+	if start.IsZero() {
+		return nil
+	}
+	m := doorsRegex.FindStringSubmatch(text)
+	if m == nil {
+		return nil
+	}
+	if m[2] == "" {
+		m[2] = "00"
+	}
+	hour, minute, ok := clock(m[1], m[2])
+	if !ok {
+		return nil
+	}
+	doors := time.Date(start.Year(), start.Month(), start.Day(), hour, minute, 0, 0, start.Location())
+	//doors open shortly before the start which may be the other side of midnight
+	if doors.Sub(start) > time.Hour*12 {
+		doors = doors.AddDate(0, 0, -1)
+	}
+	if start.Sub(doors) > time.Hour*12 {
+		doors = doors.AddDate(0, 0, 1)
+	}
+	return &doors
+}
+
+func parsePrices(text string) []float64 {
+	prices := make([]float64, 0)
+	for _, m := range priceRangeRegex.FindAllStringSubmatch(text, -1) {
+		for _, amount := range m[1:] {
+			if price, ok := parsePrice(amount); ok {
+				prices = append(prices, price)
+			}
+		}
+	}
+	for _, m := range priceRegex.FindAllStringSubmatch(text, -1) {
+		amount := m[1]
+		if amount == "" {
+			amount = m[2]
+		}
+		if price, ok := parsePrice(amount); ok {
+			prices = append(prices, price)
+		}
+	}
+	return prices
+}
+
+func parsePrice(amount string) (float64, bool) {
+	price, err := strconv.ParseFloat(strings.Replace(amount, ",", ".", 1), 64)
+	if err != nil || price > 500 {
+		return 0, false
+	}
+	return price, true
+}
+
+func parseMinAge(text string) int {
+	for _, m := range minAgeRegex.FindAllStringSubmatch(text, -1) {
+		switch {
+		case m[1] != "":
+			//"ab 19.00 h" is a time not an age
+			suffix := strings.ToLower(strings.TrimSpace(m[2]))
+			if suffix != "" && !strings.HasPrefix(suffix, "j") {
+				continue
+			}
+			age, _ := strconv.Atoi(m[1])
+			//without "Jahren" only the usual age limits are trusted e.g. "ab 20" is probably a time
+			if suffix == "" && age != 12 && age != 14 && age != 16 && age != 18 {
+				continue
+			}
+			if age >= 6 && age <= 21 {
+				return age
+			}
+		case m[3] != "" || m[4] != "":
+			age, _ := strconv.Atoi(m[3] + m[4])
+			if age >= 6 && age <= 21 {
+				return age
+			}
+		}
+	}
+	return 0
+}
+
+// clock parses an hour and minute, returning false if they are not a valid time of day.
+func clock(hour, minute string) (int, int, bool) {
+	h, err := strconv.Atoi(hour)
+	if err != nil || h > 24 {
+		return 0, 0, false
+	}
+	m, err := strconv.Atoi(minute)
+	if err != nil || m > 59 {
+		return 0, 0, false
+	}
+	return h % 24, m, true
+}
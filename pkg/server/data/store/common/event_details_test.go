@@ -0,0 +1,82 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGuessDetails(t *testing.T) {
+
+	tz, _ := time.LoadLocation("Europe/Berlin")
+	start := time.Date(2016, 9, 15, 18, 30, 0, 0, tz)
+
+	examples := []struct {
+		description   string
+		extra         string
+		date          time.Time
+		endDate       string
+		doors         string
+		priceMin      float64
+		priceMax      float64
+		free          bool
+		donationBased bool
+		minAge        int
+	}{
+		{extra: "Donnerstag, 15.09.2016, 18.30 h – 21.30 h, Größenwahn", endDate: "2016-09-15 21:30"},
+		{description: "Party 18:30-04:00", endDate: "2016-09-16 04:00"},
+		{description: "Freitag, 02.09.2016 – ab 19.00 h – Größenwahn"},
+		{description: "Workshop 10.00 - 12.00 Uhr, Konzert danach"},
+		{description: "Einlass 18 Uhr", doors: "2016-09-15 18:00"},
+		{description: "Doors: 17.45", doors: "2016-09-15 17:45"},
+		{description: "\"Kotzreiz\" (Punk aus Berlin)\nEintritt 5€", priceMin: 5, priceMax: 5},
+		{description: "AK 12,50 EUR / VVK 10 EUR", priceMin: 10, priceMax: 12.5},
+		{description: "5-10€ Spende", priceMin: 5, priceMax: 10, donationBased: true},
+		{description: "Tickets: 8 Euro", priceMin: 8, priceMax: 8},
+		{description: "Eintritt frei, Spenden erwünscht", free: true, donationBased: true},
+		{description: "Umsonst und draußen", free: true},
+		{description: "Tickets: free", free: true},
+		{description: "Soli für die Rigaer 94"},
+		{description: "Party ab 18", minAge: 18},
+		{description: "ab 16 Jahren", minAge: 16},
+		{description: "Rave 18+", minAge: 18},
+		{description: "Film (FSK 12)", minAge: 12},
+		{description: "Konzert ab 20"},
+		{description: "Konzert ab 18 Uhr"},
+	}
+
+	for _, ex := range examples {
+		e := &Event{Date: start, Description: ex.description, ExtraText: ex.extra}
+		e.GuessDetails()
+
+		text := ex.description + ex.extra
+		if got := formatTime(e.EndDate); got != ex.endDate {
+			t.Errorf("%s: expected end date %s got %s", text, ex.endDate, got)
+		}
+		if got := formatTime(e.Doors); got != ex.doors {
+			t.Errorf("%s: expected doors %s got %s", text, ex.doors, got)
+		}
+		if ex.priceMax == 0 {
+			if e.PriceMin != nil || e.PriceMax != nil || e.Currency != "" {
+				t.Errorf("%s: expected no price got %v-%v %s", text, e.PriceMin, e.PriceMax, e.Currency)
+			}
+		} else if e.PriceMin == nil || e.PriceMax == nil || *e.PriceMin != ex.priceMin || *e.PriceMax != ex.priceMax || e.Currency != CurrencyEUR {
+			t.Errorf("%s: expected price %v-%v got %v-%v", text, ex.priceMin, ex.priceMax, e.PriceMin, e.PriceMax)
+		}
+		if e.IsFree != ex.free {
+			t.Errorf("%s: expected free %v got %v", text, ex.free, e.IsFree)
+		}
+		if e.DonationBased != ex.donationBased {
+			t.Errorf("%s: expected donation based %v got %v", text, ex.donationBased, e.DonationBased)
+		}
+		if e.MinAge != ex.minAge {
+			t.Errorf("%s: expected min age %d got %d", text, ex.minAge, e.MinAge)
+		}
+	}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04")
+}
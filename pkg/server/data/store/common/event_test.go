@@ -0,0 +1,84 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestGuessStatus(t *testing.T) {
+
+	examples := []struct {
+		Description string
+		Status      string
+		Expected    string
+	}{
+		{Description: `"Foo" (Punk aus Berlin)`, Expected: EventStatusActive},
+		{Description: `"Foo" (Punk aus Berlin)`, Status: EventStatusCancelled, Expected: EventStatusCancelled},
+		{Description: `Wird auf den 12.10. verschoben`, Status: EventStatusActive, Expected: EventStatusPostponed},
+		{Description: `ABGESAGT! "Foo" (Punk aus Berlin)`, Expected: EventStatusCancelled},
+		{Description: `Das Konzert fällt leider aus`, Expected: EventStatusCancelled},
+		{Description: `Show cancelled due to illness`, Expected: EventStatusCancelled},
+		{Description: `Wird auf den 12.10. verschoben`, Expected: EventStatusPostponed},
+		{Description: `Verschiebebahnhof Party`, Expected: EventStatusActive},
+	}
+
+	for _, ex := range examples {
+		e := &Event{Description: ex.Description, Status: ex.Status}
+		e.GuessStatus()
+		if e.Status != ex.Expected {
+			t.Errorf("%s: expected %s got %s", ex.Description, ex.Expected, e.Status)
+		}
+	}
+}
+
+func TestClassifyEventType(t *testing.T) {
+
+	examples := []struct {
+		Type        string
+		Description string
+		Expected    string
+	}{
+		{Type: "Konzert", Expected: EventCategoryConcert},
+		{Type: "Solikonzert + Party", Expected: EventCategoryConcert},
+		{Type: "Soliparty", Expected: EventCategoryParty},
+		{Type: "Kneipe & Vokü", Expected: EventCategoryVokue},
+		{Type: "Kneipe", Expected: EventCategoryBar},
+		{Type: "Infoveranstaltung", Expected: EventCategoryTalk},
+		{Type: "Lesung", Expected: EventCategoryReading},
+		{Type: "Kino", Expected: EventCategoryFilm},
+		{Type: "Kundgebung", Expected: EventCategoryDemo},
+		{Type: "Ausstellung", Expected: EventCategoryExhibition},
+		{Type: "Fahrradwerkstatt", Expected: EventCategoryWorkshop},
+		{Type: "", Description: `"Kotzreiz" (Punk) live`, Expected: EventCategoryConcert},
+		{Type: "Sonstiges", Description: "Irgendwas", Expected: EventCategoryOther},
+	}
+
+	for _, ex := range examples {
+		if actual := ClassifyEventType(ex.Type, ex.Description); actual != ex.Expected {
+			t.Errorf("%s/%s: expected %s got %s", ex.Type, ex.Description, ex.Expected, actual)
+		}
+	}
+}
+
+func TestMergeStatus(t *testing.T) {
+
+	examples := []struct {
+		Current  string
+		Other    string
+		Expected string
+	}{
+		{Current: EventStatusActive, Other: EventStatusActive, Expected: EventStatusActive},
+		{Current: EventStatusActive, Other: EventStatusCancelled, Expected: EventStatusCancelled},
+		{Current: EventStatusActive, Other: EventStatusPostponed, Expected: EventStatusPostponed},
+		{Current: EventStatusPostponed, Other: EventStatusActive, Expected: EventStatusPostponed},
+		{Current: EventStatusPostponed, Other: EventStatusCancelled, Expected: EventStatusCancelled},
+		{Current: EventStatusCancelled, Other: EventStatusPostponed, Expected: EventStatusCancelled},
+		{Current: EventStatusCancelled, Other: EventStatusActive, Expected: EventStatusCancelled},
+		{Current: EventStatusRemoved, Other: EventStatusActive, Expected: EventStatusRemoved},
+	}
+
+	for _, ex := range examples {
+		if actual := MergeStatus(ex.Current, ex.Other); actual != ex.Expected {
+			t.Errorf("%s + %s: expected %s got %s", ex.Current, ex.Other, ex.Expected, actual)
+		}
+	}
+}
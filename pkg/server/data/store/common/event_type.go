@@ -0,0 +1,63 @@
+package common
+
+import "regexp"
+
+// Canonical event categories.
+const (
+	EventCategoryConcert    = "concert"
+	EventCategoryParty      = "party"
+	EventCategoryFilm       = "film"
+	EventCategoryTalk       = "talk"
+	EventCategoryReading    = "reading"
+	EventCategoryVokue      = "vokü"
+	EventCategoryBar        = "bar"
+	EventCategoryExhibition = "exhibition"
+	EventCategoryDemo       = "demo"
+	EventCategoryWorkshop   = "workshop"
+	EventCategoryTheatre    = "theatre"
+	EventCategoryMeeting    = "meeting"
+	EventCategoryMarket     = "market"
+	EventCategorySport      = "sport"
+	EventCategoryOther      = "other"
+)
+
+// EventCategoryCount is the number of (non-deleted) events in a category.
+type EventCategoryCount struct {
+	Type   string `json:"type"`
+	Events int64  `json:"events"`
+}
+
+// categoryRules are checked in order against the raw type and then the description so e.g. "Solikonzert + Party"
+// is a concert.
+var categoryRules = []struct {
+	category string
+	re       *regexp.Regexp
+}{
+	{category: EventCategoryConcert, re: regexp.MustCompile(`(?i)(konzert|concert|\blive\b|\bgig\b|open stage|jam ?session)`)},
+	{category: EventCategoryParty, re: regexp.MustCompile(`(?i)(party|disko|disco|\bdj|tanz|dance|rave|club)`)},
+	{category: EventCategoryFilm, re: regexp.MustCompile(`(?i)(film|kino|cinema|movie|doku)`)},
+	{category: EventCategoryReading, re: regexp.MustCompile(`(?i)(lesung|reading|buchvorstellung|poetry)`)},
+	{category: EventCategoryTalk, re: regexp.MustCompile(`(?i)(vortrag|talk|diskussion|discussion|info(veranstaltung|abend)|podium|gespr(ä|ae)ch)`)},
+	{category: EventCategoryVokue, re: regexp.MustCompile(`(?i)(vok(ü|ue|u)|k(ü|ue|u)fa|volxk(ü|ue|u)che|soli-?essen|brunch|fr(ü|ue)hst(ü|ue)ck)`)},
+	{category: EventCategoryExhibition, re: regexp.MustCompile(`(?i)(ausstellung|exhibition|vernissage|galerie)`)},
+	{category: EventCategoryDemo, re: regexp.MustCompile(`(?i)(demo|kundgebung|protest|aktion)`)},
+	{category: EventCategoryWorkshop, re: regexp.MustCompile(`(?i)(workshop|seminar|kurs|training|werkstatt|repair)`)},
+	{category: EventCategoryTheatre, re: regexp.MustCompile(`(?i)(theater|theatre|kabarett|performance|zirkus|circus)`)},
+	{category: EventCategoryMeeting, re: regexp.MustCompile(`(?i)(plenum|treffen|meeting|versammlung|sprechstunde|beratung)`)},
+	{category: EventCategoryMarket, re: regexp.MustCompile(`(?i)(flohmarkt|markt|market|basar|bazaar|tausch)`)},
+	{category: EventCategorySport, re: regexp.MustCompile(`(?i)(sport|fu(ß|ss)ball|football|kicker|turnier|yoga|klettern)`)},
+	{category: EventCategoryBar, re: regexp.MustCompile(`(?i)(kneipe|tresen|bar\b|caf(é|e)|cocktail)`)},
+}
+
+// ClassifyEventType maps the raw type to a canonical category. The description is only used if the type
+// doesn't match anything.
+func ClassifyEventType(rawType, description string) string {
+	for _, text := range []string{rawType, description} {
+		for _, rule := range categoryRules {
+			if rule.re.MatchString(text) {
+				return rule.category
+			}
+		}
+	}
+	return EventCategoryOther
+}
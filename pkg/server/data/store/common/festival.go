@@ -0,0 +1,18 @@
+package common
+
+import "time"
+
+// Festival groups events that are part of a larger (usually multi-day) event.
+type Festival struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	StartDate   time.Time `json:"start_date"`
+	EndDate     time.Time `json:"end_date"`
+	Description string    `json:"description,omitempty"`
+	// Events are only loaded when fetching a single festival.
+	Events []*Event `json:"events,omitempty"`
+}
+
+func (f *Festival) IsValid() bool {
+	return f.Name != "" && !f.StartDate.IsZero()
+}
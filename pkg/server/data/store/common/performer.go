@@ -5,6 +5,9 @@ import (
 	"encoding/hex"
 )
 
+// PerformerExtractorStructured is the extractor of performers taken from structured data (e.g. JSON-LD).
+const PerformerExtractorStructured = "structured"
+
 type Performer struct {
 	ID         int64             `json:"id"`
 	Name       string            `json:"name"`
@@ -19,10 +22,18 @@ type Performer struct {
 	Tags       []string          `json:"tag"`
 	Images     map[string]string `json:"images"`
 	EmbedURL   string            `json:"embed_url"`
+	// Extractor and Confidence describe how the performer was found in an event listing (see extract.Chain).
+	Extractor  string  `json:"extractor,omitempty"`
+	Confidence float64 `json:"confidence,omitempty"`
 }
 
+// IsValid requires a name and genre. Performers found by an extractor only need a name since they have a
+// confidence instead (so unlikely performers can be hidden or reviewed).
 func (p *Performer) IsValid() bool {
-	if p.Name == "" || p.Genre == "" {
+	if p.Name == "" {
+		return false
+	}
+	if p.Genre == "" && p.Extractor == "" {
 		return false
 	}
 	return true
@@ -0,0 +1,27 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestPerformerIsValid(t *testing.T) {
+
+	examples := []struct {
+		Performer *Performer
+		Expected  bool
+	}{
+		{Performer: &Performer{Name: "Foo", Genre: "Punk"}, Expected: true},
+		{Performer: &Performer{Name: "", Genre: "Punk"}, Expected: false},
+		{Performer: &Performer{Name: "Foo"}, Expected: false},
+		{Performer: &Performer{Name: "Foo", Extractor: "caps", Confidence: 0.4}, Expected: true},
+		{Performer: &Performer{Name: "", Extractor: "caps", Confidence: 0.4}, Expected: false},
+		{Performer: &Performer{Name: "Foo", Extractor: PerformerExtractorStructured, Confidence: 1}, Expected: true},
+		{Performer: &Performer{Name: "", Extractor: PerformerExtractorStructured, Confidence: 1}, Expected: false},
+	}
+
+	for _, ex := range examples {
+		if valid := ex.Performer.IsValid(); valid != ex.Expected {
+			t.Errorf("%+v: expected %v got %v", ex.Performer, ex.Expected, valid)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package common
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Types of record that can be quarantined.
+const (
+	QuarantineTypeEvent     = "event"
+	QuarantineTypePerformer = "performer"
+)
+
+// QuarantinedRecord is an event or performer that was rejected at ingest, kept so it can be fixed and
+// re-submitted instead of being lost.
+type QuarantinedRecord struct {
+	ID     int64  `json:"id"`
+	Source string `json:"source"`
+	Type   string `json:"type"`
+	// Payload is the record as it was crawled (or last edited).
+	Payload json.RawMessage `json:"payload"`
+	Reason  string          `json:"reason"`
+	// EventID is the event a rejected performer was listed with.
+	EventID int64      `json:"event_id,omitempty"`
+	Created time.Time  `json:"created"`
+	Updated *time.Time `json:"updated"`
+}
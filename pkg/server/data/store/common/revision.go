@@ -0,0 +1,113 @@
+package common
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// EventRevision records how an event changed between two crawls.
+type EventRevision struct {
+	ID      int64          `json:"id"`
+	EventID int64          `json:"event_id"`
+	Date    time.Time      `json:"date"`
+	Source  string         `json:"source"`
+	Changes []*FieldChange `json:"changes"`
+}
+
+// FieldChange is the old and new value of a single event field. Lists (performers, tags) are sorted.
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// DiffEvents returns the changes from old to updated. The venue isn't compared since changing it creates a new
+// event.
+func DiffEvents(old, updated *Event) []*FieldChange {
+
+	changes := make([]*FieldChange, 0)
+
+	if !old.Date.Equal(updated.Date) {
+		changes = append(changes, &FieldChange{Field: "date", Old: old.Date, New: updated.Date})
+	}
+	if old.Type != updated.Type {
+		changes = append(changes, &FieldChange{Field: "type", Old: old.Type, New: updated.Type})
+	}
+	if old.Status != updated.Status {
+		changes = append(changes, &FieldChange{Field: "status", Old: old.Status, New: updated.Status})
+	}
+	if old.Description != updated.Description {
+		changes = append(changes, &FieldChange{Field: "description", Old: old.Description, New: updated.Description})
+	}
+	if oldIDs, newIDs := performerIDs(old.Performers), performerIDs(updated.Performers); !int64sEqual(oldIDs, newIDs) {
+		changes = append(changes, &FieldChange{Field: "performer", Old: performerNames(old.Performers), New: performerNames(updated.Performers)})
+	}
+	if oldTags, newTags := normalizedTags(old.Tags), normalizedTags(updated.Tags); !stringsEqual(oldTags, newTags) {
+		changes = append(changes, &FieldChange{Field: "tag", Old: oldTags, New: newTags})
+	}
+	return changes
+}
+
+// performerIDs only includes stored performers since others (e.g. with no name) are never linked to the event.
+// Performers are compared by ID since a listed name may differ from the stored one (see performer aliases).
+func performerIDs(performers []*Performer) []int64 {
+	ids := make([]int64, 0, len(performers))
+	for _, p := range performers {
+		if p.ID != 0 {
+			ids = append(ids, p.ID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func performerNames(performers []*Performer) []string {
+	names := make([]string, 0, len(performers))
+	for _, p := range performers {
+		if p.ID != 0 {
+			names = append(names, p.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// normalizedTags matches how tags are stored (lower case, no duplicates).
+func normalizedTags(tags []string) []string {
+	seen := make(map[string]bool)
+	normalized := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(t)
+		if !seen[t] {
+			seen[t] = true
+			normalized = append(normalized, t)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k := range a {
+		if a[k] != b[k] {
+			return false
+		}
+	}
+	return true
+}
+
+func int64sEqual(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k := range a {
+		if a[k] != b[k] {
+			return false
+		}
+	}
+	return true
+}
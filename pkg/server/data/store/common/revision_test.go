@@ -0,0 +1,63 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiffEvents(t *testing.T) {
+
+	old := &Event{
+		Type:        "Konzert",
+		Description: `"Foo" (Punk aus Berlin)`,
+		Performers:  []*Performer{{ID: 1, Name: "Foo"}},
+		Tags:        []string{"punk", "konzert"},
+	}
+
+	//performers that weren't stored and listed names that differ from the stored name are not changes
+	same := &Event{
+		Type:        "Konzert",
+		Description: `"Foo" (Punk aus Berlin)`,
+		Performers:  []*Performer{{ID: 1, Name: "FOO"}, {Name: "Baz"}},
+		Tags:        []string{"Konzert", "Punk"},
+	}
+	if changes := DiffEvents(old, same); len(changes) != 0 {
+		t.Errorf("Expected no changes got %d", len(changes))
+	}
+
+	changed := &Event{
+		Type:        "Konzert",
+		Description: `"Foo" (Punk aus Berlin), "Bar" (Crust)`,
+		Performers:  []*Performer{{ID: 1, Name: "Foo"}, {ID: 2, Name: "Bar"}},
+		Tags:        []string{"punk", "konzert"},
+	}
+	changes := DiffEvents(old, changed)
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes got %d", len(changes))
+	}
+	if changes[0].Field != "description" || changes[1].Field != "performer" {
+		t.Errorf("Unexpected changed fields %s, %s", changes[0].Field, changes[1].Field)
+	}
+	if names := changes[1].New.([]string); len(names) != 2 || names[0] != "Bar" {
+		t.Errorf("Unexpected new performers %v", names)
+	}
+
+	date := time.Date(2018, 7, 20, 20, 0, 0, 0, time.UTC)
+	old.Date = date
+	retimed := &Event{
+		Date:        date.Add(time.Hour),
+		Type:        old.Type,
+		Description: old.Description,
+		Performers:  old.Performers,
+		Tags:        old.Tags,
+	}
+	changes = DiffEvents(old, retimed)
+	if len(changes) != 1 || changes[0].Field != "date" || !changes[0].New.(time.Time).Equal(date.Add(time.Hour)) {
+		t.Errorf("Expected a date change got %+v", changes)
+	}
+	//the same time in a different location is not a change
+	retimed.Date = date.In(time.FixedZone("CEST", 7200))
+	if changes := DiffEvents(old, retimed); len(changes) != 0 {
+		t.Errorf("Expected no changes got %d", len(changes))
+	}
+}
@@ -0,0 +1,16 @@
+package common
+
+import "time"
+
+// EventSeries is a recurring event e.g. a weekly Vokü. Its events are the ones with the series' ID.
+type EventSeries struct {
+	ID          int64        `json:"id"`
+	Venue       *Venue       `json:"venue,omitempty"`
+	Weekday     time.Weekday `json:"weekday"`
+	Time        string       `json:"time"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	NumEvents   int64        `json:"num_events"`
+	FirstDate   *time.Time   `json:"first_date,omitempty"`
+	LastDate    *time.Time   `json:"last_date,omitempty"`
+}
@@ -0,0 +1,61 @@
+package common
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/texttheater/golang-levenshtein/levenshtein"
+)
+
+var umlauts = strings.NewReplacer("ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss")
+
+// NormalizeName lower cases the string, spells out umlauts and reduces everything that isn't a letter or number
+// to single spaces e.g. "Größenwahn (Berlin)!" => "groessenwahn berlin"
+func NormalizeName(s string) string {
+	s = umlauts.Replace(strings.ToLower(s))
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}), " ")
+}
+
+// Similarity is the levenshtein ratio (0-1) of the normalized strings.
+func Similarity(a, b string) float64 {
+	a, b = NormalizeName(a), NormalizeName(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	return levenshtein.RatioForStrings([]rune(a), []rune(b), levenshtein.DefaultOptions)
+}
+
+// TokenOverlap is the fraction (0-1) of significant words in the shorter string that also appear in the other.
+// Unlike Similarity it isn't affected by word order or extra text.
+func TokenOverlap(a, b string) float64 {
+	aTokens, bTokens := significantTokens(a), significantTokens(b)
+	if len(aTokens) == 0 || len(bTokens) == 0 {
+		return 0
+	}
+	if len(aTokens) > len(bTokens) {
+		aTokens, bTokens = bTokens, aTokens
+	}
+	matched := 0
+	for token := range aTokens {
+		if bTokens[token] {
+			matched++
+		}
+	}
+	return float64(matched) / float64(len(aTokens))
+}
+
+func significantTokens(s string) map[string]bool {
+	tokens := make(map[string]bool)
+	for _, token := range strings.Fields(NormalizeName(s)) {
+		//ignore short words (mostly articles and prepositions)
+		if len([]rune(token)) >= 4 {
+			tokens[token] = true
+		}
+	}
+	return tokens
+}
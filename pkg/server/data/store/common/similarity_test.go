@@ -0,0 +1,43 @@
+package common
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+
+	examples := []struct {
+		Name     string
+		Expected string
+	}{
+		{Name: "Größenwahn (Berlin)!", Expected: "groessenwahn berlin"},
+		{Name: "  K9 - Kinzigstr. 9 ", Expected: "k9 kinzigstr 9"},
+		{Name: "SO36", Expected: "so36"},
+		{Name: "!!", Expected: ""},
+	}
+
+	for _, ex := range examples {
+		if actual := NormalizeName(ex.Name); actual != ex.Expected {
+			t.Errorf("%s: expected %s got %s", ex.Name, ex.Expected, actual)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+
+	examples := []struct {
+		A, B string
+		Min  float64
+		Max  float64
+	}{
+		{A: "Cassiopeia", B: "cassiopeia!", Min: 1, Max: 1},
+		{A: "Zukunft am Ostkreuz", B: "Zukunft am Ostkreutz", Min: 0.9, Max: 1},
+		{A: "SO36", B: "SO 36", Min: 0.85, Max: 0.9},
+		{A: "K9", B: "Köpi", Min: 0, Max: 0.5},
+		{A: "", B: "Köpi", Min: 0, Max: 0},
+	}
+
+	for _, ex := range examples {
+		if s := Similarity(ex.A, ex.B); s < ex.Min || s > ex.Max {
+			t.Errorf("%s/%s: expected similarity between %f and %f got %f", ex.A, ex.B, ex.Min, ex.Max, s)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package common
 type Tag struct {
 	ID             int64  `json:"id"`
 	Tag            string `json:"tag"`
+	ParentID       int64  `json:"parent_id,omitempty"`
 	StatPerformers int64  `json:"stat_performers"`
 	StatEvents     int64  `json:"stat_events"`
 }
@@ -17,3 +17,10 @@ func (v *Venue) IsValid() bool {
 	}
 	return true
 }
+
+// VenueDuplicate is a pair of venues that are probably the same place.
+type VenueDuplicate struct {
+	Venue     *Venue  `json:"venue"`
+	Duplicate *Venue  `json:"duplicate"`
+	Score     float64 `json:"score"`
+}
@@ -0,0 +1,166 @@
+package crawlrun
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/warmans/dbr"
+	"github.com/warmans/dbr/dialect"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+func FilterFromRequest(r *http.Request) *Filter {
+	f := &Filter{}
+	f.Populate(r)
+	return f
+}
+
+type Filter struct {
+	common.Filter
+
+	Crawler string `json:"crawler"`
+	Failed  bool   `json:"failed"`
+}
+
+func (f *Filter) Populate(r *http.Request) {
+
+	f.Filter.Populate(r)
+
+	f.Crawler = r.Form.Get("crawler")
+	f.Failed = common.StringToBool(r.Form.Get("failed"))
+}
+
+type Store struct {
+	DB *dbr.Session
+}
+
+// StartRun creates the run record so in-progress runs are visible.
+func (s *Store) StartRun(run *common.CrawlRun) error {
+	res, err := s.DB.Exec(
+		"INSERT INTO crawl_run (crawler, start_time) VALUES (?, ?)",
+		run.Crawler,
+		run.StartTime.Format(common.DateFormatSQL),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create crawl run because %s", err.Error())
+	}
+	if run.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get crawl run id because %s", err.Error())
+	}
+	return nil
+}
+
+// FinishRun stores the final counts and errors of a run.
+func (s *Store) FinishRun(run *common.CrawlRun) error {
+
+	if run.EndTime == nil {
+		return fmt.Errorf("crawl run %d has no end time", run.ID)
+	}
+
+	errs, err := json.Marshal(run.Errors)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.DB.Exec(
+		"UPDATE crawl_run SET end_time=?, discovered=?, inserted=?, updated=?, unchanged=?, rejected=?, succeeded=?, errors=? WHERE id=?",
+		run.EndTime.Format(common.DateFormatSQL),
+		run.Discovered,
+		run.Inserted,
+		run.Updated,
+		run.Unchanged,
+		run.Rejected,
+		run.Succeeded,
+		string(errs),
+		run.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update crawl run %d because %s", run.ID, err.Error())
+	}
+	return nil
+}
+
+// FindRuns returns runs newest first.
+func (s *Store) FindRuns(filter *Filter) ([]*common.CrawlRun, error) {
+
+	//if no page is specified assume the first page
+	page := filter.Page
+	if page == 0 {
+		page = 1
+	}
+
+	q := s.DB.Select(
+		"id",
+		"crawler",
+		"start_time",
+		"end_time",
+		"discovered",
+		"inserted",
+		"updated",
+		"unchanged",
+		"rejected",
+		"succeeded",
+		"coalesce(errors, '')",
+	)
+	q.From("crawl_run")
+	q.OrderDir("start_time", false).OrderDir("id", false)
+
+	if filter.PageSize != 0 {
+		q.Limit(uint64(filter.PageSize)).Offset(uint64((filter.PageSize * page) - filter.PageSize))
+	}
+	if len(filter.IDs) > 0 {
+		q.Where("id IN ?", filter.IDs)
+	}
+	if filter.Crawler != "" {
+		q.Where("crawler = ?", filter.Crawler)
+	}
+	if filter.Failed {
+		q.Where("succeeded = 0 AND end_time IS NOT NULL")
+	}
+
+	sqlString, vals := q.ToSql()
+	interpolated, err := dbr.InterpolateForDialect(sqlString, vals, dialect.SQLite3)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.DB.Query(interpolated)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer result.Close()
+
+	runs := make([]*common.CrawlRun, 0)
+	for result.Next() {
+		run := &common.CrawlRun{Errors: []string{}}
+		var endTime *time.Time
+		var errs string
+		err := result.Scan(
+			&run.ID,
+			&run.Crawler,
+			&run.StartTime,
+			&endTime,
+			&run.Discovered,
+			&run.Inserted,
+			&run.Updated,
+			&run.Unchanged,
+			&run.Rejected,
+			&run.Succeeded,
+			&errs,
+		)
+		if err != nil {
+			return nil, err
+		}
+		run.EndTime = endTime
+		if errs != "" {
+			if err := json.Unmarshal([]byte(errs), &run.Errors); err != nil {
+				return nil, fmt.Errorf("failed to decode errors of crawl run %d because %s", run.ID, err.Error())
+			}
+		}
+		runs = append(runs, run)
+	}
+	return runs, result.Err()
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/warmans/dbr/dialect"
 	"github.com/warmans/fakt-api/pkg/server/data/store/common"
 	"github.com/warmans/fakt-api/pkg/server/data/store/performer"
+	"github.com/warmans/fakt-api/pkg/server/data/store/tag"
 )
 
 func FilterFromRequest(r *http.Request) *Filter {
@@ -34,6 +35,16 @@ type Filter struct {
 	UTagUser          string    `json:"utag_user"`
 	LoadPerformerTags bool      `json:"load_performer_tags"`
 	Source            string    `json:"source"`
+	Statuses          []string  `json:"status"`
+	// MinPerformerConfidence hides performers extracted with a lower confidence.
+	MinPerformerConfidence float64 `json:"performer_confidence"`
+	Free                   bool    `json:"free"`
+	// MaxPrice limits events to those that are free, donation based or have a ticket at or below the price.
+	MaxPrice   float64 `json:"max_price"`
+	FestivalID int64   `json:"festival"`
+	SeriesID   int64   `json:"series"`
+	// ExcludeRecurring hides events that are part of a series e.g. a weekly Vokü.
+	ExcludeRecurring bool `json:"exclude_recurring"`
 }
 
 func (f *Filter) Populate(r *http.Request) {
@@ -94,6 +105,30 @@ func (f *Filter) Populate(r *http.Request) {
 
 	//additionally only look for tags from a specific user
 	f.UTagUser = r.Form.Get("tag_user")
+
+	f.Source = r.Form.Get("source")
+
+	f.Statuses = make([]string, 0)
+	if status := r.Form.Get("status"); status != "" {
+		f.Statuses = strings.Split(status, ",")
+	}
+
+	if confidence, err := strconv.ParseFloat(r.Form.Get("performer_confidence"), 64); err == nil {
+		f.MinPerformerConfidence = confidence
+	}
+
+	f.Free = common.StringToBool(r.Form.Get("free"))
+	if maxPrice, err := strconv.ParseFloat(r.Form.Get("max_price"), 64); err == nil && maxPrice >= 0 {
+		f.MaxPrice = maxPrice
+	}
+
+	if festival, err := strconv.Atoi(r.Form.Get("festival")); err == nil {
+		f.FestivalID = int64(festival)
+	}
+	if series, err := strconv.Atoi(r.Form.Get("series")); err == nil {
+		f.SeriesID = int64(series)
+	}
+	f.ExcludeRecurring = common.StringToBool(r.Form.Get("exclude_recurring"))
 }
 
 type Store struct {
@@ -101,59 +136,116 @@ type Store struct {
 	PerformerStore *performer.Store
 }
 
-func (s *Store) EventMustExist(tr *dbr.Tx, event *common.Event) error {
+// EventMustExist creates or updates the event. changed is true if the event was created or a revision was
+// recorded (see StoreEventRevision).
+func (s *Store) EventMustExist(tr *dbr.Tx, event *common.Event) (changed bool, err error) {
 
 	//sanity check incoming data
 	if !event.IsValid() || event.Venue == nil || !event.Venue.IsValid() {
-		return fmt.Errorf("invalid event/venue was rejected at injest. Event was %+v venue was %+v", event, event.Venue)
+		return false, fmt.Errorf("invalid event/venue was rejected at injest. Event was %+v venue was %+v", event, event.Venue)
 	}
 
 	if event.ID == 0 {
-		// if no ID was supplied try and find one based on the venue and date. i.e. assume two events cannot occur at the
-		// same venue at the same time. Note that if either of these fields has been updated there will be no match
-		// and the row will be processed as though it is a new record.
-		err := tr.QueryRow("SELECT id FROM event WHERE venue_id=? AND date=?", event.Venue.ID, event.Date.Format(common.DateFormatSQL)).Scan(&event.ID)
-		if err != nil && err != sql.ErrNoRows {
-			return err
+		if event.ID, err = s.FindEventID(tr, event); err != nil {
+			return false, err
 		}
 	}
 
+	if event.Status == "" {
+		event.Status = common.EventStatusActive
+	}
+	if event.Category == "" {
+		event.GuessCategory()
+	}
+
+	festivalID, err := s.eventFestivalID(tr, event)
+	if err != nil {
+		return false, err
+	}
+
+	//normalize now so revisions aren't recorded for tags that only differ in spelling
+	event.Tags = tag.NormalizeAll(event.Tags)
+
 	//update/create the main event record
 	if event.ID == 0 {
 
 		res, err := tr.Exec(
-			"INSERT INTO event (date, venue_id, type, description) VALUES (?, ?, ?, ?)",
+			`INSERT INTO event
+				(date, venue_id, type, category, description, source, status, end_date, doors, price_min, price_max, currency, is_free, donation_based, min_age, festival_id)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 			event.Date.Format(common.DateFormatSQL),
 			event.Venue.ID,
 			event.Type,
+			event.Category,
 			event.Description,
+			event.Source,
+			event.Status,
+			nullIfNoTime(event.EndDate),
+			nullIfNoTime(event.Doors),
+			event.PriceMin,
+			event.PriceMax,
+			nullIfEmpty(event.Currency),
+			event.IsFree,
+			event.DonationBased,
+			nullIfZero(event.MinAge),
+			festivalID,
 		)
 		if err != nil {
-			return err
+			return false, err
 		}
 		event.ID, err = res.LastInsertId()
 		if err != nil {
-			return err
+			return false, err
 		}
+		changed = true
 
 	} else {
-		// note that we cannot update the venue or date. Doing so will create a new event since these fields act
-		// as a composite primary key.
-		_, err := tr.Exec(
-			"UPDATE event SET type=?, description=? WHERE id=?",
+		statusSource, err := s.otherSourceStatus(tr, event)
+		if err != nil {
+			return false, err
+		}
+
+		//keep track of what changed before overwriting it
+		if changed, err = s.StoreEventRevision(tr, event); err != nil {
+			return false, err
+		}
+
+		// note that we cannot update the venue. Doing so will create a new event since venue and date act as
+		// a composite primary key. The date only changes if the caller matched a re-timed listing to the event.
+		// The source is only set if missing so the event stays with the source that first found it. A festival
+		// is kept if the listing doesn't mention one.
+		_, err = tr.Exec(
+			`UPDATE event SET
+				date=?, type=?, category=?, description=?, status=?, status_source=?, source=coalesce(source, ?), end_date=?, doors=?, price_min=?,
+				price_max=?, currency=?, is_free=?, donation_based=?, min_age=?, festival_id=coalesce(?, festival_id)
+				WHERE id=?`,
+			event.Date.Format(common.DateFormatSQL),
 			event.Type,
+			event.Category,
 			event.Description,
+			event.Status,
+			statusSource,
+			event.Source,
+			nullIfNoTime(event.EndDate),
+			nullIfNoTime(event.Doors),
+			event.PriceMin,
+			event.PriceMax,
+			nullIfEmpty(event.Currency),
+			event.IsFree,
+			event.DonationBased,
+			nullIfZero(event.MinAge),
+			festivalID,
 			event.ID,
 		)
 		if err != nil {
-			return err
+			return false, err
 		}
 	}
 
 	//clear existing relationships (i.e. always use the most up-to-date listing)
-	_, err := tr.Exec("DELETE FROM event_performer WHERE event_id=?", event.ID)
+	_, err = tr.Exec("DELETE FROM event_performer WHERE event_id=?", event.ID)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	//finally append the performers
@@ -162,48 +254,124 @@ func (s *Store) EventMustExist(tr *dbr.Tx, event *common.Event) error {
 			continue
 		}
 		//make the association
-		_, err := tr.Exec("REPLACE INTO event_performer (event_id, performer_id) VALUES (?, ?)", event.ID, perfs.ID)
+		_, err := tr.Exec(
+			"REPLACE INTO event_performer (event_id, performer_id, extractor, confidence) VALUES (?, ?, ?, ?)",
+			event.ID,
+			perfs.ID,
+			nullIfEmpty(perfs.Extractor),
+			performerConfidence(perfs),
+		)
 		if err != nil {
-			return err
+			return false, err
 		}
 	}
 
 	//and the tags...
 	if err := s.StoreEventTags(tr, event.ID, event.Tags); err != nil {
-		return err
+		return false, err
+	}
+
+	//and links
+	if err := s.StoreEventLinks(tr, event.ID, event.Links); err != nil {
+		return false, err
+	}
+
+	return changed, nil
+}
+
+// ReconcileSource marks future events from the source that were not seen in the latest crawl as removed.
+// Only events up to until (normally the last date seen in the crawl) are considered since sources usually
+// list a limited period. Events another source has listed within otherSourceGrace are left alone. Returns
+// the number of events marked removed.
+func (s *Store) ReconcileSource(source string, seenIDs []int64, until time.Time, otherSourceGrace time.Duration) (int64, error) {
+
+	q := s.DB.Update("event").
+		Set("status", common.EventStatusRemoved).
+		Where("source = ?", source).
+		Where("date >= ?", time.Now().Format(common.DateFormatSQL)).
+		Where("date <= ?", until.Format(common.DateFormatSQL)).
+		Where("deleted = 0").
+		Where("status != ?", common.EventStatusRemoved)
+	if len(seenIDs) > 0 {
+		q.Where("id NOT IN ?", seenIDs)
+	}
+	q.Where(
+		"id NOT IN (SELECT event_id FROM event_source WHERE source != ? AND last_seen >= ?)",
+		source,
+		time.Now().Add(-otherSourceGrace).Format(common.DateFormatSQL),
+	)
+
+	res, err := q.Exec()
+	if err != nil {
+		return 0, fmt.Errorf("failed to reconcile events from %s because %s", source, err.Error())
 	}
+	return res.RowsAffected()
+}
+
+// FindEventID returns the ID of an existing event or 0. If no ID was supplied try and find one based on the venue
+// and date. i.e. assume two events cannot occur at the same venue at the same time. Note that if either of these
+// fields has been updated there will be no match. A changed start time can be matched using FindEventCandidates
+// by callers that know what else the source lists.
+func (s *Store) FindEventID(tr *dbr.Tx, event *common.Event) (int64, error) {
+	if event.ID != 0 {
+		return event.ID, nil
+	}
+	var id int64
+	err := tr.QueryRow("SELECT id FROM event WHERE venue_id=? AND date=?", event.Venue.ID, event.Date.Format(common.DateFormatSQL)).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	return id, nil
+}
 
+func (s *Store) StoreEventLinks(tr *dbr.Tx, eventID int64, links []*common.Link) error {
+	//clear existing links so they are kept up-to-date with the source
+	if _, err := tr.Exec("DELETE FROM event_extra WHERE event_id = ?", eventID); err != nil {
+		return fmt.Errorf("failed to clear existing links due to error: %s", err.Error())
+	}
+	for _, link := range links {
+		_, err := tr.Exec(
+			"INSERT INTO event_extra (event_id, link, link_type, link_description) VALUES (?, ?, ?, ?)",
+			eventID,
+			link.URI,
+			link.Type,
+			link.Text,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert event link %s because %s", link.URI, err.Error())
+		}
+	}
 	return nil
 }
 
+func (s *Store) FindEventLinks(eventID int64) ([]*common.Link, error) {
+	q := s.DB.
+		Select("link", "link_type", "link_description").
+		From("event_extra").
+		Where("event_id = ?", eventID)
+
+	links := make([]*common.Link, 0)
+	if _, err := q.Load(&links); err != nil && err != dbr.ErrNotFound {
+		return nil, err
+	}
+	return links, nil
+}
+
 func (s *Store) StoreEventTags(tr *dbr.Tx, eventID int64, tags []string) error {
 	//handle tags
 	if _, err := tr.Exec("DELETE FROM event_tag WHERE event_id = ?", eventID); err != nil {
 		return fmt.Errorf("failed to clear existing tags due to error: %s", err.Error())
 	}
 
-	for _, tag := range tags {
-
-		var tagId int64
-		tag = strings.ToLower(tag)
+	for _, t := range tag.NormalizeAll(tags) {
 
-		err := s.DB.QueryRow("SELECT id FROM tag WHERE tag = ?", tag).Scan(&tagId)
-		if err != nil && err != sql.ErrNoRows {
-			return fmt.Errorf("failed to find tag id for %s because %s", tag, err.Error())
-		}
-		if tagId == 0 {
-			res, err := tr.Exec("INSERT OR IGNORE INTO tag (tag) VALUES (?)", tag)
-			if err != nil {
-				return fmt.Errorf("failed to insert tag %s because %s", tag, err.Error())
-			}
-			tagId, err = res.LastInsertId()
-			if err != nil {
-				return fmt.Errorf("failed to get inserted tag id because %s", err.Error())
-			}
+		tagId, err := tag.MustExist(tr, t)
+		if err != nil {
+			return err
 		}
 
 		if _, err := tr.Exec("INSERT OR IGNORE INTO event_tag (event_id, tag_id) VALUES (?, ?)", eventID, tagId); err != nil {
-			return fmt.Errorf("failed to insert event_tag relationship (event: %d, tag: %s, tagId: %d) because %s", eventID, tag, tagId, err.Error())
+			return fmt.Errorf("failed to insert event_tag relationship (event: %d, tag: %s, tagId: %d) because %s", eventID, t, tagId, err.Error())
 		}
 	}
 	return nil
@@ -232,21 +400,49 @@ func (s *Store) FindEventTags(eventID int64) ([]string, error) {
 	return tags, nil
 }
 
-func (s *Store) FindEventTypes() ([]string, error) {
+// FindEventTypes lists the canonical categories of current events with the number of events in each.
+func (s *Store) FindEventTypes() ([]*common.EventCategoryCount, error) {
 	q := s.DB.
-		Select("event.type").
+		Select("coalesce(event.category, 'other') AS type", "COUNT(*) AS events").
 		From("event").
 		Where("event.deleted = 0").
-		GroupBy("event.type").
-		OrderDir("SUM(1)", false)
+		GroupBy("coalesce(event.category, 'other')").
+		OrderDir("events", false)
 
-	types := make([]string, 0)
+	types := make([]*common.EventCategoryCount, 0)
 	if _, err := q.Load(&types); err != nil && err != dbr.ErrNotFound {
 		return nil, err
 	}
 	return types, nil
 }
 
+// ClassifyEvents sets the category of any events that don't have one yet. Returns the number of events updated.
+func (s *Store) ClassifyEvents() (int64, error) {
+
+	res, err := s.DB.Query("SELECT id, coalesce(type, ''), coalesce(description, '') FROM event WHERE category IS NULL")
+	if err != nil {
+		return 0, fmt.Errorf("failed to find unclassified events because %s", err.Error())
+	}
+	events := make([]*common.Event, 0)
+	for res.Next() {
+		e := &common.Event{}
+		if err := res.Scan(&e.ID, &e.Type, &e.Description); err != nil {
+			res.Close()
+			return 0, err
+		}
+		events = append(events, e)
+	}
+	res.Close()
+
+	for _, e := range events {
+		e.GuessCategory()
+		if _, err := s.DB.Update("event").Set("category", e.Category).Where("id = ?", e.ID).Exec(); err != nil {
+			return 0, fmt.Errorf("failed to classify event %d because %s", e.ID, err.Error())
+		}
+	}
+	return int64(len(events)), nil
+}
+
 func (s *Store) FindSimilarEventIDs(eventID int64) ([]int64, error) {
 
 	similarEvents := []int64{}
@@ -293,16 +489,40 @@ func (s *Store) FindEvents(filter *Filter) ([]*common.Event, error) {
 		"event.id",
 		"event.date",
 		"event.type",
+		"coalesce(event.category, '')",
 		"event.description",
 		"coalesce(event.source, '')",
+		"coalesce(event.status, '')",
 		"coalesce(venue.id, 0)",
 		"venue.name",
 		"venue.address",
 		"coalesce(group_concat(event_performer.performer_id), '')",
+		"event.end_date",
+		"event.doors",
+		"event.price_min",
+		"event.price_max",
+		"coalesce(event.currency, '')",
+		"coalesce(event.is_free, 0)",
+		"coalesce(event.donation_based, 0)",
+		"coalesce(event.min_age, 0)",
+		"coalesce(festival.id, 0)",
+		"coalesce(festival.name, '')",
+		"festival.start_date",
+		"festival.end_date",
+		"coalesce(event.series_id, 0)",
 	)
 	q.From("event")
 	q.LeftJoin("venue", "event.venue_id = venue.id")
-	q.LeftJoin("event_performer", "event.id = event_performer.event_id")
+	q.LeftJoin("festival", "event.festival_id = festival.id")
+	if filter.MinPerformerConfidence > 0 {
+		//performers found without an extractor (e.g. before extractors existed) are assumed to be correct
+		q.LeftJoin(
+			"event_performer",
+			fmt.Sprintf("event.id = event_performer.event_id AND coalesce(event_performer.confidence, 1) >= %f", filter.MinPerformerConfidence),
+		)
+	} else {
+		q.LeftJoin("event_performer", "event.id = event_performer.event_id")
+	}
 	q.OrderBy("event.date").OrderBy("event.id").OrderBy("venue.id")
 	q.GroupBy("event.id")
 	q.Limit(uint64(filter.PageSize))
@@ -315,25 +535,53 @@ func (s *Store) FindEvents(filter *Filter) ([]*common.Event, error) {
 		q.Where("event.id IN ?", filter.IDs)
 	}
 	if len(filter.Types) > 0 {
-		q.Where("event.type IN ?", filter.Types)
+		q.Where("event.category IN ?", filter.Types)
 	}
 	if len(filter.VenueIDs) > 0 {
 		q.Where("venue.id IN ?", filter.VenueIDs)
 	}
+	//multi-day events match if they overlap the range at all
 	if !filter.DateFrom.IsZero() {
-		q.Where("event.date >= ?", filter.DateFrom)
+		q.Where("coalesce(event.end_date, event.date) >= ?", filter.DateFrom)
 	}
 	if !filter.DateTo.IsZero() {
 		q.Where("event.date < ?", filter.DateTo)
 	}
+	if filter.FestivalID != 0 {
+		q.Where("event.festival_id = ?", filter.FestivalID)
+	}
+	if filter.SeriesID != 0 {
+		q.Where("event.series_id = ?", filter.SeriesID)
+	}
+	if filter.ExcludeRecurring {
+		q.Where("event.series_id IS NULL")
+	}
 	if filter.Source != "" {
 		q.Where("event.source = ?", filter.Source)
 	}
+	if len(filter.Statuses) > 0 {
+		q.Where("event.status IN ?", filter.Statuses)
+	}
 	q.Where("event.deleted = ?", common.IfOrInt(filter.ShowDeleted, 1, 0))
+	if filter.Free {
+		q.Where("event.is_free = 1")
+	}
+	if filter.MaxPrice > 0 {
+		q.Where("(event.is_free = 1 OR event.donation_based = 1 OR event.price_min <= ?)", filter.MaxPrice)
+	}
 
 	if len(filter.Tags) > 0 {
-		q.LeftJoin("event_tag", "event.id = event_tag.event_id")
-		q.Where("event_tag.tag_id IN ?", filter.Tags)
+		//tags include all their descendants and match the tags of the event or any of its performers
+		q.Where(
+			`event.id IN (
+				WITH RECURSIVE sub(id) AS (SELECT id FROM tag WHERE id IN ? UNION SELECT t.id FROM tag t JOIN sub ON t.parent_id = sub.id)
+				SELECT event_id FROM event_tag WHERE tag_id IN (SELECT id FROM sub)
+				UNION
+				SELECT ep.event_id FROM event_performer ep JOIN performer_tag pt ON ep.performer_id = pt.performer_id
+				WHERE pt.tag_id IN (SELECT id FROM sub)
+			)`,
+			filter.Tags,
+		)
 	}
 
 	if len(filter.UTags) > 0 {
@@ -363,10 +611,22 @@ func (s *Store) FindEvents(filter *Filter) ([]*common.Event, error) {
 		}
 
 		var eID, vID int
-		var eType, eDescription, eSource, vName, vAddress, pIDs string
+		var eType, eCategory, eDescription, eSource, eStatus, vName, vAddress, pIDs, eCurrency string
 		var eDate time.Time
-
-		err := result.Scan(&eID, &eDate, &eType, &eDescription, &eSource, &vID, &vName, &vAddress, &pIDs)
+		var eEndDate, eDoors *time.Time
+		var ePriceMin, ePriceMax *float64
+		var eFree, eDonation bool
+		var eMinAge int
+		var fID int64
+		var fName string
+		var fStartDate, fEndDate *time.Time
+		var sID int64
+
+		err := result.Scan(
+			&eID, &eDate, &eType, &eCategory, &eDescription, &eSource, &eStatus, &vID, &vName, &vAddress, &pIDs,
+			&eEndDate, &eDoors, &ePriceMin, &ePriceMax, &eCurrency, &eFree, &eDonation, &eMinAge,
+			&fID, &fName, &fStartDate, &fEndDate, &sID,
+		)
 		if err != nil {
 			return nil, err
 		}
@@ -383,13 +643,27 @@ func (s *Store) FindEvents(filter *Filter) ([]*common.Event, error) {
 				ID:          int64(eID),
 				Date:        eDate,
 				Type:        eType,
+				Category:    eCategory,
 				Description: eDescription,
 				Venue: &common.Venue{
 					ID:      int64(vID),
 					Name:    vName,
 					Address: vAddress,
 				},
-				Source: eSource,
+				Source:        eSource,
+				Status:        eStatus,
+				EndDate:       eEndDate,
+				Doors:         eDoors,
+				PriceMin:      ePriceMin,
+				PriceMax:      ePriceMax,
+				Currency:      eCurrency,
+				IsFree:        eFree,
+				DonationBased: eDonation,
+				MinAge:        eMinAge,
+				SeriesID:      sID,
+			}
+			if fID != 0 && fStartDate != nil && fEndDate != nil {
+				curEvent.Festival = &common.Festival{ID: fID, Name: fName, StartDate: *fStartDate, EndDate: *fEndDate}
 			}
 
 			//append the performers
@@ -399,6 +673,9 @@ func (s *Store) FindEvents(filter *Filter) ([]*common.Event, error) {
 				if curEvent.Performers, err = s.PerformerStore.FindPerformers(pf); err != nil {
 					return nil, err
 				}
+				if err := s.loadPerformerExtraction(curEvent); err != nil {
+					return nil, err
+				}
 			}
 
 			//append the tags
@@ -406,6 +683,16 @@ func (s *Store) FindEvents(filter *Filter) ([]*common.Event, error) {
 				return nil, err
 			}
 
+			//and links
+			if curEvent.Links, err = s.FindEventLinks(curEvent.ID); err != nil {
+				return nil, err
+			}
+
+			//and where it came from
+			if curEvent.Sources, err = s.FindEventSources(curEvent.ID); err != nil {
+				return nil, err
+			}
+
 		}
 	}
 
@@ -414,3 +701,17 @@ func (s *Store) FindEvents(filter *Filter) ([]*common.Event, error) {
 	}
 	return events, nil
 }
+
+func nullIfNoTime(t *time.Time) interface{} {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return t.Format(common.DateFormatSQL)
+}
+
+func nullIfZero(i int) interface{} {
+	if i == 0 {
+		return nil
+	}
+	return i
+}
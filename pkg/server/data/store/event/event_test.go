@@ -0,0 +1,83 @@
+package event
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"github.com/warmans/fakt-api/pkg/server/data/store/performer"
+	"github.com/warmans/fakt-api/pkg/server/data/store/storetest"
+	"github.com/warmans/fakt-api/pkg/server/data/store/tag"
+	"go.uber.org/zap"
+)
+
+func TestFindEventsByTag(t *testing.T) {
+
+	db, cleanup := storetest.MustOpenDB(t)
+	defer cleanup()
+
+	performerStore := &performer.Store{DB: db.NewSession(nil), Logger: zap.NewNop()}
+	store := &Store{DB: db.NewSession(nil), PerformerStore: performerStore}
+
+	date := time.Now().Truncate(time.Hour*24).AddDate(0, 0, 7).Add(time.Hour * 20)
+	venue := &common.Venue{ID: 1, Name: "K9"}
+	events := []*common.Event{
+		{Date: date, Venue: venue, Type: "Konzert", Tags: []string{"punk rock"}},
+		{Date: date.Add(time.Hour), Venue: venue, Type: "Konzert", Performers: []*common.Performer{{Name: "Kotzreiz", Genre: "Punk", Tags: []string{"hardcore"}}}},
+		{Date: date.Add(time.Hour * 2), Venue: venue, Type: "Konzert", Tags: []string{"jazz"}, Performers: []*common.Performer{{Name: "Jazzkantine", Genre: "Jazz", Tags: []string{"jazz"}}}},
+		{Date: date.Add(time.Hour * 3), Venue: venue, Type: "Konzert", Tags: []string{"punk"}, Performers: []*common.Performer{{Name: "Zaunpfahl", Genre: "Punk", Tags: []string{"punk"}}}},
+	}
+
+	tx, err := db.NewSession(nil).Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("INSERT INTO venue (id, name, address) VALUES (1, 'K9', 'Kinzigstr. 9')"); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range events {
+		for _, p := range e.Performers {
+			if err := performerStore.PerformerMustExist(tx, p); err != nil {
+				t.Fatalf("failed to store performer: %s", err.Error())
+			}
+		}
+		if _, err := store.EventMustExist(tx, e); err != nil {
+			t.Fatalf("failed to store event: %s", err.Error())
+		}
+	}
+	tagIDs := make(map[string]int64)
+	for _, name := range []string{"punk", "hardcore", "jazz"} {
+		if tagIDs[name], err = tag.MustExist(tx, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	examples := []struct {
+		Tag      string
+		Expected []int64
+	}{
+		{Tag: "punk", Expected: []int64{events[0].ID, events[1].ID, events[3].ID}},
+		{Tag: "hardcore", Expected: []int64{events[1].ID}},
+		{Tag: "jazz", Expected: []int64{events[2].ID}},
+	}
+
+	for _, ex := range examples {
+		f := &Filter{Tags: []string{fmt.Sprintf("%d", tagIDs[ex.Tag])}}
+		f.PageSize = 100
+		found, err := store.FindEvents(f)
+		if err != nil {
+			t.Fatalf("%s: failed to find events: %s", ex.Tag, err.Error())
+		}
+		actual := make([]int64, 0, len(found))
+		for _, e := range found {
+			actual = append(actual, e.ID)
+		}
+		if fmt.Sprint(actual) != fmt.Sprint(ex.Expected) {
+			t.Errorf("%s: expected events %v got %v", ex.Tag, ex.Expected, actual)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package event
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+// loadPerformerExtraction sets how each of the event's performers was found in the listing.
+func (s *Store) loadPerformerExtraction(event *common.Event) error {
+
+	res, err := s.DB.Query("SELECT performer_id, extractor, confidence FROM event_performer WHERE event_id=?", event.ID)
+	if err != nil {
+		return fmt.Errorf("failed to find performer extraction because %s", err.Error())
+	}
+	defer res.Close()
+
+	byPerformer := make(map[int64]*common.Performer, len(event.Performers))
+	for _, p := range event.Performers {
+		byPerformer[p.ID] = p
+	}
+
+	for res.Next() {
+		var performerID int64
+		var extractor sql.NullString
+		var confidence sql.NullFloat64
+		if err := res.Scan(&performerID, &extractor, &confidence); err != nil {
+			return err
+		}
+		if p, ok := byPerformer[performerID]; ok {
+			p.Extractor = extractor.String
+			p.Confidence = confidence.Float64
+		}
+	}
+	return res.Err()
+}
+
+// performerConfidence is NULL for performers that weren't extracted from the listing text.
+func performerConfidence(p *common.Performer) interface{} {
+	if p.Extractor == "" {
+		return nil
+	}
+	return p.Confidence
+}
+
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,206 @@
+package event
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/warmans/dbr"
+	"github.com/warmans/dbr/dialect"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+// festivalMatchWindow is how far apart two listings of a festival with the same name can be and still be
+// treated as the same festival. Sources often only list some of the days so the periods don't always overlap.
+const festivalMatchWindow = time.Hour * 24 * 7
+
+// maxFestivalEvents limits the number of events loaded with a festival.
+const maxFestivalEvents = 500
+
+var ErrFestivalNotFound = errors.New("festival not found")
+
+func FestivalFilterFromRequest(r *http.Request) *FestivalFilter {
+	f := &FestivalFilter{}
+	f.Populate(r)
+	return f
+}
+
+type FestivalFilter struct {
+	common.Filter
+
+	DateFrom time.Time `json:"from_date"`
+	DateTo   time.Time `json:"to_date"`
+}
+
+func (f *FestivalFilter) Populate(r *http.Request) {
+
+	f.Filter.Populate(r)
+
+	if from := r.Form.Get("from"); from != "" {
+		if dateFrom, err := time.Parse("2006-01-02", from); err == nil {
+			f.DateFrom = dateFrom
+		}
+	}
+	if to := r.Form.Get("to"); to != "" {
+		if dateTo, err := time.Parse("2006-01-02", to); err == nil {
+			f.DateTo = dateTo
+		}
+	}
+	if dateRelative := r.Form.Get("date_relative"); dateRelative != "" {
+		f.DateFrom, f.DateTo = common.GetRelativeDateRange(dateRelative)
+	}
+}
+
+// FestivalMustExist finds or creates the festival and sets its ID. An existing festival is one with the same
+// name running within festivalMatchWindow of the given dates. Its dates are extended to cover the new dates.
+// ErrFestivalNotFound is returned if the festival already has an ID that doesn't exist.
+func (s *Store) FestivalMustExist(tr *dbr.Tx, festival *common.Festival) error {
+
+	if !festival.IsValid() {
+		return fmt.Errorf("invalid festival was rejected: %+v", festival)
+	}
+	if festival.EndDate.Before(festival.StartDate) {
+		festival.EndDate = festival.StartDate
+	}
+
+	if festival.ID == 0 {
+		err := tr.QueryRow(
+			"SELECT id FROM festival WHERE lower(name) = lower(?) AND start_date <= ? AND end_date >= ? ORDER BY start_date LIMIT 1",
+			festival.Name,
+			festival.EndDate.Add(festivalMatchWindow).Format(common.DateFormatSQL),
+			festival.StartDate.Add(-festivalMatchWindow).Format(common.DateFormatSQL),
+		).Scan(&festival.ID)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to find festival because %s", err.Error())
+		}
+	}
+
+	if festival.ID == 0 {
+		res, err := tr.Exec(
+			"INSERT INTO festival (name, description, start_date, end_date) VALUES (?, ?, ?, ?)",
+			festival.Name,
+			nullIfEmpty(festival.Description),
+			festival.StartDate.Format(common.DateFormatSQL),
+			festival.EndDate.Format(common.DateFormatSQL),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert festival because %s", err.Error())
+		}
+		festival.ID, err = res.LastInsertId()
+		return err
+	}
+
+	res, err := tr.Exec(
+		`UPDATE festival SET
+			description=coalesce(?, description), start_date=min(start_date, ?), end_date=max(end_date, ?)
+			WHERE id=?`,
+		nullIfEmpty(festival.Description),
+		festival.StartDate.Format(common.DateFormatSQL),
+		festival.EndDate.Format(common.DateFormatSQL),
+		festival.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update festival because %s", err.Error())
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrFestivalNotFound
+	}
+	return nil
+}
+
+// eventFestivalID stores the event's festival and returns its ID or nil if the event isn't part of one. Festival
+// dates default to the event's dates.
+func (s *Store) eventFestivalID(tr *dbr.Tx, event *common.Event) (interface{}, error) {
+	if event.Festival == nil || event.Festival.Name == "" {
+		return nil, nil
+	}
+	if event.Festival.StartDate.IsZero() {
+		event.Festival.StartDate = event.Date
+	}
+	if event.Festival.EndDate.IsZero() {
+		event.Festival.EndDate = event.LastDate()
+	}
+	if err := s.FestivalMustExist(tr, event.Festival); err != nil {
+		return nil, err
+	}
+	return event.Festival.ID, nil
+}
+
+// FindFestivals lists festivals without their events. Date filters match festivals running at any point in
+// the range.
+func (s *Store) FindFestivals(filter *FestivalFilter) ([]*common.Festival, error) {
+
+	page := filter.Page
+	if page == 0 {
+		page = 1
+	}
+
+	q := s.DB.Select("id", "name", "coalesce(description, '')", "start_date", "end_date")
+	q.From("festival")
+	q.OrderBy("start_date").OrderBy("id")
+	q.Limit(uint64(filter.PageSize))
+
+	if filter.PageSize != 0 {
+		q.Limit(uint64(filter.PageSize)).Offset(uint64((filter.PageSize * page) - filter.PageSize))
+	}
+	if len(filter.IDs) > 0 {
+		q.Where("id IN ?", filter.IDs)
+	}
+	if !filter.DateFrom.IsZero() {
+		q.Where("end_date >= ?", filter.DateFrom)
+	}
+	if !filter.DateTo.IsZero() {
+		q.Where("start_date < ?", filter.DateTo)
+	}
+
+	sqlString, vals := q.ToSql()
+	interpolated, err := dbr.InterpolateForDialect(sqlString, vals, dialect.SQLite3)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.DB.Query(interpolated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find festivals because %s", err.Error())
+	}
+	defer res.Close()
+
+	festivals := make([]*common.Festival, 0)
+	for res.Next() {
+		f := &common.Festival{}
+		if err := res.Scan(&f.ID, &f.Name, &f.Description, &f.StartDate, &f.EndDate); err != nil {
+			return nil, err
+		}
+		festivals = append(festivals, f)
+	}
+	return festivals, res.Err()
+}
+
+// FindFestival returns the festival with all its events.
+func (s *Store) FindFestival(id int64) (*common.Festival, error) {
+
+	f := &FestivalFilter{}
+	f.IDs = []int64{id}
+	f.PageSize = 1
+
+	festivals, err := s.FindFestivals(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(festivals) == 0 {
+		return nil, ErrFestivalNotFound
+	}
+
+	ef := &Filter{FestivalID: id}
+	ef.PageSize = maxFestivalEvents
+	if festivals[0].Events, err = s.FindEvents(ef); err != nil {
+		return nil, err
+	}
+	return festivals[0], nil
+}
@@ -0,0 +1,81 @@
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"github.com/warmans/fakt-api/pkg/server/data/store/storetest"
+)
+
+func TestFestivalMustExist(t *testing.T) {
+
+	db, cleanup := storetest.MustOpenDB(t)
+	defer cleanup()
+
+	store := &Store{DB: db.NewSession(nil)}
+	tx, err := db.NewSession(nil).Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	start := time.Date(2018, 8, 10, 0, 0, 0, 0, time.UTC)
+	fusion := &common.Festival{Name: "Fusion", StartDate: start, EndDate: start.AddDate(0, 0, 2)}
+	if err := store.FestivalMustExist(tx, fusion); err != nil {
+		t.Fatalf("failed to create festival: %s", err.Error())
+	}
+
+	examples := []struct {
+		Name     string
+		Festival *common.Festival
+		Err      error
+		Existing bool
+	}{
+		{
+			Name:     "another day of the same festival",
+			Festival: &common.Festival{Name: "fusion", StartDate: start.AddDate(0, 0, 3), EndDate: start.AddDate(0, 0, 3)},
+			Existing: true,
+		},
+		{
+			Name:     "same festival next year",
+			Festival: &common.Festival{Name: "Fusion", StartDate: start.AddDate(1, 0, 0), EndDate: start.AddDate(1, 0, 2)},
+		},
+		{
+			Name:     "different festival",
+			Festival: &common.Festival{Name: "Monis Rache", StartDate: start, EndDate: start.AddDate(0, 0, 2)},
+		},
+		{
+			Name:     "known ID",
+			Festival: &common.Festival{ID: fusion.ID, Name: "Fusion", StartDate: start, EndDate: start},
+			Existing: true,
+		},
+		{
+			Name:     "unknown ID",
+			Festival: &common.Festival{ID: fusion.ID + 100, Name: "Fusion", StartDate: start, EndDate: start},
+			Err:      ErrFestivalNotFound,
+		},
+	}
+
+	for _, ex := range examples {
+		err := store.FestivalMustExist(tx, ex.Festival)
+		if err != ex.Err {
+			t.Errorf("%s: expected error %v got %v", ex.Name, ex.Err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if existing := ex.Festival.ID == fusion.ID; existing != ex.Existing {
+			t.Errorf("%s: expected existing festival %v got festival %d", ex.Name, ex.Existing, ex.Festival.ID)
+		}
+	}
+
+	var endDate time.Time
+	if err := tx.QueryRow("SELECT end_date FROM festival WHERE id=?", fusion.ID).Scan(&endDate); err != nil {
+		t.Fatal(err)
+	}
+	if !endDate.Equal(start.AddDate(0, 0, 3)) {
+		t.Errorf("expected festival to be extended to %s got %s", start.AddDate(0, 0, 3), endDate)
+	}
+}
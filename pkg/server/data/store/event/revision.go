@@ -0,0 +1,111 @@
+package event
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/warmans/dbr"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+// StoreEventRevision compares the event to the stored version and records any differences. It must be
+// called before the stored version is updated. changed is true if a revision was recorded.
+func (s *Store) StoreEventRevision(tr *dbr.Tx, event *common.Event) (changed bool, err error) {
+	stored, err := s.findRevisionFields(tr, event.ID)
+	if err != nil || stored == nil {
+		return false, err
+	}
+	return s.storeRevision(tr, event.ID, event.Source, common.DiffEvents(stored, event))
+}
+
+// findRevisionFields loads the fields of a stored event that revisions compare or nil if there is no such event.
+func (s *Store) findRevisionFields(tr *dbr.Tx, eventID int64) (*common.Event, error) {
+
+	stored := &common.Event{ID: eventID}
+	err := tr.QueryRow(
+		"SELECT date, coalesce(type, ''), coalesce(description, ''), coalesce(status, '') FROM event WHERE id=?",
+		eventID,
+	).Scan(&stored.Date, &stored.Type, &stored.Description, &stored.Status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load event %d for revision because %s", eventID, err.Error())
+	}
+
+	performers, err := tr.Query("SELECT p.id, p.name FROM event_performer ep JOIN performer p ON ep.performer_id = p.id WHERE ep.event_id=?", eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load event performers for revision because %s", err.Error())
+	}
+	defer performers.Close()
+	for performers.Next() {
+		p := &common.Performer{}
+		if err := performers.Scan(&p.ID, &p.Name); err != nil {
+			return nil, err
+		}
+		stored.Performers = append(stored.Performers, p)
+	}
+
+	tags, err := tr.Query("SELECT t.tag FROM event_tag et JOIN tag t ON et.tag_id = t.id WHERE et.event_id=?", eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load event tags for revision because %s", err.Error())
+	}
+	defer tags.Close()
+	for tags.Next() {
+		var tag string
+		if err := tags.Scan(&tag); err != nil {
+			return nil, err
+		}
+		stored.Tags = append(stored.Tags, tag)
+	}
+	return stored, tags.Err()
+}
+
+// storeRevision records the changes (if any) made to an event by the source. It returns true if there were any.
+func (s *Store) storeRevision(tr *dbr.Tx, eventID int64, source string, changes []*common.FieldChange) (bool, error) {
+	if len(changes) == 0 {
+		return false, nil
+	}
+
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return false, err
+	}
+	_, err = tr.Exec(
+		"INSERT INTO event_revision (event_id, date, source, changes) VALUES (?, ?, ?, ?)",
+		eventID,
+		time.Now().Format(common.DateFormatSQL),
+		source,
+		string(encoded),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert event revision because %s", err.Error())
+	}
+	return true, nil
+}
+
+// FindEventRevisions returns the revisions of an event newest first.
+func (s *Store) FindEventRevisions(eventID int64) ([]*common.EventRevision, error) {
+
+	res, err := s.DB.Query("SELECT id, event_id, date, coalesce(source, ''), changes FROM event_revision WHERE event_id=? ORDER BY id DESC", eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find revisions for event %d because %s", eventID, err.Error())
+	}
+	defer res.Close()
+
+	revisions := make([]*common.EventRevision, 0)
+	for res.Next() {
+		rev := &common.EventRevision{}
+		var changes string
+		if err := res.Scan(&rev.ID, &rev.EventID, &rev.Date, &rev.Source, &changes); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(changes), &rev.Changes); err != nil {
+			return nil, fmt.Errorf("failed to decode revision %d because %s", rev.ID, err.Error())
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, res.Err()
+}
@@ -0,0 +1,326 @@
+package event
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/warmans/dbr"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+// StoreEventSource records that the source listed the event.
+func (s *Store) StoreEventSource(tr *dbr.Tx, eventID int64, source string, score float64) error {
+	now := time.Now().Format(common.DateFormatSQL)
+	_, err := tr.Exec(
+		"INSERT OR IGNORE INTO event_source (event_id, source, first_seen, last_seen, score) VALUES (?, ?, ?, ?, ?)",
+		eventID,
+		source,
+		now,
+		now,
+		score,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert event source because %s", err.Error())
+	}
+	_, err = tr.Exec("UPDATE event_source SET last_seen=?, score=? WHERE event_id=? AND source=?", now, score, eventID, source)
+	if err != nil {
+		return fmt.Errorf("failed to update event source because %s", err.Error())
+	}
+	return nil
+}
+
+func (s *Store) FindEventSources(eventID int64) ([]*common.EventSource, error) {
+	res, err := s.DB.Query("SELECT source, first_seen, last_seen, coalesce(score, 1) FROM event_source WHERE event_id=? ORDER BY first_seen", eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find sources for event %d because %s", eventID, err.Error())
+	}
+	defer res.Close()
+
+	sources := make([]*common.EventSource, 0)
+	for res.Next() {
+		src := &common.EventSource{}
+		if err := res.Scan(&src.Source, &src.FirstSeen, &src.LastSeen, &src.Score); err != nil {
+			return nil, err
+		}
+		sources = append(sources, src)
+	}
+	return sources, res.Err()
+}
+
+// FindEventCandidates returns events (with venue) that start within window of date. These are the events
+// that could be duplicates of an event at that date.
+func (s *Store) FindEventCandidates(tr *dbr.Tx, date time.Time, window time.Duration) ([]*common.Event, error) {
+
+	res, err := tr.Query(
+		`SELECT e.id, e.date, coalesce(e.type, ''), coalesce(e.description, ''), coalesce(e.source, ''), v.id, v.name, coalesce(v.address, '')
+		FROM event e
+		JOIN venue v ON e.venue_id = v.id
+		WHERE e.date >= ? AND e.date <= ? AND e.deleted = 0`,
+		date.Add(-window).Format(common.DateFormatSQL),
+		date.Add(window).Format(common.DateFormatSQL),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find candidate events because %s", err.Error())
+	}
+	defer res.Close()
+
+	events := make([]*common.Event, 0)
+	for res.Next() {
+		e := &common.Event{Venue: &common.Venue{}}
+		if err := res.Scan(&e.ID, &e.Date, &e.Type, &e.Description, &e.Source, &e.Venue.ID, &e.Venue.Name, &e.Venue.Address); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, res.Err()
+}
+
+// MergeEvent adds performers, tags and links from another source's listing to an existing event. Unlike
+// EventMustExist nothing is removed or overwritten so sources don't keep replacing each other's data. The
+// exception is the status since the other source may be the only one to list a cancellation (see
+// common.MergeStatus). The other source is recorded as the status_source so the event's own listing doesn't
+// replace it (see EventMustExist). Changes are recorded as a revision from the other source and changed is true
+// if there were any.
+func (s *Store) MergeEvent(tr *dbr.Tx, event *common.Event) (changed bool, err error) {
+
+	if event.ID == 0 {
+		return false, fmt.Errorf("cannot merge event without an ID")
+	}
+
+	stored, err := s.findRevisionFields(tr, event.ID)
+	if err != nil {
+		return false, err
+	}
+	if stored == nil {
+		return false, fmt.Errorf("cannot merge into missing event %d", event.ID)
+	}
+
+	status := common.MergeStatus(stored.Status, event.Status)
+	var statusSource interface{}
+	if status != stored.Status {
+		statusSource = event.Source
+	}
+
+	//details are only filled in if the first source didn't have them
+	festivalID, err := s.eventFestivalID(tr, event)
+	if err != nil {
+		return false, err
+	}
+	if _, err := tr.Exec(
+		`UPDATE event SET
+			status=?, status_source=coalesce(?, status_source), end_date=coalesce(end_date, ?), doors=coalesce(doors, ?), price_min=coalesce(price_min, ?),
+			price_max=coalesce(price_max, ?), currency=coalesce(currency, ?), is_free=max(coalesce(is_free, 0), ?),
+			donation_based=max(coalesce(donation_based, 0), ?), min_age=coalesce(min_age, ?),
+			festival_id=coalesce(festival_id, ?)
+			WHERE id=?`,
+		status,
+		statusSource,
+		nullIfNoTime(event.EndDate),
+		nullIfNoTime(event.Doors),
+		event.PriceMin,
+		event.PriceMax,
+		nullIfEmpty(event.Currency),
+		event.IsFree,
+		event.DonationBased,
+		nullIfZero(event.MinAge),
+		festivalID,
+		event.ID,
+	); err != nil {
+		return false, fmt.Errorf("failed to merge event details because %s", err.Error())
+	}
+
+	for _, perf := range event.Performers {
+		if perf.ID == 0 {
+			continue
+		}
+		_, err := tr.Exec(
+			"INSERT OR IGNORE INTO event_performer (event_id, performer_id, extractor, confidence) VALUES (?, ?, ?, ?)",
+			event.ID,
+			perf.ID,
+			nullIfEmpty(perf.Extractor),
+			performerConfidence(perf),
+		)
+		if err != nil {
+			return false, fmt.Errorf("failed to merge performer %d because %s", perf.ID, err.Error())
+		}
+	}
+
+	existingTags := make(map[string]bool)
+	for _, tag := range stored.Tags {
+		existingTags[tag] = true
+	}
+
+	tags := make([]string, 0, len(existingTags))
+	for tag := range existingTags {
+		tags = append(tags, tag)
+	}
+	for _, tag := range event.Tags {
+		if !existingTags[strings.ToLower(tag)] {
+			tags = append(tags, tag)
+		}
+	}
+	if err := s.StoreEventTags(tr, event.ID, tags); err != nil {
+		return false, err
+	}
+
+	for _, link := range event.Links {
+		var exists int
+		if err := tr.QueryRow("SELECT count(*) FROM event_extra WHERE event_id=? AND link=?", event.ID, link.URI).Scan(&exists); err != nil {
+			return false, err
+		}
+		if exists > 0 {
+			continue
+		}
+		_, err := tr.Exec(
+			"INSERT INTO event_extra (event_id, link, link_type, link_description) VALUES (?, ?, ?, ?)",
+			event.ID,
+			link.URI,
+			link.Type,
+			link.Text,
+		)
+		if err != nil {
+			return false, fmt.Errorf("failed to merge event link %s because %s", link.URI, err.Error())
+		}
+	}
+
+	merged, err := s.findRevisionFields(tr, event.ID)
+	if err != nil {
+		return false, err
+	}
+	return s.storeRevision(tr, event.ID, event.Source, common.DiffEvents(stored, merged))
+}
+
+// otherSourceStatus keeps a cancellation or postponement by another source (see MergeEvent) when the event's own
+// source lists it again, otherwise the sources would keep replacing each other's status. The event's status is
+// updated and the source of the status is returned (nil if it is the event's own).
+func (s *Store) otherSourceStatus(tr *dbr.Tx, event *common.Event) (interface{}, error) {
+
+	var status string
+	var statusSource sql.NullString
+	err := tr.QueryRow("SELECT coalesce(status, ''), status_source FROM event WHERE id=?", event.ID).Scan(&status, &statusSource)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load status of event %d because %s", event.ID, err.Error())
+	}
+	if !statusSource.Valid || statusSource.String == event.Source {
+		return nil, nil
+	}
+	if merged := common.MergeStatus(event.Status, status); merged != event.Status {
+		event.Status = merged
+		return statusSource.String, nil
+	}
+	return nil, nil
+}
+
+// MergeEvents merges the event fromID into intoID as if it was another source's listing (see MergeEvent) and
+// deletes it. Sources, revisions and quarantined records of the deleted event are moved to intoID.
+func (s *Store) MergeEvents(tr *dbr.Tx, fromID, intoID int64) error {
+
+	if fromID == intoID {
+		return fmt.Errorf("cannot merge event %d into itself", fromID)
+	}
+
+	from, err := s.findMergeFields(tr, fromID)
+	if err != nil {
+		return err
+	}
+	from.ID = intoID
+	if _, err := s.MergeEvent(tr, from); err != nil {
+		return err
+	}
+
+	for _, q := range []string{
+		"INSERT OR IGNORE INTO event_source (event_id, source, first_seen, last_seen, score) SELECT ?, source, first_seen, last_seen, score FROM event_source WHERE event_id=?",
+		"UPDATE event_revision SET event_id=? WHERE event_id=?",
+		"UPDATE quarantine SET event_id=? WHERE event_id=?",
+	} {
+		if _, err := tr.Exec(q, intoID, fromID); err != nil {
+			return fmt.Errorf("failed to merge event %d into %d because %s", fromID, intoID, err.Error())
+		}
+	}
+	for _, q := range []string{
+		"DELETE FROM event_performer WHERE event_id=?",
+		"DELETE FROM event_tag WHERE event_id=?",
+		"DELETE FROM event_extra WHERE event_id=?",
+		"DELETE FROM event_source WHERE event_id=?",
+		"DELETE FROM event WHERE id=?",
+	} {
+		if _, err := tr.Exec(q, fromID); err != nil {
+			return fmt.Errorf("failed to delete merged event %d because %s", fromID, err.Error())
+		}
+	}
+	return nil
+}
+
+// findMergeFields loads everything MergeEvent uses from a stored event.
+func (s *Store) findMergeFields(tr *dbr.Tx, eventID int64) (*common.Event, error) {
+
+	event := &common.Event{ID: eventID}
+	var fID int64
+	var fName string
+	var fStartDate, fEndDate *time.Time
+	err := tr.QueryRow(
+		`SELECT
+			coalesce(e.source, ''), coalesce(e.status, ''), e.end_date, e.doors, e.price_min, e.price_max,
+			coalesce(e.currency, ''), coalesce(e.is_free, 0), coalesce(e.donation_based, 0), coalesce(e.min_age, 0),
+			coalesce(f.id, 0), coalesce(f.name, ''), f.start_date, f.end_date
+		FROM event e
+		LEFT JOIN festival f ON e.festival_id = f.id
+		WHERE e.id=?`,
+		eventID,
+	).Scan(
+		&event.Source, &event.Status, &event.EndDate, &event.Doors, &event.PriceMin, &event.PriceMax,
+		&event.Currency, &event.IsFree, &event.DonationBased, &event.MinAge,
+		&fID, &fName, &fStartDate, &fEndDate,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load event %d for merge because %s", eventID, err.Error())
+	}
+	if fID != 0 && fStartDate != nil && fEndDate != nil {
+		event.Festival = &common.Festival{ID: fID, Name: fName, StartDate: *fStartDate, EndDate: *fEndDate}
+	}
+
+	performers, err := tr.Query("SELECT performer_id, coalesce(extractor, ''), coalesce(confidence, 0) FROM event_performer WHERE event_id=?", eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load event performers for merge because %s", err.Error())
+	}
+	defer performers.Close()
+	for performers.Next() {
+		p := &common.Performer{}
+		if err := performers.Scan(&p.ID, &p.Extractor, &p.Confidence); err != nil {
+			return nil, err
+		}
+		event.Performers = append(event.Performers, p)
+	}
+
+	tags, err := tr.Query("SELECT t.tag FROM event_tag et JOIN tag t ON et.tag_id = t.id WHERE et.event_id=?", eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load event tags for merge because %s", err.Error())
+	}
+	defer tags.Close()
+	for tags.Next() {
+		var tag string
+		if err := tags.Scan(&tag); err != nil {
+			return nil, err
+		}
+		event.Tags = append(event.Tags, tag)
+	}
+
+	links, err := tr.Query("SELECT link, coalesce(link_type, ''), coalesce(link_description, '') FROM event_extra WHERE event_id=?", eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load event links for merge because %s", err.Error())
+	}
+	defer links.Close()
+	for links.Next() {
+		l := &common.Link{}
+		if err := links.Scan(&l.URI, &l.Type, &l.Text); err != nil {
+			return nil, err
+		}
+		event.Links = append(event.Links, l)
+	}
+	return event, links.Err()
+}
@@ -4,10 +4,10 @@ import (
 	"database/sql"
 	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/warmans/dbr"
 	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"github.com/warmans/fakt-api/pkg/server/data/store/tag"
 	"go.uber.org/zap"
 )
 
@@ -213,11 +213,15 @@ func (s *Store) PerformerMustExist(tr *dbr.Tx, performer *common.Performer) erro
 	}
 
 	if performer.ID == 0 {
-		//geg the performer based on their name and genre
-		err := tr.QueryRow("SELECT id FROM performer WHERE name=? AND genre=?", performer.Name, performer.Genre).Scan(&performer.ID)
-		if err != nil && err != sql.ErrNoRows {
+		//get the performer based on their name and what else is known about them
+		id, confidence, err := s.ResolvePerformer(tr, performer)
+		if err != nil {
 			return err
 		}
+		if id != 0 && confidence < 1 {
+			s.Logger.Debug("Resolved performer", zap.String("name", performer.Name), zap.Int64("id", id), zap.Float64("confidence", confidence))
+		}
+		performer.ID = id
 	}
 	if performer.ID == 0 {
 		res, err := tr.Exec(
@@ -236,9 +240,13 @@ func (s *Store) PerformerMustExist(tr *dbr.Tx, performer *common.Performer) erro
 		if err != nil {
 			return err
 		}
+		if err := s.StorePerformerAlias(tr, performer.ID, performer.Name); err != nil {
+			return err
+		}
 	} else {
+		//listings rarely have as much detail as the enriched performer so only fill in what is given
 		_, err := tr.Exec(
-			"UPDATE performer SET info=?, home=?, listen_url=? WHERE id=?",
+			"UPDATE performer SET info=coalesce(nullif(?, ''), info), home=coalesce(nullif(?, ''), home), listen_url=coalesce(nullif(?, ''), listen_url) WHERE id=?",
 			performer.Info,
 			performer.Home,
 			performer.ListenURL,
@@ -249,12 +257,16 @@ func (s *Store) PerformerMustExist(tr *dbr.Tx, performer *common.Performer) erro
 		}
 	}
 
-	//clear existing relationships for extra data to allow links to be kept up-to-date
-	_, err := tr.Exec("DELETE FROM performer_extra WHERE performer_id=?", performer.ID)
-	if err != nil {
-		return err
-	}
+	//the performer may have been resolved to an existing (e.g. enriched or merged) performer that the listing only
+	//knows part of so links and tags are only ever added
 	for _, link := range performer.Links {
+		var exists int
+		if err := tr.QueryRow("SELECT count(*) FROM performer_extra WHERE performer_id=? AND link=?", performer.ID, link.URI).Scan(&exists); err != nil {
+			return err
+		}
+		if exists > 0 {
+			continue
+		}
 		_, err := tr.Exec(
 			"INSERT INTO performer_extra (performer_id, link, link_type, link_description) VALUES (?, ?, ?, ?)",
 			performer.ID,
@@ -269,7 +281,7 @@ func (s *Store) PerformerMustExist(tr *dbr.Tx, performer *common.Performer) erro
 	}
 
 	//try and store additional entities but just log errors instead of failing for now
-	if err := s.StorePerformerTags(tr, performer.ID, performer.Tags); err != nil {
+	if err := s.AddPerformerTags(tr, performer.ID, performer.Tags); err != nil {
 		s.Logger.Error("Failed to store performer tags", zap.Error(err))
 	}
 	if err := s.StorePerformerImages(tr, performer.ID, performer.Images); err != nil {
@@ -278,36 +290,17 @@ func (s *Store) PerformerMustExist(tr *dbr.Tx, performer *common.Performer) erro
 	return nil
 }
 
-func (s *Store) StorePerformerTags(tr *dbr.Tx, performerID int64, tags []string) error {
-	//handle tags
-	if _, err := tr.Exec("DELETE FROM performer_tag WHERE performer_id = ?", performerID); err != nil {
-		s.Logger.Error(fmt.Sprintf("failed to delete existing performer_tag relationships (perfomer: %d)", performerID),zap.Error(err))
-	}
-
-	//todo: move this into new tag store
-	for _, tag := range tags {
+// AddPerformerTags adds the tags to the performer's existing tags.
+func (s *Store) AddPerformerTags(tr *dbr.Tx, performerID int64, tags []string) error {
+	for _, t := range tag.NormalizeAll(tags) {
 
-		var tagId int64
-		tag = strings.ToLower(tag)
-
-		err := s.DB.QueryRow("SELECT id FROM tag WHERE tag = ?", tag).Scan(&tagId)
-		if err != nil && err != sql.ErrNoRows {
-			return fmt.Errorf("failed to find tag id for %s because %s", tag, err.Error())
-		}
-		if tagId == 0 {
-			res, err := tr.Exec("INSERT OR IGNORE INTO tag (tag) VALUES (?)", tag)
-			if err != nil {
-				return fmt.Errorf("failed to insert tag %s because %s", tag, err.Error())
-			}
-			//todo: does this work with OR IGNORE?
-			tagId, err = res.LastInsertId()
-			if err != nil {
-				return fmt.Errorf("failed to get inserted tag id because %s", err.Error())
-			}
+		tagId, err := tag.MustExist(tr, t)
+		if err != nil {
+			return err
 		}
 
 		if _, err := tr.Exec("INSERT OR IGNORE INTO performer_tag (performer_id, tag_id) VALUES (?, ?)", performerID, tagId); err != nil {
-			return fmt.Errorf("failed to insert performer_tag relationship (perfomer: %d, tag: %s, tagId: %d) because %s", performerID, tag, tagId, err.Error())
+			return fmt.Errorf("failed to insert performer_tag relationship (perfomer: %d, tag: %s, tagId: %d) because %s", performerID, t, tagId, err.Error())
 		}
 	}
 	return nil
@@ -0,0 +1,64 @@
+package performer
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"github.com/warmans/fakt-api/pkg/server/data/store/storetest"
+	"go.uber.org/zap"
+)
+
+func TestPerformerMustExistKeepsTagsAndLinks(t *testing.T) {
+
+	db, cleanup := storetest.MustOpenDB(t)
+	defer cleanup()
+
+	store := &Store{DB: db.NewSession(nil), Logger: zap.NewNop()}
+
+	mustStore := func(p *common.Performer) int64 {
+		tx, err := store.DB.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.PerformerMustExist(tx, p); err != nil {
+			tx.Rollback()
+			t.Fatalf("failed to store performer: %s", err.Error())
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		return p.ID
+	}
+
+	//enriched performer (e.g. from Bandcamp)
+	id := mustStore(&common.Performer{
+		Name:  "Kotzreiz",
+		Genre: "Punk aus Leipzig",
+		Tags:  []string{"punk", "deutschpunk"},
+		Links: []*common.Link{{URI: "https://kotzreiz.bandcamp.com", Type: "bandcamp"}},
+	})
+
+	//a later listing that knows less
+	if listed := mustStore(&common.Performer{Name: "Kotzreiz", Genre: "Punk, Leipzig", Tags: []string{"punk", "leipzig"}}); listed != id {
+		t.Fatalf("expected listing to resolve to performer %d got %d", id, listed)
+	}
+
+	tags, err := store.FindPerformerTags(id)
+	if err != nil {
+		t.Fatalf("failed to find tags: %s", err.Error())
+	}
+	sort.Strings(tags)
+	if expected := []string{"deutschpunk", "leipzig", "punk"}; !reflect.DeepEqual(tags, expected) {
+		t.Errorf("expected tags %v got %v", expected, tags)
+	}
+
+	links, err := store.FindPerformerLinks(id)
+	if err != nil {
+		t.Fatalf("failed to find links: %s", err.Error())
+	}
+	if len(links) != 1 || links[0].URI != "https://kotzreiz.bandcamp.com" {
+		t.Errorf("expected the bandcamp link to be kept got %+v", links)
+	}
+}
@@ -0,0 +1,212 @@
+package performer
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/warmans/dbr"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+// MatchThreshold is the minimum confidence for a listed performer to be resolved to an existing one.
+const MatchThreshold = 0.75
+
+// ErrPerformerNotFound is returned when merging a performer that doesn't exist.
+var ErrPerformerNotFound = errors.New("performer not found")
+
+// queryer is satisfied by both the session and transactions so performers can be resolved either way.
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// ResolvePerformer finds the existing performer the listed one most likely refers to. An exact name and genre
+// match is certain, otherwise performers with the same normalized name (or an alias of it) are scored with
+// ScorePerformerMatch. Returns 0 if nothing is at least MatchThreshold.
+func (s *Store) ResolvePerformer(q queryer, performer *common.Performer) (int64, float64, error) {
+
+	candidates, err := s.findPerformerCandidates(q, performer.Name)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var bestID int64
+	bestScore := 0.0
+	for _, candidate := range candidates {
+		if candidate.Name == performer.Name && candidate.Genre == performer.Genre {
+			return candidate.ID, 1, nil
+		}
+		if score := ScorePerformerMatch(performer, candidate); score >= MatchThreshold && score > bestScore {
+			bestID, bestScore = candidate.ID, score
+		}
+	}
+	return bestID, bestScore, nil
+}
+
+// ScorePerformerMatch estimates how likely it is (0-1) that two performers with the same name are the same act
+// based on where they're from (including the genre text since listings often mix the two e.g. "Punk aus
+// Leipzig") and their tags. Sharing a name is enough for a match unless what's known about them differs.
+func ScorePerformerMatch(listed, existing *common.Performer) float64 {
+
+	homeScore := 0.75
+	listedHome := strings.TrimSpace(listed.Home + " " + listed.Genre)
+	existingHome := strings.TrimSpace(existing.Home + " " + existing.Genre)
+	if listedHome != "" && existingHome != "" {
+		homeScore = common.TokenOverlap(listedHome, existingHome)
+	}
+
+	tagScore := 0.5
+	if len(listed.Tags) > 0 && len(existing.Tags) > 0 {
+		tagScore = common.TokenOverlap(strings.Join(listed.Tags, " "), strings.Join(existing.Tags, " "))
+	}
+
+	return 0.4 + 0.4*homeScore + 0.2*tagScore
+}
+
+// StorePerformerAlias records the normalized name as referring to the performer.
+func (s *Store) StorePerformerAlias(tr *dbr.Tx, performerID int64, name string) error {
+	alias := common.NormalizeName(name)
+	if alias == "" {
+		return nil
+	}
+	if _, err := tr.Exec("INSERT OR IGNORE INTO performer_alias (alias, performer_id) VALUES (?, ?)", alias, performerID); err != nil {
+		return fmt.Errorf("failed to store performer alias %s because %s", alias, err.Error())
+	}
+	return nil
+}
+
+// MergePerformers moves the events, tags, links and images of fromID onto intoID and deletes it. Any details
+// missing from intoID are taken from fromID and its name becomes an alias of intoID.
+func (s *Store) MergePerformers(fromID, intoID int64) error {
+
+	if fromID == intoID {
+		return fmt.Errorf("cannot merge performer %d into itself", fromID)
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = func(tr *dbr.Tx) error {
+		var fromName string
+		if err := tr.QueryRow("SELECT name FROM performer WHERE id=?", fromID).Scan(&fromName); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrPerformerNotFound
+			}
+			return err
+		}
+		var exists int
+		if err := tr.QueryRow("SELECT count(*) FROM performer WHERE id=?", intoID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			return ErrPerformerNotFound
+		}
+
+		//fill in anything the surviving performer is missing
+		_, err := tr.Exec(
+			`UPDATE performer SET
+				info = coalesce(nullif(info, ''), (SELECT info FROM performer WHERE id=?1)),
+				home = coalesce(nullif(home, ''), (SELECT home FROM performer WHERE id=?1)),
+				listen_url = coalesce(nullif(listen_url, ''), (SELECT listen_url FROM performer WHERE id=?1)),
+				embed_url = coalesce(nullif(embed_url, ''), (SELECT embed_url FROM performer WHERE id=?1))
+			WHERE id=?2`,
+			fromID,
+			intoID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to merge performer %d details because %s", fromID, err.Error())
+		}
+
+		//relationships that would clash are already on the surviving performer so can be dropped
+		for _, table := range []string{"event_performer", "performer_tag", "performer_image", "performer_alias"} {
+			if _, err := tr.Exec(fmt.Sprintf("UPDATE OR IGNORE %s SET performer_id=? WHERE performer_id=?", table), intoID, fromID); err != nil {
+				return fmt.Errorf("failed to merge performer %d %s because %s", fromID, table, err.Error())
+			}
+			if _, err := tr.Exec(fmt.Sprintf("DELETE FROM %s WHERE performer_id=?", table), fromID); err != nil {
+				return fmt.Errorf("failed to merge performer %d %s because %s", fromID, table, err.Error())
+			}
+		}
+
+		//links have no constraint so drop duplicates explicitly
+		_, err = tr.Exec(
+			"DELETE FROM performer_extra WHERE performer_id=? AND link IN (SELECT link FROM performer_extra WHERE performer_id=?)",
+			fromID,
+			intoID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to merge performer %d links because %s", fromID, err.Error())
+		}
+		if _, err := tr.Exec("UPDATE performer_extra SET performer_id=? WHERE performer_id=?", intoID, fromID); err != nil {
+			return fmt.Errorf("failed to merge performer %d links because %s", fromID, err.Error())
+		}
+
+		if _, err := tr.Exec("DELETE FROM performer WHERE id=?", fromID); err != nil {
+			return fmt.Errorf("failed to delete merged performer %d because %s", fromID, err.Error())
+		}
+		return s.StorePerformerAlias(tr, intoID, fromName)
+	}(tx)
+
+	if err != nil {
+		if txerr := tx.Rollback(); txerr != nil {
+			return fmt.Errorf("%s -> %s", err, txerr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Store) findPerformerCandidates(q queryer, name string) ([]*common.Performer, error) {
+
+	res, err := q.Query(
+		`SELECT id, name, coalesce(genre, ''), coalesce(home, '') FROM performer
+		WHERE lower(name) = lower(?) OR id IN (SELECT performer_id FROM performer_alias WHERE alias = ?)
+		ORDER BY id`,
+		name,
+		common.NormalizeName(name),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find performer candidates because %s", err.Error())
+	}
+
+	candidates := make([]*common.Performer, 0)
+	for res.Next() {
+		p := &common.Performer{}
+		if err := res.Scan(&p.ID, &p.Name, &p.Genre, &p.Home); err != nil {
+			res.Close()
+			return nil, err
+		}
+		candidates = append(candidates, p)
+	}
+	res.Close()
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, p := range candidates {
+		if p.Tags, err = s.findPerformerTags(q, p.ID); err != nil {
+			return nil, err
+		}
+	}
+	return candidates, nil
+}
+
+func (s *Store) findPerformerTags(q queryer, performerID int64) ([]string, error) {
+	res, err := q.Query("SELECT t.tag FROM performer_tag pt JOIN tag t ON pt.tag_id = t.id WHERE pt.performer_id = ?", performerID)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	tags := make([]string, 0)
+	for res.Next() {
+		var tag string
+		if err := res.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, res.Err()
+}
@@ -0,0 +1,31 @@
+package performer
+
+import (
+	"testing"
+
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+func TestScorePerformerMatch(t *testing.T) {
+
+	existing := &common.Performer{Name: "Kotzreiz", Genre: "Punk aus Leipzig", Tags: []string{"punk", "deutschpunk"}}
+
+	examples := []struct {
+		Name   string
+		Listed *common.Performer
+		Match  bool
+	}{
+		{Name: "nothing else known", Listed: &common.Performer{Name: "Kotzreiz"}, Match: true},
+		{Name: "genre reworded", Listed: &common.Performer{Name: "Kotzreiz", Genre: "Punk, Leipzig"}, Match: true},
+		{Name: "home moved out of genre", Listed: &common.Performer{Name: "Kotzreiz", Genre: "Punk", Home: "Leipzig"}, Match: true},
+		{Name: "different city", Listed: &common.Performer{Name: "Kotzreiz", Genre: "Punk aus Hamburg"}, Match: false},
+		{Name: "different everything", Listed: &common.Performer{Name: "Kotzreiz", Genre: "Jazz", Tags: []string{"jazz"}}, Match: false},
+	}
+
+	for _, ex := range examples {
+		score := ScorePerformerMatch(ex.Listed, existing)
+		if (score >= MatchThreshold) != ex.Match {
+			t.Errorf("%s: unexpected score %f", ex.Name, score)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package quarantine
+
+import (
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/warmans/dbr"
+	"github.com/warmans/dbr/dialect"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+// ErrRecordNotFound is returned when changing a record that doesn't exist.
+var ErrRecordNotFound = errors.New("quarantined record not found")
+
+func FilterFromRequest(r *http.Request) *Filter {
+	f := &Filter{}
+	f.Populate(r)
+	return f
+}
+
+type Filter struct {
+	common.Filter
+
+	Source string `json:"source"`
+	Type   string `json:"type"`
+}
+
+func (f *Filter) Populate(r *http.Request) {
+
+	f.Filter.Populate(r)
+
+	f.Source = r.Form.Get("source")
+	f.Type = r.Form.Get("type")
+}
+
+type Store struct {
+	DB *dbr.Session
+}
+
+// AddRecord quarantines a record. The ID and created time are set on the record. A source usually lists the same
+// broken record on every crawl so if an identical record (same source, type, payload and event) is already
+// quarantined only its reason and updated time are changed.
+func (s *Store) AddRecord(record *common.QuarantinedRecord) error {
+
+	hash := payloadHash(record.Payload)
+
+	err := s.DB.QueryRow(
+		`SELECT id, created FROM quarantine
+		WHERE source=? AND record_type=? AND payload_hash=? AND coalesce(event_id, 0)=?
+		ORDER BY id LIMIT 1`,
+		record.Source,
+		record.Type,
+		hash,
+		record.EventID,
+	).Scan(&record.ID, &record.Created)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to find quarantined %s because %s", record.Type, err.Error())
+	}
+	if err == nil {
+		return s.UpdateRecord(record)
+	}
+
+	record.Created = time.Now()
+	res, err := s.DB.Exec(
+		"INSERT INTO quarantine (source, record_type, payload, payload_hash, reason, event_id, created) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		record.Source,
+		record.Type,
+		string(record.Payload),
+		hash,
+		record.Reason,
+		nullIfZero(record.EventID),
+		record.Created.Format(common.DateFormatSQL),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to quarantine %s because %s", record.Type, err.Error())
+	}
+	if record.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get quarantined record id because %s", err.Error())
+	}
+	return nil
+}
+
+// UpdateRecord replaces the payload and reason of a record e.g. after it was edited or failed again.
+func (s *Store) UpdateRecord(record *common.QuarantinedRecord) error {
+	updated := time.Now()
+	res, err := s.DB.Exec(
+		"UPDATE quarantine SET payload=?, payload_hash=?, reason=?, updated=? WHERE id=?",
+		string(record.Payload),
+		payloadHash(record.Payload),
+		record.Reason,
+		updated.Format(common.DateFormatSQL),
+		record.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update quarantined record %d because %s", record.ID, err.Error())
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return ErrRecordNotFound
+	}
+	record.Updated = &updated
+	return nil
+}
+
+// DiscardRecord removes a record from quarantine.
+func (s *Store) DiscardRecord(id int64) error {
+	res, err := s.DB.Exec("DELETE FROM quarantine WHERE id=?", id)
+	if err != nil {
+		return fmt.Errorf("failed to discard quarantined record %d because %s", id, err.Error())
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// FindRecord returns the record with the ID or ErrRecordNotFound.
+func (s *Store) FindRecord(id int64) (*common.QuarantinedRecord, error) {
+	f := &Filter{}
+	f.IDs = []int64{id}
+	f.PageSize = 1
+	records, err := s.FindRecords(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, ErrRecordNotFound
+	}
+	return records[0], nil
+}
+
+// FindRecords returns records newest first.
+func (s *Store) FindRecords(filter *Filter) ([]*common.QuarantinedRecord, error) {
+
+	//if no page is specified assume the first page
+	page := filter.Page
+	if page == 0 {
+		page = 1
+	}
+
+	q := s.DB.Select(
+		"id",
+		"coalesce(source, '')",
+		"record_type",
+		"payload",
+		"coalesce(reason, '')",
+		"coalesce(event_id, 0)",
+		"created",
+		"updated",
+	)
+	q.From("quarantine")
+	q.OrderDir("created", false).OrderDir("id", false)
+
+	if filter.PageSize != 0 {
+		q.Limit(uint64(filter.PageSize)).Offset(uint64((filter.PageSize * page) - filter.PageSize))
+	}
+	if len(filter.IDs) > 0 {
+		q.Where("id IN ?", filter.IDs)
+	}
+	if filter.Source != "" {
+		q.Where("source = ?", filter.Source)
+	}
+	if filter.Type != "" {
+		q.Where("record_type = ?", filter.Type)
+	}
+
+	sqlString, vals := q.ToSql()
+	interpolated, err := dbr.InterpolateForDialect(sqlString, vals, dialect.SQLite3)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.DB.Query(interpolated)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer result.Close()
+
+	records := make([]*common.QuarantinedRecord, 0)
+	for result.Next() {
+		record := &common.QuarantinedRecord{}
+		var payload string
+		var updated *time.Time
+		err := result.Scan(
+			&record.ID,
+			&record.Source,
+			&record.Type,
+			&payload,
+			&record.Reason,
+			&record.EventID,
+			&record.Created,
+			&updated,
+		)
+		if err != nil {
+			return nil, err
+		}
+		record.Payload = []byte(payload)
+		record.Updated = updated
+		records = append(records, record)
+	}
+	return records, result.Err()
+}
+
+// payloadHash identifies identical payloads without comparing them in full.
+func payloadHash(payload []byte) string {
+	sum := sha1.Sum(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+func nullIfZero(id int64) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
@@ -0,0 +1,122 @@
+package quarantine
+
+import (
+	"testing"
+
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"github.com/warmans/fakt-api/pkg/server/data/store/storetest"
+)
+
+func TestAddRecordUpdatesIdenticalRecord(t *testing.T) {
+
+	db, cleanup := storetest.MustOpenDB(t)
+	defer cleanup()
+
+	store := &Store{DB: db.NewSession(nil)}
+
+	original := &common.QuarantinedRecord{Source: "k9", Type: common.QuarantineTypeEvent, Payload: []byte(`{"type":"Konzert"}`), Reason: "invalid venue"}
+	if err := store.AddRecord(original); err != nil {
+		t.Fatalf("failed to add record: %s", err.Error())
+	}
+
+	examples := []struct {
+		Name   string
+		Record *common.QuarantinedRecord
+		Same   bool
+	}{
+		{
+			Name:   "rejected again",
+			Record: &common.QuarantinedRecord{Source: "k9", Type: common.QuarantineTypeEvent, Payload: []byte(`{"type":"Konzert"}`), Reason: "invalid event"},
+			Same:   true,
+		},
+		{
+			Name:   "different source",
+			Record: &common.QuarantinedRecord{Source: "koepi", Type: common.QuarantineTypeEvent, Payload: []byte(`{"type":"Konzert"}`)},
+		},
+		{
+			Name:   "different type",
+			Record: &common.QuarantinedRecord{Source: "k9", Type: common.QuarantineTypePerformer, Payload: []byte(`{"type":"Konzert"}`)},
+		},
+		{
+			Name:   "different payload",
+			Record: &common.QuarantinedRecord{Source: "k9", Type: common.QuarantineTypeEvent, Payload: []byte(`{"type":"Party"}`)},
+		},
+		{
+			Name:   "different event",
+			Record: &common.QuarantinedRecord{Source: "k9", Type: common.QuarantineTypeEvent, Payload: []byte(`{"type":"Konzert"}`), EventID: 1},
+		},
+	}
+
+	for _, ex := range examples {
+		if err := store.AddRecord(ex.Record); err != nil {
+			t.Fatalf("%s: failed to add record: %s", ex.Name, err.Error())
+		}
+		if same := ex.Record.ID == original.ID; same != ex.Same {
+			t.Errorf("%s: expected same record %v got record %d", ex.Name, ex.Same, ex.Record.ID)
+		}
+	}
+
+	stored, err := store.FindRecord(original.ID)
+	if err != nil {
+		t.Fatalf("failed to find record: %s", err.Error())
+	}
+	if stored.Reason != "invalid event" || stored.Updated == nil {
+		t.Errorf("expected reason and updated time to be changed got %+v", stored)
+	}
+	if !stored.Created.Equal(original.Created) {
+		t.Errorf("expected created time %s to be kept got %s", original.Created, stored.Created)
+	}
+
+	f := &Filter{}
+	f.PageSize = 100
+	records, err := store.FindRecords(f)
+	if err != nil {
+		t.Fatalf("failed to find records: %s", err.Error())
+	}
+	if len(records) != len(examples) {
+		t.Errorf("expected %d records got %d", len(examples), len(records))
+	}
+}
+
+func TestAddRecordMatchesEditedPayload(t *testing.T) {
+
+	db, cleanup := storetest.MustOpenDB(t)
+	defer cleanup()
+
+	store := &Store{DB: db.NewSession(nil)}
+
+	record := &common.QuarantinedRecord{Source: "k9", Type: common.QuarantineTypeEvent, Payload: []byte(`{"type":"Konzert"}`), Reason: "invalid venue"}
+	if err := store.AddRecord(record); err != nil {
+		t.Fatalf("failed to add record: %s", err.Error())
+	}
+	record.Payload = []byte(`{"type":"Konzert","venue":{"name":"K9"}}`)
+	if err := store.UpdateRecord(record); err != nil {
+		t.Fatalf("failed to update record: %s", err.Error())
+	}
+
+	edited := &common.QuarantinedRecord{Source: "k9", Type: common.QuarantineTypeEvent, Payload: record.Payload, Reason: "invalid event"}
+	if err := store.AddRecord(edited); err != nil {
+		t.Fatalf("failed to add record: %s", err.Error())
+	}
+	if edited.ID != record.ID {
+		t.Errorf("expected edited record %d to be matched got %d", record.ID, edited.ID)
+	}
+}
+
+func TestChangeUnknownRecord(t *testing.T) {
+
+	db, cleanup := storetest.MustOpenDB(t)
+	defer cleanup()
+
+	store := &Store{DB: db.NewSession(nil)}
+
+	if err := store.UpdateRecord(&common.QuarantinedRecord{ID: 100}); err != ErrRecordNotFound {
+		t.Errorf("expected update to fail with %v got %v", ErrRecordNotFound, err)
+	}
+	if err := store.DiscardRecord(100); err != ErrRecordNotFound {
+		t.Errorf("expected discard to fail with %v got %v", ErrRecordNotFound, err)
+	}
+	if _, err := store.FindRecord(100); err != ErrRecordNotFound {
+		t.Errorf("expected find to fail with %v got %v", ErrRecordNotFound, err)
+	}
+}
@@ -0,0 +1,115 @@
+package series
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/warmans/dbr"
+	"github.com/warmans/dbr/dialect"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+func FilterFromRequest(r *http.Request) *Filter {
+	f := &Filter{}
+	f.Populate(r)
+	return f
+}
+
+type Filter struct {
+	common.Filter
+
+	VenueIDs []int64 `json:"venues"`
+	// Active limits the list to series with an event today or later.
+	Active bool `json:"active"`
+}
+
+func (f *Filter) Populate(r *http.Request) {
+
+	f.Filter.Populate(r)
+
+	f.VenueIDs = make([]int64, 0)
+	if venue := r.Form.Get("venue"); venue != "" {
+		for _, idStr := range strings.Split(venue, ",") {
+			if idInt, err := strconv.Atoi(idStr); err == nil {
+				f.VenueIDs = append(f.VenueIDs, int64(idInt))
+			}
+		}
+	}
+
+	f.Active = common.StringToBool(r.Form.Get("active"))
+}
+
+type Store struct {
+	DB *dbr.Session
+}
+
+// FindSeries lists series (with venue) by venue, weekday and time.
+func (s *Store) FindSeries(filter *Filter) ([]*common.EventSeries, error) {
+
+	page := filter.Page
+	if page == 0 {
+		page = 1
+	}
+
+	q := s.DB.Select(
+		"s.id",
+		"s.weekday",
+		"s.time",
+		"s.name",
+		"coalesce(s.description, '')",
+		"s.num_events",
+		"s.first_date",
+		"s.last_date",
+		"v.id",
+		"v.name",
+		"coalesce(v.address, '')",
+	)
+	q.From("event_series s")
+	q.Join("venue v", "s.venue_id = v.id")
+	q.OrderBy("v.name").OrderBy("s.weekday").OrderBy("s.time").OrderBy("s.id")
+	q.Limit(uint64(filter.PageSize))
+
+	if filter.PageSize != 0 {
+		q.Limit(uint64(filter.PageSize)).Offset(uint64((filter.PageSize * page) - filter.PageSize))
+	}
+	if len(filter.IDs) > 0 {
+		q.Where("s.id IN ?", filter.IDs)
+	}
+	if len(filter.VenueIDs) > 0 {
+		q.Where("s.venue_id IN ?", filter.VenueIDs)
+	}
+	if filter.Active {
+		q.Where("date(s.last_date) >= date('now')")
+	}
+
+	sqlString, vals := q.ToSql()
+	interpolated, err := dbr.InterpolateForDialect(sqlString, vals, dialect.SQLite3)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.DB.Query(interpolated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find series because %s", err.Error())
+	}
+	defer res.Close()
+
+	series := make([]*common.EventSeries, 0)
+	for res.Next() {
+		var weekday int
+		cur := &common.EventSeries{Venue: &common.Venue{}}
+		err := res.Scan(
+			&cur.ID, &weekday, &cur.Time, &cur.Name, &cur.Description, &cur.NumEvents, &cur.FirstDate, &cur.LastDate,
+			&cur.Venue.ID, &cur.Venue.Name, &cur.Venue.Address,
+		)
+		if err != nil {
+			return nil, err
+		}
+		cur.Weekday = time.Weekday(weekday)
+		series = append(series, cur)
+	}
+	return series, res.Err()
+}
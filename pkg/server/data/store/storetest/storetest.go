@@ -0,0 +1,46 @@
+// Package storetest creates databases for tests that need to run real queries.
+package storetest
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/rubenv/sql-migrate"
+	"github.com/warmans/dbr"
+)
+
+// MustOpenDB creates a database in a temporary directory with all migrations applied. The returned function
+// closes and removes it.
+func MustOpenDB(t testing.TB) (*dbr.Connection, func()) {
+
+	dir, err := ioutil.TempDir("", "fakt-api-test")
+	if err != nil {
+		t.Fatalf("failed to create DB dir: %s", err.Error())
+	}
+
+	db, err := dbr.Open("sqlite3", path.Join(dir, "db.sqlite3"), nil)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("failed to open DB: %s", err.Error())
+	}
+	cleanup := func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+
+	if _, err := migrate.Exec(db.DB, "sqlite3", &migrate.FileMigrationSource{Dir: migrationsPath()}, migrate.Up); err != nil {
+		cleanup()
+		t.Fatalf("failed to apply migrations: %s", err.Error())
+	}
+	return db, cleanup
+}
+
+// migrationsPath is the migrations directory at the root of the repository.
+func migrationsPath() string {
+	_, file, _, _ := runtime.Caller(0)
+	return path.Join(path.Dir(file), "..", "..", "..", "..", "..", "migrations")
+}
@@ -0,0 +1,117 @@
+package tag
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/warmans/dbr"
+)
+
+// MustExist returns the ID of the (already normalized) tag, creating it and its parents if needed.
+func MustExist(tr *dbr.Tx, tag string) (int64, error) {
+
+	var parentID interface{}
+	if parent := Parent(tag); parent != "" {
+		id, err := MustExist(tr, parent)
+		if err != nil {
+			return 0, err
+		}
+		parentID = id
+	}
+
+	var tagID int64
+	err := tr.QueryRow("SELECT id FROM tag WHERE tag = ?", tag).Scan(&tagID)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to find tag id for %s because %s", tag, err.Error())
+	}
+	if tagID != 0 {
+		if _, err := tr.Exec("UPDATE tag SET parent_id=? WHERE id=?", parentID, tagID); err != nil {
+			return 0, fmt.Errorf("failed to update tag %s because %s", tag, err.Error())
+		}
+		return tagID, nil
+	}
+
+	res, err := tr.Exec("INSERT INTO tag (tag, parent_id) VALUES (?, ?)", tag, parentID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert tag %s because %s", tag, err.Error())
+	}
+	if tagID, err = res.LastInsertId(); err != nil {
+		return 0, fmt.Errorf("failed to get inserted tag id because %s", err.Error())
+	}
+	return tagID, nil
+}
+
+// SyncTaxonomy brings tags stored before they were normalized (or before the taxonomy changed) in line with it.
+// Blocked tags are deleted, synonyms are merged into their canonical tag and parents are set. Returns the number
+// of tags removed.
+func (s *Store) SyncTaxonomy() (int64, error) {
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	removed, err := func(tr *dbr.Tx) (int64, error) {
+
+		tags := make(map[int64]string)
+		res, err := tr.Query("SELECT id, tag FROM tag")
+		if err != nil {
+			return 0, err
+		}
+		for res.Next() {
+			var id int64
+			var raw string
+			if err := res.Scan(&id, &raw); err != nil {
+				res.Close()
+				return 0, err
+			}
+			tags[id] = raw
+		}
+		res.Close()
+
+		removed := int64(0)
+		for id, raw := range tags {
+			normalized, ok := Normalize(raw)
+			if ok && normalized == raw {
+				if _, err := MustExist(tr, normalized); err != nil {
+					return removed, err
+				}
+				continue
+			}
+			if ok {
+				canonicalID, err := MustExist(tr, normalized)
+				if err != nil {
+					return removed, err
+				}
+				for _, q := range []string{
+					"UPDATE OR IGNORE event_tag SET tag_id=? WHERE tag_id=?",
+					"UPDATE OR IGNORE performer_tag SET tag_id=? WHERE tag_id=?",
+				} {
+					if _, err := tr.Exec(q, canonicalID, id); err != nil {
+						return removed, fmt.Errorf("failed to merge tag %s into %s because %s", raw, normalized, err.Error())
+					}
+				}
+			}
+			for _, q := range []string{
+				"DELETE FROM event_tag WHERE tag_id=?",
+				"DELETE FROM performer_tag WHERE tag_id=?",
+				"UPDATE tag SET parent_id=NULL WHERE parent_id=?",
+				"DELETE FROM tag WHERE id=?",
+			} {
+				if _, err := tr.Exec(q, id); err != nil {
+					return removed, fmt.Errorf("failed to remove tag %s because %s", raw, err.Error())
+				}
+			}
+			removed++
+		}
+		return removed, nil
+	}(tx)
+
+	if err != nil {
+		if txerr := tx.Rollback(); txerr != nil {
+			return 0, fmt.Errorf("%s -> %s", err, txerr)
+		}
+		return 0, err
+	}
+	return removed, tx.Commit()
+}
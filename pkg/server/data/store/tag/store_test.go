@@ -0,0 +1,134 @@
+package tag
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/warmans/fakt-api/pkg/server/data/store/storetest"
+)
+
+// mustFindParent returns the parent of the tag or "" if it has none.
+func mustFindParent(t *testing.T, db interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}, tag string) string {
+	var parent sql.NullString
+	err := db.QueryRow("SELECT p.tag FROM tag t LEFT JOIN tag p ON t.parent_id = p.id WHERE t.tag=?", tag).Scan(&parent)
+	if err != nil {
+		t.Fatalf("failed to find parent of %s: %s", tag, err.Error())
+	}
+	return parent.String
+}
+
+func TestMustExist(t *testing.T) {
+
+	db, cleanup := storetest.MustOpenDB(t)
+	defer cleanup()
+
+	tx, err := db.NewSession(nil).Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	examples := []struct {
+		Tag    string
+		Parent string
+	}{
+		{Tag: "hardcore", Parent: "punk"},
+		{Tag: "punk", Parent: ""},
+		{Tag: "hamburg", Parent: ""},
+	}
+
+	for _, ex := range examples {
+		id, err := MustExist(tx, ex.Tag)
+		if err != nil {
+			t.Fatalf("%s: failed to create tag: %s", ex.Tag, err.Error())
+		}
+		again, err := MustExist(tx, ex.Tag)
+		if err != nil {
+			t.Fatalf("%s: failed to find tag: %s", ex.Tag, err.Error())
+		}
+		if id != again {
+			t.Errorf("%s: expected existing tag %d got %d", ex.Tag, id, again)
+		}
+		if parent := mustFindParent(t, tx, ex.Tag); parent != ex.Parent {
+			t.Errorf("%s: expected parent %q got %q", ex.Tag, ex.Parent, parent)
+		}
+	}
+
+	var count int
+	if err := tx.QueryRow("SELECT count(*) FROM tag").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3 tags got %d", count)
+	}
+}
+
+func TestSyncTaxonomy(t *testing.T) {
+
+	db, cleanup := storetest.MustOpenDB(t)
+	defer cleanup()
+
+	//tags as they were stored before normalization
+	for _, q := range []string{
+		"INSERT INTO tag (id, tag) VALUES (1, 'Konzert'), (2, 'concert'), (3, 'sonstiges'), (4, 'hardcore'), (5, 'Punk-Rock')",
+		"INSERT INTO event_tag (event_id, tag_id) VALUES (1, 1), (1, 3), (2, 1), (2, 2)",
+		"INSERT INTO performer_tag (performer_id, tag_id) VALUES (1, 5), (1, 3), (2, 4)",
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatalf("failed to insert fixtures: %s", err.Error())
+		}
+	}
+
+	store := &Store{DB: db.NewSession(nil)}
+	removed, err := store.SyncTaxonomy()
+	if err != nil {
+		t.Fatalf("failed to sync taxonomy: %s", err.Error())
+	}
+	if removed != 3 {
+		t.Errorf("expected 3 tags to be removed got %d", removed)
+	}
+
+	examples := []struct {
+		Query    string
+		Expected []string
+	}{
+		{
+			Query:    "SELECT tag FROM tag ORDER BY tag",
+			Expected: []string{"concert", "hardcore", "punk", "punk rock"},
+		},
+		{
+			Query:    "SELECT t.tag FROM event_tag et JOIN tag t ON et.tag_id = t.id ORDER BY et.event_id, t.tag",
+			Expected: []string{"concert", "concert"},
+		},
+		{
+			Query:    "SELECT t.tag FROM performer_tag pt JOIN tag t ON pt.tag_id = t.id ORDER BY pt.performer_id, t.tag",
+			Expected: []string{"punk rock", "hardcore"},
+		},
+	}
+
+	for _, ex := range examples {
+		res, err := db.Query(ex.Query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := make([]string, 0)
+		for res.Next() {
+			var tag string
+			if err := res.Scan(&tag); err != nil {
+				t.Fatal(err)
+			}
+			actual = append(actual, tag)
+		}
+		res.Close()
+		if !reflect.DeepEqual(actual, ex.Expected) {
+			t.Errorf("%s: expected %v got %v", ex.Query, ex.Expected, actual)
+		}
+	}
+
+	if parent := mustFindParent(t, db, "hardcore"); parent != "punk" {
+		t.Errorf("expected hardcore to be under punk got %q", parent)
+	}
+}
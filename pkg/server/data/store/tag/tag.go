@@ -37,6 +37,7 @@ func (s *Store) FindTags(filter *Filter) ([]*common.Tag, error) {
 		Select(
 		"t.id",
 		"t.tag",
+		"coalesce(t.parent_id, 0) AS parent_id",
 		"COUNT(DISTINCT performer_tag.performer_id) AS stat_performers",
 		"COUNT(DISTINCT event.id) AS stat_events",
 		).
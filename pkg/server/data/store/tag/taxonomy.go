@@ -0,0 +1,180 @@
+package tag
+
+import (
+	"strings"
+	"unicode"
+)
+
+// blocked tags say nothing useful about an event or performer.
+var blocked = map[string]bool{
+	"sonstiges": true,
+	"sonstige":  true,
+	"diverses":  true,
+	"misc":      true,
+	"other":     true,
+	"various":   true,
+	"tba":       true,
+	"na":        true,
+}
+
+// synonyms maps the compacted form (see compact) of German, English and alternately spelled tags to a canonical tag.
+var synonyms = map[string]string{
+	//event types
+	"konzert":           "concert",
+	"konzerte":          "concert",
+	"gig":               "concert",
+	"livemusik":         "concert",
+	"fete":              "party",
+	"feier":             "party",
+	"lesung":            "reading",
+	"vortrag":           "talk",
+	"diskussion":        "discussion",
+	"podiumsdiskussion": "discussion",
+	"ausstellung":       "exhibition",
+	"kino":              "film",
+	"movie":             "film",
+	"filmabend":         "film",
+	"kneipe":            "bar",
+	"tresen":            "bar",
+	"soli":              "benefit",
+	"solikonzert":       "benefit",
+	"soliparty":         "benefit",
+	"voku":              "vokü",
+	"kufa":              "vokü",
+	"küfa":              "vokü",
+	"demonstration":     "demo",
+	"kundgebung":        "demo",
+
+	//genres
+	"punkrock":         "punk rock",
+	"hc":               "hardcore",
+	"hardcorepunk":     "hardcore",
+	"crustpunk":        "crust",
+	"postpunk":         "post-punk",
+	"skapunk":          "ska-punk",
+	"oi":               "oi",
+	"dbeat":            "d-beat",
+	"poppunk":          "pop punk",
+	"anarchopunk":      "anarcho punk",
+	"streetpunk":       "street punk",
+	"grind":            "grindcore",
+	"hiphop":           "hip hop",
+	"rap":              "hip hop",
+	"elektro":          "electronic",
+	"electro":          "electronic",
+	"elektronisch":     "electronic",
+	"elektronik":       "electronic",
+	"dnb":              "drum and bass",
+	"drumnbass":        "drum and bass",
+	"drumandbass":      "drum and bass",
+	"liedermacher":     "singer-songwriter",
+	"liedermacherin":   "singer-songwriter",
+	"singersongwriter": "singer-songwriter",
+	"songwriter":       "singer-songwriter",
+	"postrock":         "post-rock",
+	"indierock":        "indie",
+	"garagerock":       "garage",
+	"psychedelicrock":  "psychedelic",
+	"psych":            "psychedelic",
+	"blackmetal":       "black metal",
+	"deathmetal":       "death metal",
+	"doommetal":        "doom",
+	"sludgemetal":      "sludge",
+	"thrashmetal":      "thrash",
+	"liedgut":          "folk",
+	"volksmusik":       "folk",
+}
+
+// parents places tags in a hierarchy so e.g. filtering by punk includes hardcore.
+var parents = map[string]string{
+	"punk rock":     "punk",
+	"hardcore":      "punk",
+	"crust":         "punk",
+	"deutschpunk":   "punk",
+	"post-punk":     "punk",
+	"ska-punk":      "punk",
+	"oi":            "punk",
+	"d-beat":        "punk",
+	"pop punk":      "punk",
+	"anarcho punk":  "punk",
+	"street punk":   "punk",
+	"grindcore":     "metal",
+	"black metal":   "metal",
+	"death metal":   "metal",
+	"doom":          "metal",
+	"sludge":        "metal",
+	"thrash":        "metal",
+	"techno":        "electronic",
+	"house":         "electronic",
+	"drum and bass": "electronic",
+	"dub":           "reggae",
+	"post-rock":     "rock",
+	"indie":         "rock",
+	"garage":        "rock",
+	"psychedelic":   "rock",
+	"shoegaze":      "rock",
+}
+
+// canonical maps the compacted form of every canonical tag to itself so e.g. "Black-Metal" matches "black metal".
+var canonical = make(map[string]string)
+
+func init() {
+	for _, tag := range synonyms {
+		canonical[compact(tag)] = tag
+	}
+	for child, parent := range parents {
+		canonical[compact(child)] = child
+		canonical[compact(parent)] = parent
+	}
+}
+
+// Normalize cleans up a raw tag and maps it to its canonical form. ok is false if the tag should be dropped.
+func Normalize(raw string) (tag string, ok bool) {
+
+	tag = strings.Join(strings.Fields(strings.ToLower(raw)), " ")
+	tag = strings.TrimFunc(tag, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	key := compact(tag)
+	if key == "" || blocked[key] || strings.IndexFunc(key, unicode.IsLetter) < 0 {
+		return "", false
+	}
+	if syn, found := synonyms[key]; found {
+		return syn, true
+	}
+	if can, found := canonical[key]; found {
+		return can, true
+	}
+	return tag, true
+}
+
+// NormalizeAll normalizes the tags removing any that are blocked or duplicates.
+func NormalizeAll(raw []string) []string {
+	seen := make(map[string]bool)
+	tags := make([]string, 0, len(raw))
+	for _, r := range raw {
+		tag, ok := Normalize(r)
+		if !ok || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// Parent returns the tag's parent or an empty string if it is top level.
+func Parent(tag string) string {
+	return parents[tag]
+}
+
+// compact reduces the tag to only letters and numbers so spacing and hyphenation don't matter.
+func compact(tag string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			return r
+		}
+		return -1
+	}, strings.ToLower(tag))
+}
@@ -0,0 +1,60 @@
+package tag
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+
+	examples := []struct {
+		Raw      string
+		Expected string
+		OK       bool
+	}{
+		{Raw: " Konzert ", Expected: "concert", OK: true},
+		{Raw: "concert", Expected: "concert", OK: true},
+		{Raw: "Punk-Rock", Expected: "punk rock", OK: true},
+		{Raw: "punkrock", Expected: "punk rock", OK: true},
+		{Raw: "Black  Metal", Expected: "black metal", OK: true},
+		{Raw: "Hip-Hop", Expected: "hip hop", OK: true},
+		{Raw: "Oi!", Expected: "oi", OK: true},
+		{Raw: "Noise", Expected: "noise", OK: true},
+		{Raw: "", OK: false},
+		{Raw: " - ", OK: false},
+		{Raw: "Sonstiges", OK: false},
+		{Raw: "2016", OK: false},
+	}
+
+	for _, ex := range examples {
+		actual, ok := Normalize(ex.Raw)
+		if ok != ex.OK || (ok && actual != ex.Expected) {
+			t.Errorf("%q: expected %q (%v) got %q (%v)", ex.Raw, ex.Expected, ex.OK, actual, ok)
+		}
+	}
+}
+
+func TestNormalizeAll(t *testing.T) {
+	actual := NormalizeAll([]string{"Punk ", "Hamburg", "", "konzert", "Concert", "punk"})
+	if expected := []string{"punk", "hamburg", "concert"}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v got %v", expected, actual)
+	}
+}
+
+func TestParent(t *testing.T) {
+	if p := Parent("hardcore"); p != "punk" {
+		t.Errorf("expected hardcore to be under punk got %q", p)
+	}
+	if p := Parent("punk"); p != "" {
+		t.Errorf("expected punk to be top level got %q", p)
+	}
+	//every parent must itself be canonical so it can be found again
+	for child, parent := range parents {
+		if n, ok := Normalize(parent); !ok || n != parent {
+			t.Errorf("parent %q of %q does not normalize to itself", parent, child)
+		}
+		if n, ok := Normalize(child); !ok || n != child {
+			t.Errorf("child %q does not normalize to itself", child)
+		}
+	}
+}
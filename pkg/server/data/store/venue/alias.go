@@ -0,0 +1,242 @@
+package venue
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/warmans/dbr"
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+)
+
+const (
+	// MatchThreshold is the minimum name similarity for an unknown venue name to be resolved to an existing venue.
+	MatchThreshold = 0.85
+	// DefaultDuplicateThreshold is the minimum score for two venues to be reported as likely duplicates.
+	DefaultDuplicateThreshold = 0.75
+)
+
+// ErrVenueNotFound is returned when merging a venue that doesn't exist.
+var ErrVenueNotFound = errors.New("venue not found")
+
+// ResolveVenue finds the ID of the venue with the given name. Names are matched exactly, then by known aliases,
+// then by normalized name and finally by the most similar name above MatchThreshold. Inexact matches are stored
+// as aliases so they don't need to be found again. Returns 0 if there is no match.
+func (s *Store) ResolveVenue(tr *dbr.Tx, name string) (int64, error) {
+
+	var id int64
+	err := tr.QueryRow("SELECT id FROM venue WHERE name=?", name).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	if id != 0 {
+		return id, nil
+	}
+
+	alias := common.NormalizeName(name)
+	if alias == "" {
+		return 0, nil
+	}
+
+	err = tr.QueryRow("SELECT venue_id FROM venue_alias WHERE alias=?", alias).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	if id != 0 {
+		return id, nil
+	}
+
+	venues, err := s.findVenueNames(tr)
+	if err != nil {
+		return 0, err
+	}
+	bestScore := 0.0
+	for _, v := range venues {
+		if common.NormalizeName(v.Name) == alias {
+			id = v.ID
+			break
+		}
+		if score := common.Similarity(name, v.Name); score >= MatchThreshold && score > bestScore {
+			id, bestScore = v.ID, score
+		}
+	}
+	if id == 0 {
+		return 0, nil
+	}
+	return id, s.StoreVenueAlias(tr, id, name)
+}
+
+// StoreVenueAlias resolves the name to the venue in future.
+func (s *Store) StoreVenueAlias(tr *dbr.Tx, venueID int64, name string) error {
+	alias := common.NormalizeName(name)
+	if alias == "" {
+		return nil
+	}
+	if _, err := tr.Exec("INSERT OR REPLACE INTO venue_alias (alias, venue_id) VALUES (?, ?)", alias, venueID); err != nil {
+		return fmt.Errorf("failed to store venue alias %s because %s", alias, err.Error())
+	}
+	return nil
+}
+
+func (s *Store) FindVenueAliases(venueID int64) ([]string, error) {
+	res, err := s.DB.Query("SELECT alias FROM venue_alias WHERE venue_id=? ORDER BY alias", venueID)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	aliases := make([]string, 0)
+	for res.Next() {
+		var alias string
+		if err := res.Scan(&alias); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, res.Err()
+}
+
+// MergeVenues moves everything at the venue fromID to intoID and deletes it. Its name becomes an alias of
+// intoID so future listings using the old name go to the right place. Events at both venues with the same
+// date are merged into intoID's event since they must be the same event.
+func (s *Store) MergeVenues(fromID, intoID int64) error {
+
+	if fromID == intoID {
+		return fmt.Errorf("cannot merge venue %d into itself", fromID)
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = func(tr *dbr.Tx) error {
+		var fromName string
+		if err := tr.QueryRow("SELECT name FROM venue WHERE id=?", fromID).Scan(&fromName); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrVenueNotFound
+			}
+			return err
+		}
+		var exists int
+		if err := tr.QueryRow("SELECT count(*) FROM venue WHERE id=?", intoID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			return ErrVenueNotFound
+		}
+
+		collisions, err := s.findCollidingEvents(tr, fromID, intoID)
+		if err != nil {
+			return err
+		}
+		for fromEventID, intoEventID := range collisions {
+			if err := s.EventStore.MergeEvents(tr, fromEventID, intoEventID); err != nil {
+				return err
+			}
+		}
+
+		for _, q := range []string{
+			"UPDATE event SET venue_id=? WHERE venue_id=?",
+			"UPDATE venue_extra SET venue_id=? WHERE venue_id=?",
+			"UPDATE venue_alias SET venue_id=? WHERE venue_id=?",
+		} {
+			if _, err := tr.Exec(q, intoID, fromID); err != nil {
+				return fmt.Errorf("failed to merge venue %d into %d because %s", fromID, intoID, err.Error())
+			}
+		}
+		if err := s.StoreVenueAlias(tr, intoID, fromName); err != nil {
+			return err
+		}
+		if _, err := tr.Exec("DELETE FROM venue WHERE id=?", fromID); err != nil {
+			return fmt.Errorf("failed to delete merged venue %d because %s", fromID, err.Error())
+		}
+		return nil
+	}(tx)
+
+	if err != nil {
+		if txerr := tx.Rollback(); txerr != nil {
+			return fmt.Errorf("%s -> %s", err, txerr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// findCollidingEvents maps events at the venue fromID to the event at intoID with the same date.
+func (s *Store) findCollidingEvents(tr *dbr.Tx, fromID, intoID int64) (map[int64]int64, error) {
+	res, err := tr.Query(
+		`SELECT f.id, min(i.id)
+		FROM event f
+		JOIN event i ON i.venue_id = ? AND i.date = f.date
+		WHERE f.venue_id = ?
+		GROUP BY f.id`,
+		intoID,
+		fromID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find colliding events because %s", err.Error())
+	}
+	defer res.Close()
+
+	collisions := make(map[int64]int64)
+	for res.Next() {
+		var fromEventID, intoEventID int64
+		if err := res.Scan(&fromEventID, &intoEventID); err != nil {
+			return nil, err
+		}
+		collisions[fromEventID] = intoEventID
+	}
+	return collisions, res.Err()
+}
+
+// FindDuplicateVenues reports pairs of venues with similar names (and addresses, where known) scoring at least
+// threshold, most likely first.
+func (s *Store) FindDuplicateVenues(threshold float64) ([]*common.VenueDuplicate, error) {
+
+	venues := make([]*common.Venue, 0)
+	if _, err := s.DB.Select("id", "name", "coalesce(address, '') AS address").From("venue").OrderBy("id").Load(&venues); err != nil && err != dbr.ErrNotFound {
+		return nil, err
+	}
+
+	duplicates := make([]*common.VenueDuplicate, 0)
+	for i, a := range venues {
+		for _, b := range venues[i+1:] {
+			if score := ScoreVenues(a, b); score >= threshold {
+				duplicates = append(duplicates, &common.VenueDuplicate{Venue: a, Duplicate: b, Score: score})
+			}
+		}
+	}
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		return duplicates[i].Score > duplicates[j].Score
+	})
+	return duplicates, nil
+}
+
+// ScoreVenues estimates how likely it is (0-1) that the two venues are the same place.
+func ScoreVenues(a, b *common.Venue) float64 {
+	nameScore := common.Similarity(a.Name, b.Name)
+	if a.Address == "" || b.Address == "" {
+		return nameScore
+	}
+	return 0.7*nameScore + 0.3*common.Similarity(a.Address, b.Address)
+}
+
+func (s *Store) findVenueNames(tr *dbr.Tx) ([]*common.Venue, error) {
+	res, err := tr.Query("SELECT id, name FROM venue ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	venues := make([]*common.Venue, 0)
+	for res.Next() {
+		v := &common.Venue{}
+		if err := res.Scan(&v.ID, &v.Name); err != nil {
+			return nil, err
+		}
+		venues = append(venues, v)
+	}
+	return venues, res.Err()
+}
@@ -0,0 +1,108 @@
+package venue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"github.com/warmans/fakt-api/pkg/server/data/store/event"
+	"github.com/warmans/fakt-api/pkg/server/data/store/performer"
+	"github.com/warmans/fakt-api/pkg/server/data/store/storetest"
+	"go.uber.org/zap"
+)
+
+func TestMergeVenuesMergesEventsOnTheSameDate(t *testing.T) {
+
+	db, cleanup := storetest.MustOpenDB(t)
+	defer cleanup()
+
+	performerStore := &performer.Store{DB: db.NewSession(nil), Logger: zap.NewNop()}
+	eventStore := &event.Store{DB: db.NewSession(nil), PerformerStore: performerStore}
+	store := &Store{DB: db.NewSession(nil), EventStore: eventStore}
+
+	date := time.Now().Truncate(time.Hour*24).AddDate(0, 0, 7).Add(time.Hour * 20)
+	into := &common.Venue{Name: "K9", Address: "Kinzigstr. 9"}
+	from := &common.Venue{Name: "Kinzigstraße Neun"}
+	kotzreiz := &common.Performer{Name: "Kotzreiz", Genre: "Punk"}
+	gaeste := &common.Performer{Name: "Gäste", Genre: "Punk"}
+
+	events := []*common.Event{
+		{Date: date, Venue: into, Type: "Konzert", Source: "k9", Performers: []*common.Performer{kotzreiz}},
+		{Date: date, Venue: from, Type: "Konzert", Source: "stressfaktor", Status: common.EventStatusCancelled, Performers: []*common.Performer{gaeste}, Tags: []string{"punk"}},
+		{Date: date.AddDate(0, 0, 1), Venue: from, Type: "Kneipe", Source: "stressfaktor"},
+	}
+
+	tx, err := db.NewSession(nil).Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range events {
+		if err := store.VenueMustExist(tx, e.Venue); err != nil {
+			t.Fatalf("failed to store venue: %s", err.Error())
+		}
+		for _, p := range e.Performers {
+			if err := performerStore.PerformerMustExist(tx, p); err != nil {
+				t.Fatalf("failed to store performer: %s", err.Error())
+			}
+		}
+		if _, err := eventStore.EventMustExist(tx, e); err != nil {
+			t.Fatalf("failed to store event: %s", err.Error())
+		}
+		if err := eventStore.StoreEventSource(tx, e.ID, e.Source, 1); err != nil {
+			t.Fatalf("failed to store event source: %s", err.Error())
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if into.ID == from.ID {
+		t.Fatal("venues should not have been resolved to the same venue")
+	}
+
+	if err := store.MergeVenues(from.ID, into.ID); err != nil {
+		t.Fatalf("failed to merge venues: %s", err.Error())
+	}
+
+	f := &event.Filter{}
+	f.PageSize = 100
+	merged, err := eventStore.FindEvents(f)
+	if err != nil {
+		t.Fatalf("failed to find events: %s", err.Error())
+	}
+	if len(merged) != 2 {
+		t.Fatalf("expected 2 events got %d", len(merged))
+	}
+	for _, e := range merged {
+		if e.Venue.ID != into.ID {
+			t.Errorf("expected event %d to be at venue %d got %d", e.ID, into.ID, e.Venue.ID)
+		}
+	}
+	if merged[0].ID != events[0].ID || merged[1].ID != events[2].ID {
+		t.Fatalf("expected events %d and %d to remain got %d and %d", events[0].ID, events[2].ID, merged[0].ID, merged[1].ID)
+	}
+
+	if merged[0].Status != common.EventStatusCancelled {
+		t.Errorf("expected cancellation to be merged got %s", merged[0].Status)
+	}
+	if len(merged[0].Performers) != 2 {
+		t.Errorf("expected performers to be merged got %d", len(merged[0].Performers))
+	}
+	if len(merged[0].Tags) != 1 || merged[0].Tags[0] != "punk" {
+		t.Errorf("expected tags to be merged got %v", merged[0].Tags)
+	}
+	sources, err := eventStore.FindEventSources(merged[0].ID)
+	if err != nil {
+		t.Fatalf("failed to find sources: %s", err.Error())
+	}
+	if len(sources) != 2 {
+		t.Errorf("expected sources to be merged got %d", len(sources))
+	}
+
+	var orphans int
+	if err := db.QueryRow("SELECT count(*) FROM event_performer WHERE event_id=?", events[1].ID).Scan(&orphans); err != nil {
+		t.Fatal(err)
+	}
+	if orphans != 0 {
+		t.Errorf("expected merged event's performers to be removed got %d", orphans)
+	}
+}
@@ -1,11 +1,11 @@
 package venue
 
 import (
-	"database/sql"
 	"net/http"
 
 	"github.com/warmans/dbr"
 	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"github.com/warmans/fakt-api/pkg/server/data/store/event"
 )
 
 func FilterFromRequest(r *http.Request) *Filter {
@@ -44,14 +44,16 @@ func (f *Filter) Populate(r *http.Request) {
 
 type Store struct {
 	DB *dbr.Session
+	// EventStore is used to merge events when merging venues.
+	EventStore *event.Store
 }
 
 func (s *Store) VenueMustExist(tr *dbr.Tx, venue *common.Venue) error {
 
 	//get the venue ID if it exists
 	if venue.ID == 0 {
-		err := tr.QueryRow("SELECT id FROM venue WHERE name=?", venue.Name, venue.Address).Scan(&venue.ID)
-		if err != nil && err != sql.ErrNoRows {
+		var err error
+		if venue.ID, err = s.ResolveVenue(tr, venue.Name); err != nil {
 			return err
 		}
 	}
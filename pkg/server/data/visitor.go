@@ -11,6 +11,27 @@ import (
 	"go.uber.org/zap"
 )
 
+// StatusVisitor marks events as cancelled or postponed based on their description.
+type StatusVisitor struct{}
+
+func (v *StatusVisitor) Visit(e *common.Event) {
+	e.GuessStatus()
+}
+
+// CategoryVisitor classifies events into canonical categories.
+type CategoryVisitor struct{}
+
+func (v *CategoryVisitor) Visit(e *common.Event) {
+	e.GuessCategory()
+}
+
+// DetailsVisitor parses times, prices and entry restrictions from the description.
+type DetailsVisitor struct{}
+
+func (v *DetailsVisitor) Visit(e *common.Event) {
+	e.GuessDetails()
+}
+
 // BandcampVisitor embellishes event with data from Bandcamp
 type BandcampVisitor struct {
 	Bandcamp    *bcamp.Bandcamp
@@ -84,12 +105,25 @@ type PerformerStoreVisitor struct {
 func (v *PerformerStoreVisitor) Visit(e *common.Event) {
 	//just replace whole performer if an existing one is found
 	for k, perf := range e.Performers {
-		existing, err := v.PerformerStore.FindPerformers(&performer.Filter{Name: perf.Name, Genre: perf.Genre})
+		id, _, err := v.PerformerStore.ResolvePerformer(v.PerformerStore.DB, perf)
+		if err != nil {
+			v.Logger.Error("Failed to resolve performer visiting event", zap.Error(err))
+			return
+		}
+		if id == 0 {
+			continue
+		}
+		f := &performer.Filter{}
+		f.IDs = []int64{id}
+		existing, err := v.PerformerStore.FindPerformers(f)
 		if err != nil {
 			v.Logger.Error("Failed to find performer visiting event", zap.Error(err))
 			return
 		}
 		if len(existing) > 0 {
+			//how the performer was found is specific to this listing
+			existing[0].Extractor = perf.Extractor
+			existing[0].Confidence = perf.Confidence
 			e.Performers[k] = existing[0]
 		}
 	}
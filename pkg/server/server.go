@@ -1,8 +1,10 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/NYTimes/gziphandler"
@@ -11,12 +13,16 @@ import (
 	"github.com/warmans/fakt-api/pkg/server/data"
 	"github.com/warmans/fakt-api/pkg/server/data/media"
 	"github.com/warmans/fakt-api/pkg/server/data/process"
+	"github.com/warmans/fakt-api/pkg/server/data/snapshot"
 	"github.com/warmans/fakt-api/pkg/server/data/source"
-	"github.com/warmans/fakt-api/pkg/server/data/source/k9"
-	"github.com/warmans/fakt-api/pkg/server/data/source/sfaktor"
+	_ "github.com/warmans/fakt-api/pkg/server/data/source/ics"
+	_ "github.com/warmans/fakt-api/pkg/server/data/source/jsonld"
 	"github.com/warmans/fakt-api/pkg/server/data/store/common"
+	"github.com/warmans/fakt-api/pkg/server/data/store/crawlrun"
 	"github.com/warmans/fakt-api/pkg/server/data/store/event"
 	"github.com/warmans/fakt-api/pkg/server/data/store/performer"
+	"github.com/warmans/fakt-api/pkg/server/data/store/quarantine"
+	"github.com/warmans/fakt-api/pkg/server/data/store/series"
 	"github.com/warmans/fakt-api/pkg/server/data/store/tag"
 	"github.com/warmans/fakt-api/pkg/server/data/store/venue"
 	"github.com/warmans/go-bandcamp-search/bcamp"
@@ -26,89 +32,126 @@ import (
 // VERSION is used in packaging
 var Version string
 
+const DefaultShutdownTimeout = time.Second * 30
+
 type Config struct {
 	ServerBind             string
 	ServerLocation         string
 	CrawlerStressfaktorURI string
+	CrawlerConfigPath      string
 	DbPath                 string
 	EncryptionKey          string
 	CrawlerRun             bool
+	CrawlerConcurrency     int
+	CrawlerMaxBackoff      time.Duration
+	CrawlerUserAgent       string
+	CrawlerInterval        time.Duration
+	CrawlerHTTPTimeout     time.Duration
+	CrawlerCacheDir        string
 	StaticFilesPath        string
 	VerboseLogging         bool
+	AdminToken             string
+	// ShutdownTimeout is how long in-flight API requests are given to finish when the server is stopped.
+	ShutdownTimeout time.Duration
 }
 
 func NewServer(conf *Config, logger *zap.Logger, db *dbr.Connection) *Server {
-	return &Server{conf: conf, logger: logger, db: db}
+
+	//one fetcher is shared by everything making requests to other sites so rate limits apply across crawlers
+	fetcher := source.NewFetcher(conf.CrawlerCacheDir, logger.With(zap.String("component", "fetcher")))
+	if conf.CrawlerUserAgent != "" {
+		fetcher.UserAgent = conf.CrawlerUserAgent
+	}
+	if conf.CrawlerInterval != 0 {
+		fetcher.Interval = conf.CrawlerInterval
+	}
+
+	return &Server{conf: conf, logger: logger, db: db, fetcher: fetcher}
 }
 
 type Server struct {
-	conf   *Config
-	logger *zap.Logger
-	db     *dbr.Connection
+	conf    *Config
+	logger  *zap.Logger
+	db      *dbr.Connection
+	fetcher *source.Fetcher
 }
 
-func (s *Server) Start() error {
+// Start runs the API, ingest and processors until ctx is cancelled. The API stops accepting connections and
+// in-flight requests are given ShutdownTimeout to finish while ingest and processors finish whatever they are
+// in the middle of. A nil error means everything stopped cleanly.
+func (s *Server) Start(ctx context.Context) error {
 
-	//localize time
-	time.LoadLocation(s.conf.ServerLocation)
-
-	performerStore := &performer.Store{DB: s.db.NewSession(nil), Logger: s.logger}
-	eventStore := &event.Store{DB: s.db.NewSession(nil), PerformerStore: performerStore}
-	venueStore := &venue.Store{DB: s.db.NewSession(nil)}
+	performerStore, eventStore, venueStore := s.newStores()
 	tagStore := &tag.Store{DB: s.db.NewSession(nil)}
+	crawlRunStore := &crawlrun.Store{DB: s.db.NewSession(nil)}
+	quarantineStore := &quarantine.Store{DB: s.db.NewSession(nil)}
+	seriesStore := &series.Store{DB: s.db.NewSession(nil)}
 
-	imageMirror := media.NewImageMirror(s.conf.StaticFilesPath)
+	//tags stored before the current taxonomy need cleaning up
+	if removed, err := tagStore.SyncTaxonomy(); err != nil {
+		s.logger.Error("Failed to sync tag taxonomy", zap.Error(err))
+	} else {
+		s.logger.Info(fmt.Sprintf("Tag taxonomy synced, %d tags merged or removed", removed))
+	}
 
-	if s.conf.CrawlerRun {
-		tz, err := source.MustMakeTimeLocation("Europe/Berlin")
-		if err != nil {
-			panic(err.Error())
-		}
+	if classified, err := eventStore.ClassifyEvents(); err != nil {
+		s.logger.Error("Failed to classify events", zap.Error(err))
+	} else if classified > 0 {
+		s.logger.Info(fmt.Sprintf("Classified %d events", classified))
+	}
 
-		dataIngest := data.Ingest{
-			DB:              s.db.NewSession(nil),
-			UpdateFrequency: time.Duration(1) * time.Hour,
-			Crawlers: []source.Crawler{
-				&sfaktor.Crawler{
-					TermineURI: s.conf.CrawlerStressfaktorURI,
-					Timezone:   tz,
-					Logger:     s.logger.With(zap.String("component", "sfaktor crawler")),
-				},
-				&k9.Crawler{
-					Timezone: tz,
-					Logger:   s.logger.With(zap.String("component", "k9crawler")),
-				},
-			},
-			EventVisitors: []common.EventVisitor{
-				&data.PerformerStoreVisitor{PerformerStore: performerStore, Logger: s.logger},
-				&data.BandcampVisitor{Bandcamp: &bcamp.Bandcamp{HTTP: http.DefaultClient}, Logger: s.logger, ImageMirror: imageMirror},
-			},
-			EventStore:     eventStore,
-			PerformerStore: performerStore,
-			VenueStore:     venueStore,
-			Logger:         s.logger.With(zap.String("component", "ingest")),
-		}
-		go dataIngest.Run()
+	//ingest is always created so it can be triggered via the admin API
+	dataIngest, err := s.NewIngest()
+	if err != nil {
+		return err
+	}
+
+	//sessions
+	if s.conf.EncryptionKey == "" {
+		return fmt.Errorf("you must specify an auth.key")
+	}
+
+	//background work stops with ctx or when the API fails
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	background := sync.WaitGroup{}
+	if s.conf.CrawlerRun {
+		background.Add(3)
+		go func() {
+			defer background.Done()
+			dataIngest.Run(ctx)
+		}()
 
 		//pre-calculate some stats when ingest is running
 
 		//performer activity
 		activityRunner := process.GetActivityRunner(time.Minute*10, s.logger)
-		go activityRunner.Run(s.db.NewSession(nil))
-	}
+		go func() {
+			defer background.Done()
+			activityRunner.Run(ctx, s.db.NewSession(nil))
+		}()
 
-	//sessions
-	if s.conf.EncryptionKey == "" {
-		return fmt.Errorf("you must specify an auth.key")
+		//recurring event series
+		seriesRunner := process.GetSeriesRunner(time.Hour, s.logger)
+		go func() {
+			defer background.Done()
+			seriesRunner.Run(ctx, s.db.NewSession(nil))
+		}()
 	}
 
 	API := v1.API{
-		AppVersion:     Version,
-		EventStore:     eventStore,
-		VenueStore:     venueStore,
-		PerformerStore: performerStore,
-		TagStore:       tagStore,
-		Logger:         s.logger,
+		AppVersion:      Version,
+		EventStore:      eventStore,
+		VenueStore:      venueStore,
+		PerformerStore:  performerStore,
+		TagStore:        tagStore,
+		CrawlRunStore:   crawlRunStore,
+		QuarantineStore: quarantineStore,
+		SeriesStore:     seriesStore,
+		Ingest:          dataIngest,
+		AdminToken:      s.conf.AdminToken,
+		Logger:          s.logger,
 	}
 
 	mux := http.NewServeMux()
@@ -117,6 +160,134 @@ func (s *Server) Start() error {
 	staticFileServer := http.FileServer(http.Dir("static"))
 	mux.Handle("/static/", http.StripPrefix("/static", gziphandler.GzipHandler(staticFileServer)))
 
-	s.logger.Info(fmt.Sprintf("API listening on %s", s.conf.ServerBind))
-	return http.ListenAndServe(s.conf.ServerBind, mux)
+	httpServer := &http.Server{Addr: s.conf.ServerBind, Handler: mux}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		s.logger.Info(fmt.Sprintf("API listening on %s", s.conf.ServerBind))
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err = <-serveErr:
+		//the API failed to start or stopped unexpectedly
+		cancel()
+	case <-ctx.Done():
+		s.logger.Info("Shutting down...")
+
+		shutdownTimeout := s.conf.ShutdownTimeout
+		if shutdownTimeout == 0 {
+			shutdownTimeout = DefaultShutdownTimeout
+		}
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancelShutdown()
+
+		if err = httpServer.Shutdown(shutdownCtx); err != nil {
+			err = fmt.Errorf("failed to drain API requests because %s", err.Error())
+		}
+	}
+
+	background.Wait()
+	return err
+}
+
+// NewIngest creates the ingest with all configured crawlers.
+func (s *Server) NewIngest() (*data.Ingest, error) {
+
+	client := s.crawlerClient()
+	crawlers, err := s.crawlers(client)
+	if err != nil {
+		return nil, err
+	}
+
+	performerStore, eventStore, venueStore := s.newStores()
+
+	return &data.Ingest{
+		DB:              s.db.NewSession(nil),
+		UpdateFrequency: time.Duration(1) * time.Hour,
+		Crawlers:        crawlers,
+		MaxConcurrency:  s.conf.CrawlerConcurrency,
+		MaxBackoff:      s.conf.CrawlerMaxBackoff,
+		EventVisitors: []common.EventVisitor{
+			&data.StatusVisitor{},
+			&data.CategoryVisitor{},
+			&data.DetailsVisitor{},
+			&data.PerformerStoreVisitor{PerformerStore: performerStore, Logger: s.logger},
+			&data.BandcampVisitor{Bandcamp: &bcamp.Bandcamp{HTTP: client}, Logger: s.logger, ImageMirror: media.NewImageMirror(s.conf.StaticFilesPath, client)},
+		},
+		EventStore:      eventStore,
+		PerformerStore:  performerStore,
+		VenueStore:      venueStore,
+		CrawlRunStore:   &crawlrun.Store{DB: s.db.NewSession(nil)},
+		QuarantineStore: &quarantine.Store{DB: s.db.NewSession(nil)},
+		Logger:          s.logger.With(zap.String("component", "ingest")),
+	}, nil
+}
+
+// NewDryRunIngest creates an ingest for data.Ingest.DryRun with all configured crawlers. Only visitors that don't
+// touch the DB or make requests of their own are used so performers are neither resolved nor looked up on
+// Bandcamp (and no images are mirrored).
+func (s *Server) NewDryRunIngest() (*data.Ingest, error) {
+
+	crawlers, err := s.crawlers(s.crawlerClient())
+	if err != nil {
+		return nil, err
+	}
+	return &data.Ingest{
+		Crawlers: crawlers,
+		EventVisitors: []common.EventVisitor{
+			&data.StatusVisitor{},
+			&data.CategoryVisitor{},
+			&data.DetailsVisitor{},
+		},
+		Logger: s.logger.With(zap.String("component", "ingest")),
+	}, nil
+}
+
+// crawlerClient is the client used by crawlers and enrichers.
+func (s *Server) crawlerClient() *http.Client {
+	timeout := s.conf.CrawlerHTTPTimeout
+	if timeout == 0 {
+		timeout = source.DefaultRequestTimeout
+	}
+	return s.fetcher.Client(timeout)
+}
+
+// crawlers builds the configured crawlers.
+func (s *Server) crawlers(client *http.Client) ([]*source.ConfiguredCrawler, error) {
+	crawlerConf, err := s.crawlerConfig()
+	if err != nil {
+		return nil, err
+	}
+	return crawlerConf.Build(s.conf.ServerLocation, client, s.logger)
+}
+
+// NewSnapshot creates a snapshot for exporting and importing the database.
+func (s *Server) NewSnapshot() *snapshot.Snapshot {
+	performerStore, eventStore, venueStore := s.newStores()
+	return &snapshot.Snapshot{
+		DB:             s.db.NewSession(nil),
+		EventStore:     eventStore,
+		VenueStore:     venueStore,
+		PerformerStore: performerStore,
+		TagStore:       &tag.Store{DB: s.db.NewSession(nil)},
+	}
+}
+
+// newStores creates the performer, event and venue stores which depend on each other (e.g. merging venues also
+// merges their events).
+func (s *Server) newStores() (*performer.Store, *event.Store, *venue.Store) {
+	performerStore := &performer.Store{DB: s.db.NewSession(nil), Logger: s.logger}
+	eventStore := &event.Store{DB: s.db.NewSession(nil), PerformerStore: performerStore}
+	venueStore := &venue.Store{DB: s.db.NewSession(nil), EventStore: eventStore}
+	return performerStore, eventStore, venueStore
+}
+
+// crawlerConfig loads the configured crawlers or falls back to the stressfaktor and k9 crawlers if no config
+// file was given.
+func (s *Server) crawlerConfig() (*source.Config, error) {
+	if s.conf.CrawlerConfigPath != "" {
+		return source.LoadConfig(s.conf.CrawlerConfigPath)
+	}
+	return defaultCrawlerConfig(s.conf.CrawlerStressfaktorURI), nil
 }